@@ -0,0 +1,100 @@
+// Command admin runs operator tasks against the application database.
+//
+//	admin delete-user <user-id>        erase a user's data (same as DELETE /me) and print the signed report
+//	admin verify-deletion <report.json> check a report's signature ("-" reads stdin)
+//
+// Both need the same ACCOUNT_DELETION_KEY as the API; delete-user also uses its database and
+// blob store settings.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/samusafe/genericapi/internal/blobstore"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/logging"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+const usage = `usage:
+  admin delete-user <user-id>
+  admin verify-deletion <report.json | ->`
+
+func main() {
+	logging.Init()
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if config.AccountDeletionKey == "" {
+		log.Fatal().Msg("ACCOUNT_DELETION_KEY is required")
+	}
+	key := []byte(config.AccountDeletionKey)
+
+	switch os.Args[1] {
+	case "delete-user":
+		deleteUser(key, os.Args[2])
+	case "verify-deletion":
+		verifyDeletion(key, os.Args[2])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func deleteUser(key []byte, userID string) {
+	if err := database.Connect(); err != nil {
+		log.Fatal().Err(err).Msg("database connection failed")
+	}
+	blobs, err := blobstore.FromConfig()
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid blob store configuration")
+	}
+	service := services.NewAccountService(repositories.NewAccountRepository(), repositories.NewGuardedBlobStore(blobs), key)
+	report, err := service.DeleteAccount(context.Background(), userID, models.DeletionRequestedByAdmin)
+	if err != nil {
+		log.Fatal().Err(err).Msg("account deletion failed")
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Fatal().Err(err).Msg("write report")
+	}
+}
+
+func verifyDeletion(key []byte, path string) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatal().Err(err).Msg("open report")
+		}
+		defer f.Close()
+		r = f
+	}
+	// Accept both the bare report and the API response envelope ({"data": report}).
+	var doc struct {
+		models.DeletionReport
+		Data *models.DeletionReport `json:"data"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		log.Fatal().Err(err).Msg("decode report")
+	}
+	report := &doc.DeletionReport
+	if doc.Data != nil {
+		report = doc.Data
+	}
+	if !services.VerifyDeletionReport(key, report) {
+		fmt.Println("INVALID signature")
+		os.Exit(1)
+	}
+	fmt.Printf("valid: report %s deleted %d documents at %s\n", report.ID, report.Counts.Documents, report.DeletedAt.Format("2006-01-02T15:04:05Z07:00"))
+}
@@ -26,7 +26,9 @@ func main() {
 		log.Fatal().Err(err).Msg("database connection failed")
 	}
 
-	r := routes.SetupRouter()
+	// Background workers (analysis jobs) live until shutdown is initiated
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	r, waitWorkers := routes.SetupRouter(workersCtx)
 	port := config.Port
 
 	srv := &http.Server{Addr: ":" + port, Handler: r}
@@ -55,5 +57,8 @@ func main() {
 	} else {
 		log.Info().Msg("server stopped cleanly")
 	}
+	// In-flight job files are released back to the queue by their workers
+	stopWorkers()
+	waitWorkers()
 	log.Info().Dur("uptime", time.Since(start)).Msg("process exit")
 }
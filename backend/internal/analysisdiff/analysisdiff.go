@@ -0,0 +1,59 @@
+// Package analysisdiff compares two analysis versions of a document.
+package analysisdiff
+
+import (
+	"github.com/samusafe/genericapi/internal/grading"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+// Compare reports what changed from one version to the other. Keywords, summary points and the
+// summary are matched after grading.Normalize, so case, accents and punctuation do not count as
+// changes; the lists keep the wording of the version they come from.
+func Compare(from, to models.AnalysisVersion) models.AnalysisDiff {
+	return models.AnalysisDiff{
+		From:           from.Version,
+		To:             to.Version,
+		Keywords:       compareLists(from.Keywords, to.Keywords),
+		SummaryPoints:  compareLists(from.SummaryPoints, to.SummaryPoints),
+		Sentiment:      models.SentimentDiff{From: from.Sentiment, To: to.Sentiment, Changed: grading.Normalize(from.Sentiment) != grading.Normalize(to.Sentiment)},
+		SummaryChanged: grading.Normalize(from.Summary) != grading.Normalize(to.Summary),
+	}
+}
+
+// compareLists splits the entries of both lists, deduplicated, in the order they appear.
+func compareLists(from, to []string) models.ListDiff {
+	diff := models.ListDiff{Added: []string{}, Removed: []string{}, Unchanged: []string{}}
+	old := keySet(from)
+	seen := map[string]bool{}
+	for _, s := range to {
+		k := grading.Normalize(s)
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		if old[k] {
+			diff.Unchanged = append(diff.Unchanged, s)
+		} else {
+			diff.Added = append(diff.Added, s)
+		}
+	}
+	for _, s := range from {
+		k := grading.Normalize(s)
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		diff.Removed = append(diff.Removed, s)
+	}
+	return diff
+}
+
+func keySet(list []string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, s := range list {
+		if k := grading.Normalize(s); k != "" {
+			set[k] = true
+		}
+	}
+	return set
+}
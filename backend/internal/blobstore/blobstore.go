@@ -0,0 +1,55 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/samusafe/genericapi/internal/config"
+)
+
+// Original uploads are kept in a blob store keyed by their sha256 content hash, so identical
+// uploads (from any user) share one blob. Access control lives on the documents table: a blob
+// is only ever served through a document the caller owns.
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore persists immutable blobs by key.
+type BlobStore interface {
+	// Put stores the size bytes read from r under key; an existing key is left untouched
+	// (content-addressed).
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get opens the blob and reports its size. Returns ErrNotFound for unknown keys.
+	Get(ctx context.Context, key string) (io.ReadCloser, int64, error)
+	// Delete removes the blob; unknown keys are not an error. Callers must make sure no document
+	// still references the key, since blobs are shared.
+	Delete(ctx context.Context, key string) error
+}
+
+// FromConfig builds the store selected by BLOB_STORE (local | s3).
+func FromConfig() (BlobStore, error) {
+	switch config.BlobStore {
+	case "local":
+		return NewLocal(config.BlobLocalDir), nil
+	case "s3":
+		if config.S3Endpoint == "" || config.S3Bucket == "" {
+			return nil, errors.New("BLOB_STORE=s3 requires S3_ENDPOINT and S3_BUCKET")
+		}
+		return NewS3(config.S3Endpoint, config.S3Region, config.S3Bucket, config.S3AccessKey, config.S3SecretKey, nil), nil
+	}
+	return nil, fmt.Errorf("unknown BLOB_STORE %q", config.BlobStore)
+}
+
+// validKey accepts the hex content hashes we use as keys (and nothing that could escape a path).
+func validKey(key string) bool {
+	if len(key) < 4 {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
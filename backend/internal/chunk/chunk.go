@@ -0,0 +1,73 @@
+package chunk
+
+import "strings"
+
+// Splitting of document text into overlapping passages for retrieval.
+// Offsets are in characters (Unicode code points), the unit the Python service uses for answer
+// spans, so a span inside a passage maps to Start+offset in the document.
+
+// Chunk is one passage of a document.
+type Chunk struct {
+	Index int
+	Start int
+	End   int
+	Text  string
+}
+
+// Split cuts text into passages of at most size characters, preferring to end at a paragraph,
+// sentence or word boundary in the second half of the window. Consecutive passages overlap by
+// about overlap characters, starting at a word boundary.
+func Split(text string, size, overlap int) []Chunk {
+	runes := []rune(text)
+	if size <= 0 || strings.TrimSpace(text) == "" {
+		return nil
+	}
+	overlap = max(0, min(overlap, size/2))
+
+	var out []Chunk
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			end = boundary(runes, start+size/2, end)
+		}
+		if part := string(runes[start:end]); strings.TrimSpace(part) != "" {
+			out = append(out, Chunk{Index: len(out), Start: start, End: end, Text: part})
+		}
+		if end == len(runes) {
+			break
+		}
+		next := end - overlap
+		for next < end && next > start && !isSpace(runes[next-1]) {
+			next++
+		}
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return out
+}
+
+// boundary returns the best cut in runes[from:to]: after a blank line, then after sentence
+// punctuation or a newline, then after a space, else to.
+func boundary(runes []rune, from, to int) int {
+	best := [3]int{-1, -1, -1}
+	for i := to - 1; i > from; i-- {
+		switch {
+		case best[0] < 0 && runes[i] == '\n' && runes[i-1] == '\n':
+			best[0] = i + 1
+		case best[1] < 0 && isSpace(runes[i]) && strings.ContainsRune(".!?\n", runes[i-1]):
+			best[1] = i + 1
+		case best[2] < 0 && isSpace(runes[i]):
+			best[2] = i + 1
+		}
+	}
+	for _, b := range best {
+		if b > 0 {
+			return b
+		}
+	}
+	return to
+}
+
+func isSpace(r rune) bool { return r == ' ' || r == '\n' || r == '\t' || r == '\r' }
@@ -13,7 +13,34 @@ const (
 	defaultRateLimitBurst      = 5
 	defaultHTTPClientTimeout   = 90 * time.Second
 	defaultMaxUploadBytes      = 5 * 1024 * 1024 // 5MB
+	defaultMultipartMemory     = 2 * 1024 * 1024 // larger form parts spill to temp files
 	defaultQuizMaxChars        = 100_000
+	defaultQuizQuestions       = 5
+	defaultQuizMaxQuestions    = 20
+	defaultSearchMaxChars      = 256
+	defaultChunkChars          = 1000
+	defaultChunkOverlap        = 150
+	defaultAskContextChunks    = 5
+	defaultAskMaxChars         = 500
+	defaultQuizPassPercent     = 60
+	defaultQuizMaxAnswerChars  = 2000
+	defaultJobWorkers          = 2
+	defaultJobPollInterval     = 2 * time.Second
+	defaultJobLeaseTimeout     = 5 * time.Minute
+	defaultJobMaxUploadBytes   = 5 * 1024 * 1024 // 5MB
+	defaultAnalysisConcurrency = 8
+	defaultAnalysisPerUser     = 2
+	defaultRetryMaxAttempts    = 3
+	defaultRetryBaseDelayMs    = 200
+	defaultRetryMaxDelayMs     = 2000
+	defaultBreakerThreshold    = 5
+	defaultBreakerCooldown     = 30 * time.Second
+	defaultScannerTimeout      = 30 * time.Second
+	defaultS3Timeout           = 5 * time.Minute
+	defaultWorkspaceImportMax  = 100 * 1024 * 1024
+	defaultModelVersionTTL     = 60 * time.Second
+	defaultInsightsKeywords    = 20
+	defaultInsightsSummaryMax  = 20_000
 	SwaggerAlwaysEnabled       = true // serve swagger endpoints unconditionally
 )
 
@@ -23,8 +50,51 @@ var (
 	RateLimitBurst    = utils.IntFromEnv("RATE_LIMIT_BURST", defaultRateLimitBurst)
 	HTTPClientTimeout = utils.DurationFromEnvSeconds("HTTP_CLIENT_TIMEOUT_SECONDS", defaultHTTPClientTimeout)
 	MaxUploadBytes    = int64(utils.IntFromEnv("MAX_UPLOAD_BYTES", int(defaultMaxUploadBytes)))
+	MultipartMemory   = int64(utils.IntFromEnv("MULTIPART_MEMORY_BYTES", int(defaultMultipartMemory)))
 	QuizMaxChars      = utils.IntFromEnv("QUIZ_MAX_CHARS", defaultQuizMaxChars)
+	QuizQuestions     = utils.IntFromEnv("QUIZ_DEFAULT_QUESTIONS", defaultQuizQuestions)
+	QuizMaxQuestions  = utils.IntFromEnv("QUIZ_MAX_QUESTIONS", defaultQuizMaxQuestions)
+	SearchMaxChars    = utils.IntFromEnv("SEARCH_MAX_QUERY_CHARS", defaultSearchMaxChars)
 	SwaggerUIVersion  = utils.UseEnvOrDefault("SWAGGER_UI_VERSION", "5.17.14")
+	JobWorkers        = utils.IntFromEnv("JOB_WORKERS", defaultJobWorkers)
+	JobPollInterval   = utils.DurationFromEnvSeconds("JOB_POLL_INTERVAL_SECONDS", defaultJobPollInterval)
+	JobLeaseTimeout   = utils.DurationFromEnvSeconds("JOB_LEASE_SECONDS", defaultJobLeaseTimeout)
+	// Job uploads are read into memory to be stored as BYTEA (and again by the worker), so they get
+	// their own total-size cap on top of MAX_UPLOAD_BYTES
+	JobMaxUploadBytes = int64(utils.IntFromEnv("JOB_MAX_UPLOAD_BYTES", int(defaultJobMaxUploadBytes)))
+
+	// Outbound analysis calls (process-wide cap and per-user share of it)
+	AnalysisMaxConcurrency     = utils.IntFromEnv("ANALYSIS_MAX_CONCURRENCY", defaultAnalysisConcurrency)
+	AnalysisPerUserConcurrency = utils.IntFromEnv("ANALYSIS_PER_USER_CONCURRENCY", defaultAnalysisPerUser)
+
+	// Python client resilience (retries for idempotent failures, circuit breaker)
+	PythonRetryMaxAttempts = utils.IntFromEnv("PYTHON_RETRY_MAX_ATTEMPTS", defaultRetryMaxAttempts)
+	PythonRetryBaseDelay   = time.Duration(utils.IntFromEnv("PYTHON_RETRY_BASE_DELAY_MS", defaultRetryBaseDelayMs)) * time.Millisecond
+	PythonRetryMaxDelay    = time.Duration(utils.IntFromEnv("PYTHON_RETRY_MAX_DELAY_MS", defaultRetryMaxDelayMs)) * time.Millisecond
+	PythonBreakerThreshold = utils.IntFromEnv("PYTHON_BREAKER_FAILURE_THRESHOLD", defaultBreakerThreshold)
+	PythonBreakerCooldown  = utils.DurationFromEnvSeconds("PYTHON_BREAKER_COOLDOWN_SECONDS", defaultBreakerCooldown)
+
+	// How long a backend's current model version (GET /models) is trusted before asking again
+	ModelVersionTTL = utils.DurationFromEnvSeconds("MODEL_VERSION_CACHE_SECONDS", defaultModelVersionTTL)
+
+	// Question answering over collections (passages cut at analysis time, retrieved per question)
+	ChunkChars       = utils.IntFromEnv("CHUNK_CHARS", defaultChunkChars)
+	ChunkOverlap     = utils.IntFromEnv("CHUNK_OVERLAP_CHARS", defaultChunkOverlap)
+	AskContextChunks = utils.IntFromEnv("ASK_CONTEXT_CHUNKS", defaultAskContextChunks)
+	AskMaxChars      = utils.IntFromEnv("ASK_MAX_QUESTION_CHARS", defaultAskMaxChars)
+
+	// Quiz attempts (an answer is accepted when its token overlap with the expected one reaches
+	// the pass score) and spaced-repetition reviews
+	QuizPassScore      = float64(utils.IntFromEnv("QUIZ_PASS_SCORE_PERCENT", defaultQuizPassPercent)) / 100
+	QuizMaxAnswerChars = utils.IntFromEnv("QUIZ_MAX_ANSWER_CHARS", defaultQuizMaxAnswerChars)
+
+	// Collection insights: keywords listed, and the longest text (concatenated document summaries)
+	// sent to the backend for the meta-summary
+	InsightsTopKeywords     = utils.IntFromEnv("INSIGHTS_TOP_KEYWORDS", defaultInsightsKeywords)
+	InsightsSummaryMaxChars = utils.IntFromEnv("INSIGHTS_SUMMARY_MAX_CHARS", defaultInsightsSummaryMax)
+
+	// Workspace import (POST /me/import): largest accepted archive body, compressed or not (100MB)
+	WorkspaceImportMaxBytes = int64(utils.IntFromEnv("WORKSPACE_IMPORT_MAX_BYTES", int(defaultWorkspaceImportMax)))
 )
 
 // Core string settings
@@ -32,6 +102,37 @@ var (
 	Port             = utils.UseEnvOrDefault("BACKEND_PORT", "8080")
 	PythonServiceURL = utils.UseEnvOrDefault("PYTHON_SERVICE_URL", "http://python:5000")
 	DatabaseURL      = utils.UseEnvOrDefault("DATABASE_URL", "postgres://postgres:postgres@db:5432/docanalyzer?sslmode=disable")
+
+	// Extra analysis backends ("name=url,name=url"), optional JSON file with backends + routing rules
+	AnalysisBackends       = utils.UseEnvOrDefault("ANALYSIS_BACKENDS", "")
+	AnalysisBackendsFile   = utils.UseEnvOrDefault("ANALYSIS_BACKENDS_FILE", "")
+	AnalysisDefaultBackend = utils.UseEnvOrDefault("ANALYSIS_DEFAULT_BACKEND", "")
+
+	// Local .txt/.md extraction: strip Markdown syntax before analysis (set "false" to keep it)
+	ExtractStripMarkdown = utils.UseEnvOrDefault("EXTRACT_STRIP_MARKDOWN", "true") != "false"
+
+	// Malware scanning (clamd at "tcp://host:3310" or "unix:///path/clamd.sock"; empty disables).
+	// With SCANNER_FAIL_OPEN=true uploads are accepted when the scanner cannot be reached.
+	ScannerAddr     = utils.UseEnvOrDefault("SCANNER_ADDR", "")
+	ScannerTimeout  = utils.DurationFromEnvSeconds("SCANNER_TIMEOUT_SECONDS", defaultScannerTimeout)
+	ScannerFailOpen = utils.UseEnvOrDefault("SCANNER_FAIL_OPEN", "false") == "true"
+
+	// Original upload storage: "local" (BLOB_LOCAL_DIR) or "s3" (any S3-compatible endpoint, e.g. MinIO).
+	// S3_TIMEOUT_SECONDS bounds each S3 request, including streaming the body of an upload or download.
+	BlobStore    = utils.UseEnvOrDefault("BLOB_STORE", "local")
+	BlobLocalDir = utils.UseEnvOrDefault("BLOB_LOCAL_DIR", "data/blobs")
+	S3Endpoint   = utils.UseEnvOrDefault("S3_ENDPOINT", "")
+	S3Region     = utils.UseEnvOrDefault("S3_REGION", "us-east-1")
+	S3Bucket     = utils.UseEnvOrDefault("S3_BUCKET", "")
+	S3AccessKey  = utils.UseEnvOrDefault("S3_ACCESS_KEY", "")
+	S3SecretKey  = utils.UseEnvOrDefault("S3_SECRET_KEY", "")
+	S3Timeout    = utils.DurationFromEnvSeconds("S3_TIMEOUT_SECONDS", defaultS3Timeout)
+
+	// Export templates: files here (document.md.tmpl, document.html.tmpl) replace the built-in ones
+	ExportTemplatesDir = utils.UseEnvOrDefault("EXPORT_TEMPLATES_DIR", "")
+
+	// Account deletion: HMAC key signing deletion reports and hashing user IDs in the audit trail
+	AccountDeletionKey = utils.UseEnvOrDefault("ACCOUNT_DELETION_KEY", "")
 )
 
 // Supported static lists
@@ -40,6 +141,8 @@ var (
 	SupportedFileTypes = []string{".txt", ".md", ".pdf", ".docx"}
 	AllowedOrigins     = loadAllowedOrigins()
 	AllowedPageLimits  = []int{10, 25, 50}
+	QuizTypes          = []string{"open", "multiple_choice", "true_false", "cloze"}
+	QuizDifficulties   = []string{"easy", "medium", "hard"}
 )
 
 func loadAllowedOrigins() []string {
@@ -0,0 +1,33 @@
+-- Asynchronous analysis jobs submitted through POST /analyze/jobs.
+
+-- One row per submitted upload request.
+CREATE TABLE IF NOT EXISTS analysis_jobs (
+    id UUID PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    lang TEXT NOT NULL,
+    collection_id INT REFERENCES collections(id) ON DELETE SET NULL,
+    correlation_id TEXT,
+    file_count INT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- One row per uploaded file; the raw bytes are kept only until the file has been processed.
+-- status: queued -> running -> done | failed
+CREATE TABLE IF NOT EXISTS analysis_job_files (
+    id SERIAL PRIMARY KEY,
+    job_id UUID NOT NULL REFERENCES analysis_jobs(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    file_name TEXT NOT NULL,
+    content BYTEA,
+    status TEXT NOT NULL DEFAULT 'queued',
+    result JSONB,
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- --- INDEXES ---
+
+CREATE INDEX IF NOT EXISTS analysis_jobs_user_created_at_idx ON analysis_jobs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS analysis_job_files_job_position_idx ON analysis_job_files(job_id, position);
+
+-- Workers only ever scan unfinished files.
+CREATE INDEX IF NOT EXISTS analysis_job_files_pending_idx ON analysis_job_files(status, id) WHERE status IN ('queued', 'running');
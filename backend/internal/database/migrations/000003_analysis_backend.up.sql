@@ -0,0 +1,6 @@
+-- Name of the analysis backend (engine) that produced each analysis.
+-- Rows created before backends were configurable came from the default Python service.
+ALTER TABLE analyses ADD COLUMN IF NOT EXISTS backend TEXT NOT NULL DEFAULT 'python';
+
+-- Backend explicitly requested for an async job ('' lets the routing rules decide).
+ALTER TABLE analysis_jobs ADD COLUMN IF NOT EXISTS backend TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Declared extension and the content type sniffed from the upload's magic bytes.
+-- Documents stored before sniffing existed keep NULL in both columns.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS file_ext TEXT;
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS detected_type TEXT;
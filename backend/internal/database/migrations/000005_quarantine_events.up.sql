@@ -0,0 +1,19 @@
+-- Audit trail of uploads the malware scanner rejected.
+-- The file itself is never stored; only its hash and the scanner's verdict.
+-- verdict: infected
+CREATE TABLE IF NOT EXISTS quarantine_events (
+    id SERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    file_name TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    scanner TEXT NOT NULL,
+    verdict TEXT NOT NULL,
+    signature TEXT,
+    correlation_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- --- INDEXES ---
+
+CREATE INDEX IF NOT EXISTS quarantine_events_user_created_at_idx ON quarantine_events(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS quarantine_events_content_hash_idx ON quarantine_events(content_hash);
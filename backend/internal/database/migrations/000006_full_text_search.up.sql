@@ -0,0 +1,33 @@
+-- Full-text search over document text and analysis summaries/keywords.
+-- Stemming is language specific, so each supported language gets its own generated column and
+-- the query picks the one matching the request language. Adding a language means a new column
+-- pair here plus an entry in the search repository.
+
+-- array_to_string is only STABLE; generated columns need an IMMUTABLE expression.
+CREATE OR REPLACE FUNCTION search_keywords(TEXT[]) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE
+    AS $$ SELECT COALESCE(array_to_string($1, ' '), '') $$;
+
+-- File name weighs more than the body so exact title hits rank first.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS search_en tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(file_name, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(full_text, '')), 'C')
+) STORED;
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS search_pt tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('portuguese', COALESCE(file_name, '')), 'A') ||
+    setweight(to_tsvector('portuguese', COALESCE(full_text, '')), 'C')
+) STORED;
+
+ALTER TABLE analyses ADD COLUMN IF NOT EXISTS search_en tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', search_keywords(keywords)), 'A') ||
+    setweight(to_tsvector('english', COALESCE(summary, '')), 'B')
+) STORED;
+ALTER TABLE analyses ADD COLUMN IF NOT EXISTS search_pt tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('portuguese', search_keywords(keywords)), 'A') ||
+    setweight(to_tsvector('portuguese', COALESCE(summary, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS documents_search_en_idx ON documents USING GIN (search_en);
+CREATE INDEX IF NOT EXISTS documents_search_pt_idx ON documents USING GIN (search_pt);
+CREATE INDEX IF NOT EXISTS analyses_search_en_idx ON analyses USING GIN (search_en);
+CREATE INDEX IF NOT EXISTS analyses_search_pt_idx ON analyses USING GIN (search_pt);
@@ -0,0 +1,12 @@
+-- Document embeddings for "related documents".
+-- Stored as a plain REAL[] because the stock postgres image has no pgvector; similarity is
+-- computed in Go over one user's documents. Only vectors from the same model are compared.
+CREATE TABLE IF NOT EXISTS document_embeddings (
+    document_id INT PRIMARY KEY REFERENCES documents(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    model TEXT NOT NULL,
+    embedding REAL[] NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS document_embeddings_user_model_idx ON document_embeddings(user_id, model);
@@ -0,0 +1,18 @@
+-- Passages of each document's full_text, cut once at analysis time for question answering.
+-- Offsets are character positions in documents.full_text so answers can cite exact spans.
+-- Search columns mirror 000006 (one per supported language).
+CREATE TABLE IF NOT EXISTS document_chunks (
+    id BIGSERIAL PRIMARY KEY,
+    document_id INT NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    chunk_index INT NOT NULL,
+    start_offset INT NOT NULL,
+    end_offset INT NOT NULL,
+    content TEXT NOT NULL,
+    search_en tsvector GENERATED ALWAYS AS (to_tsvector('english', content)) STORED,
+    search_pt tsvector GENERATED ALWAYS AS (to_tsvector('portuguese', content)) STORED,
+    UNIQUE (document_id, chunk_index)
+);
+
+CREATE INDEX IF NOT EXISTS document_chunks_search_en_idx ON document_chunks USING GIN (search_en);
+CREATE INDEX IF NOT EXISTS document_chunks_search_pt_idx ON document_chunks USING GIN (search_pt);
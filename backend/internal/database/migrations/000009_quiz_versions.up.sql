@@ -0,0 +1,6 @@
+-- Generated quizzes are persisted per analysis. Regenerating adds a new version and keeps the
+-- earlier ones; position keeps the question order within a version.
+ALTER TABLE quiz_questions ADD COLUMN IF NOT EXISTS quiz_version INT NOT NULL DEFAULT 1;
+ALTER TABLE quiz_questions ADD COLUMN IF NOT EXISTS position INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS quiz_questions_analysis_version_idx ON quiz_questions(analysis_id, quiz_version, position);
@@ -0,0 +1,39 @@
+-- Quiz attempts: every submission is kept together with its graded answers (the per-question
+-- correctness history), and each answered question gets an SM-2 review schedule per user.
+CREATE TABLE IF NOT EXISTS quiz_attempts (
+    id SERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    analysis_id INT NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+    quiz_version INT NOT NULL,
+    correct INT NOT NULL,
+    total INT NOT NULL,
+    score REAL NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS quiz_answers (
+    id SERIAL PRIMARY KEY,
+    attempt_id INT NOT NULL REFERENCES quiz_attempts(id) ON DELETE CASCADE,
+    question_id INT NOT NULL REFERENCES quiz_questions(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    correct BOOLEAN NOT NULL,
+    score REAL NOT NULL,
+    quality SMALLINT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS review_schedule (
+    user_id TEXT NOT NULL,
+    question_id INT NOT NULL REFERENCES quiz_questions(id) ON DELETE CASCADE,
+    repetitions INT NOT NULL DEFAULT 0,
+    interval_days INT NOT NULL DEFAULT 0,
+    ease REAL NOT NULL DEFAULT 2.5,
+    due_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (user_id, question_id)
+);
+
+CREATE INDEX IF NOT EXISTS quiz_attempts_user_analysis_idx ON quiz_attempts(user_id, analysis_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS quiz_answers_question_idx ON quiz_answers(user_id, question_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS review_schedule_due_idx ON review_schedule(user_id, due_at);
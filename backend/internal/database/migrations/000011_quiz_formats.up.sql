@@ -0,0 +1,10 @@
+-- Quiz formats: each question has a type (open, multiple_choice, true_false, cloze);
+-- multiple-choice questions keep their options and the index of the correct one.
+-- Every question row also records the rest of the request that produced its version
+-- (difficulty, requested count), so a stored quiz is only reused for the same request.
+-- Existing quizzes were generated as 5 open questions.
+ALTER TABLE quiz_questions ADD COLUMN IF NOT EXISTS question_type TEXT NOT NULL DEFAULT 'open';
+ALTER TABLE quiz_questions ADD COLUMN IF NOT EXISTS options TEXT[];
+ALTER TABLE quiz_questions ADD COLUMN IF NOT EXISTS correct_index INT;
+ALTER TABLE quiz_questions ADD COLUMN IF NOT EXISTS difficulty TEXT NOT NULL DEFAULT 'medium';
+ALTER TABLE quiz_questions ADD COLUMN IF NOT EXISTS requested_count INT NOT NULL DEFAULT 5;
@@ -0,0 +1,16 @@
+-- Audit trail of account deletions (DELETE /me and the admin command).
+-- No personal content is kept: the user is identified only by a keyed hash of the user ID
+-- (the same value as the signed report's subject), next to the deletion counts.
+-- requested_by: user | admin
+CREATE TABLE IF NOT EXISTS account_deletions (
+    id UUID PRIMARY KEY,
+    subject TEXT NOT NULL,
+    requested_by TEXT NOT NULL,
+    counts JSONB NOT NULL,
+    signature TEXT NOT NULL,
+    deleted_at TIMESTAMPTZ NOT NULL
+);
+
+-- --- INDEXES ---
+
+CREATE INDEX IF NOT EXISTS account_deletions_subject_idx ON account_deletions(subject);
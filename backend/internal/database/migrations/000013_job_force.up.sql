@@ -0,0 +1,3 @@
+-- Jobs submitted with force=true analyze every file again instead of reusing an earlier analysis
+-- of the same content.
+ALTER TABLE analysis_jobs ADD COLUMN IF NOT EXISTS force BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,4 @@
+-- Provenance reported by the analysis backend with every result: model names and versions,
+-- detected language, token counts and per-stage timings. NULL for analyses stored before the
+-- backend reported it; those are treated as coming from an older model and are not reused.
+ALTER TABLE analyses ADD COLUMN IF NOT EXISTS meta JSONB;
@@ -0,0 +1,23 @@
+-- Files of a multi-file upload (or job) that produced no analysis, so a batch can be audited:
+-- unsupported or mismatched files, scanner rejections, backend failures, cancellations.
+-- error_key is the message key of the error; backend and force are the options requested
+-- (an empty backend means the routing rules decided).
+CREATE TABLE IF NOT EXISTS analysis_failures (
+    id SERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    batch_id UUID NOT NULL,
+    batch_size INT NOT NULL,
+    collection_id INT REFERENCES collections(id) ON DELETE SET NULL,
+    file_name TEXT NOT NULL,
+    content_hash TEXT,
+    error_key TEXT NOT NULL,
+    backend TEXT NOT NULL DEFAULT '',
+    force BOOLEAN NOT NULL DEFAULT false,
+    correlation_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- --- INDEXES ---
+
+CREATE INDEX IF NOT EXISTS analysis_failures_batch_id_idx ON analysis_failures(batch_id);
+CREATE INDEX IF NOT EXISTS analysis_failures_user_created_at_idx ON analysis_failures(user_id, created_at DESC);
@@ -0,0 +1,10 @@
+-- Failed files of a batch can be retried (POST /batches/:id/retry). content_stored means the
+-- upload was kept in the blob store under content_hash (only for transient errors such as an
+-- unavailable backend); resolved_at is set once the file was retried, whose outcome is then a new
+-- analysis or a new failure of the same batch.
+ALTER TABLE analysis_failures ADD COLUMN IF NOT EXISTS content_stored BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE analysis_failures ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMPTZ;
+
+-- Blobs still needed by a pending retry are kept when documents are deleted
+CREATE INDEX IF NOT EXISTS analysis_failures_pending_content_idx ON analysis_failures(content_hash)
+    WHERE content_stored AND resolved_at IS NULL;
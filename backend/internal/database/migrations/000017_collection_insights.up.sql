@@ -0,0 +1,12 @@
+-- Cached GET /collections/:id/insights. fingerprint identifies the documents of the collection
+-- and their latest analysis: adding, moving, deleting or re-analyzing a document changes it, and
+-- the insights are computed again. summary (the optional meta-summary) is NULL until requested.
+-- Rows go with their collection (including on account deletion).
+CREATE TABLE IF NOT EXISTS collection_insights (
+    collection_id INT PRIMARY KEY REFERENCES collections(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    insights JSONB NOT NULL,
+    summary TEXT,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,4 @@
+-- A retry claims a failure for a while instead of resolving it up front: resolved_at is only set
+-- once the file was analyzed, a retry that fails again clears the claim (with the new error), and
+-- a claim left behind by a crashed process expires so the file can be retried again.
+ALTER TABLE analysis_failures ADD COLUMN IF NOT EXISTS retry_claimed_at TIMESTAMPTZ;
@@ -0,0 +1,19 @@
+-- A tsvector is limited to 1MB, so indexing the whole text made inserting a large document fail.
+-- Only the first 60k characters are indexed. The limit counts bytes: a character takes up to 4
+-- bytes in UTF-8 and each distinct lexeme costs about 9 more for its entry and position, so the
+-- worst case is under 240KB of lexeme text plus 30k entries (one-character words), about 510KB.
+-- Generated columns cannot be altered, so they are dropped (with their indexes) and added again.
+ALTER TABLE documents DROP COLUMN IF EXISTS search_en;
+ALTER TABLE documents DROP COLUMN IF EXISTS search_pt;
+
+ALTER TABLE documents ADD COLUMN search_en tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(file_name, '')), 'A') ||
+    setweight(to_tsvector('english', left(COALESCE(full_text, ''), 60000)), 'C')
+) STORED;
+ALTER TABLE documents ADD COLUMN search_pt tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('portuguese', COALESCE(file_name, '')), 'A') ||
+    setweight(to_tsvector('portuguese', left(COALESCE(full_text, ''), 60000)), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS documents_search_en_idx ON documents USING GIN (search_en);
+CREATE INDEX IF NOT EXISTS documents_search_pt_idx ON documents USING GIN (search_pt);
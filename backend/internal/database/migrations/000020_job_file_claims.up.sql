@@ -0,0 +1,4 @@
+-- Every claim of a job file gets a new attempt number. Completing, releasing or renewing a file
+-- only succeeds for the attempt that still holds it, so a worker whose lease expired and was
+-- requeued cannot overwrite the result of the worker that claimed the file after it.
+ALTER TABLE analysis_job_files ADD COLUMN IF NOT EXISTS attempt INT NOT NULL DEFAULT 0;
@@ -0,0 +1,271 @@
+// Package export renders analyses for download: a single document as Markdown, HTML or JSON,
+// and a whole collection as a ZIP archive (one file per document plus a manifest) or a CSV table.
+//
+// The Markdown and HTML templates are embedded; a file with the same name in
+// EXPORT_TEMPLATES_DIR (document.md.tmpl, document.html.tmpl) replaces the built-in one.
+// Templates receive a Document.
+package export
+
+import (
+	"archive/zip"
+	"embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	"unicode"
+
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/i18n"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+//go:embed templates/*.tmpl
+var builtin embed.FS
+
+const (
+	markdownTemplate = "document.md.tmpl"
+	htmlTemplate     = "document.html.tmpl"
+)
+
+// Document formats and collection formats.
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+	FormatZip      = "zip"
+	FormatCSV      = "csv"
+)
+
+var contentTypes = map[string]string{
+	FormatMarkdown: "text/markdown; charset=utf-8",
+	FormatHTML:     "text/html; charset=utf-8",
+	FormatJSON:     "application/json; charset=utf-8",
+	FormatZip:      "application/zip",
+	FormatCSV:      "text/csv; charset=utf-8",
+}
+
+// ContentType of an export format.
+func ContentType(format string) string { return contentTypes[format] }
+
+// IsDocumentFormat reports whether format can render a single document.
+func IsDocumentFormat(format string) bool {
+	return format == FormatMarkdown || format == FormatHTML || format == FormatJSON
+}
+
+// labelKeys are the translated headings available to templates as .Labels.<key>
+// (i18n key "Export<key>").
+var labelKeys = []string{"Summary", "KeyPoints", "Keywords", "Details", "Sentiment", "Collection", "AnalyzedAt", "Backend", "DocumentID", "AnalysisID", "ExportedAt"}
+
+// Document is the data handed to the templates.
+type Document struct {
+	Analysis       *models.AnalysisDetail
+	CollectionName string
+	Lang           string
+	Labels         map[string]string
+	ExportedAt     time.Time
+}
+
+// NewDocument prepares an analysis for rendering in lang.
+func NewDocument(analysis *models.AnalysisDetail, collectionName, lang string, exportedAt time.Time) *Document {
+	labels := make(map[string]string, len(labelKeys))
+	for _, k := range labelKeys {
+		labels[k] = i18n.GetMessage(lang, "Export"+k)
+	}
+	return &Document{Analysis: analysis, CollectionName: collectionName, Lang: lang, Labels: labels, ExportedAt: exportedAt.UTC()}
+}
+
+// Renderer holds the parsed templates; it is safe for concurrent use.
+type Renderer struct {
+	markdown *texttemplate.Template
+	html     *htmltemplate.Template
+}
+
+var funcs = map[string]any{"join": strings.Join}
+
+// New parses the templates, preferring overrides found in dir (may be empty).
+func New(dir string) (*Renderer, error) {
+	md, err := readTemplate(dir, markdownTemplate)
+	if err != nil {
+		return nil, err
+	}
+	html, err := readTemplate(dir, htmlTemplate)
+	if err != nil {
+		return nil, err
+	}
+	r := &Renderer{}
+	if r.markdown, err = texttemplate.New(markdownTemplate).Funcs(funcs).Parse(md); err != nil {
+		return nil, err
+	}
+	if r.html, err = htmltemplate.New(htmlTemplate).Funcs(funcs).Parse(html); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// FromConfig builds the renderer with the templates of config.ExportTemplatesDir.
+func FromConfig() (*Renderer, error) { return New(config.ExportTemplatesDir) }
+
+func readTemplate(dir, name string) (string, error) {
+	if dir != "" {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(b), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	b, err := builtin.ReadFile("templates/" + name)
+	return string(b), err
+}
+
+// jsonDocument is the JSON export: the analysis without the extracted text.
+type jsonDocument struct {
+	*models.AnalysisDetail
+	FullText       string    `json:"fullText,omitempty"`
+	CollectionName string    `json:"collectionName,omitempty"`
+	ExportedAt     time.Time `json:"exportedAt"`
+}
+
+// RenderDocument writes doc in one of the document formats.
+func (r *Renderer) RenderDocument(w io.Writer, format string, doc *Document) error {
+	switch format {
+	case FormatMarkdown:
+		return r.markdown.Execute(w, doc)
+	case FormatHTML:
+		return r.html.Execute(w, doc)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(jsonDocument{AnalysisDetail: doc.Analysis, CollectionName: doc.CollectionName, ExportedAt: doc.ExportedAt})
+	}
+	return fmt.Errorf("unsupported document format %q", format)
+}
+
+// Loader returns the document to export for a collection item, or nil to skip it (no analysis).
+type Loader func(item models.DocumentItem) (*Document, error)
+
+type manifestEntry struct {
+	DocumentID int    `json:"documentId"`
+	FileName   string `json:"fileName"`
+	AnalysisID int    `json:"analysisId,omitempty"`
+	File       string `json:"file,omitempty"`
+}
+
+type manifest struct {
+	Collection struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"collection"`
+	Format     string          `json:"format"`
+	ExportedAt time.Time       `json:"exportedAt"`
+	Documents  []manifestEntry `json:"documents"`
+	Skipped    []manifestEntry `json:"skipped"`
+}
+
+// WriteZip streams a ZIP archive of the collection: each document is loaded, rendered in format
+// and written as its own entry before the next one is loaded; manifest.json comes last. On error
+// the archive is left without its central directory, so a partial download is not a valid ZIP.
+func (r *Renderer) WriteZip(w io.Writer, format string, collection models.Collection, items []models.DocumentItem, load Loader, exportedAt time.Time) error {
+	zw := zip.NewWriter(w)
+	m := manifest{Format: format, ExportedAt: exportedAt.UTC(), Documents: []manifestEntry{}, Skipped: []manifestEntry{}}
+	m.Collection.ID, m.Collection.Name = collection.ID, collection.Name
+	for _, it := range items {
+		doc, err := load(it)
+		if err != nil {
+			return err
+		}
+		entry := manifestEntry{DocumentID: it.ID, FileName: it.FileName}
+		if doc == nil {
+			m.Skipped = append(m.Skipped, entry)
+			continue
+		}
+		entry.AnalysisID = doc.Analysis.AnalysisID
+		entry.File = EntryName(it.ID, it.FileName, format)
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: entry.File, Method: zip.Deflate, Modified: m.ExportedAt})
+		if err != nil {
+			return err
+		}
+		if err := r.RenderDocument(f, format, doc); err != nil {
+			return err
+		}
+		m.Documents = append(m.Documents, entry)
+	}
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: "manifest.json", Method: zip.Deflate, Modified: m.ExportedAt})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+var csvHeader = []string{"document_id", "file_name", "analysis_id", "analyzed_at", "sentiment", "keywords", "summary", "summary_points", "backend"}
+
+// WriteCSV streams one row per analysed document of the collection (documents without an
+// analysis are left out). Rows are flushed as they are written.
+func WriteCSV(w io.Writer, items []models.DocumentItem, load Loader) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, it := range items {
+		doc, err := load(it)
+		if err != nil {
+			return err
+		}
+		if doc == nil {
+			continue
+		}
+		a := doc.Analysis
+		row := []string{
+			strconv.Itoa(a.DocumentID), csvSafe(a.FileName), strconv.Itoa(a.AnalysisID), a.CreatedAt, a.Sentiment,
+			csvSafe(strings.Join(a.Keywords, "; ")), csvSafe(a.Summary), csvSafe(strings.Join(a.SummaryPoints, " | ")), a.Backend,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvSafe defuses spreadsheet formulas in user-controlled cells.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// EntryName is the archive/download name of a document export: "<id>-<name>.<format>", with the
+// original extension dropped and anything but letters, digits, '-', '_' and '.' replaced.
+func EntryName(documentID int, fileName, format string) string {
+	base := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	safe := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, base)
+	if safe == "" || strings.Trim(safe, ".") == "" {
+		safe = "document"
+	}
+	return fmt.Sprintf("%d-%s.%s", documentID, safe, format)
+}
@@ -0,0 +1,116 @@
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/samusafe/genericapi/internal/sniff"
+)
+
+// Local text extraction for formats that need no parsing library (.txt, .md).
+// Bytes are decoded (UTF-8, UTF-16 with BOM, Latin-1 fallback), checked for binary garbage,
+// line endings normalized to \n and, for Markdown, syntax optionally stripped. The result is
+// sent to the text-only analysis endpoint instead of the raw upload.
+
+var (
+	ErrBinary = errors.New("content is not text")
+	ErrEmpty  = errors.New("no text content")
+)
+
+// Options tune extraction.
+type Options struct {
+	StripMarkdown bool
+}
+
+// Supported reports whether ext (lower-case, with dot) is extracted locally.
+func Supported(ext string) bool {
+	return ext == ".txt" || ext == ".md"
+}
+
+// Text decodes raw into normalized UTF-8 text.
+func Text(raw []byte, ext string, opts Options) (string, error) {
+	text := decode(raw)
+	if sniff.LooksBinary(text) {
+		return "", ErrBinary
+	}
+	text = normalizeLineEndings(text)
+	if ext == ".md" && opts.StripMarkdown {
+		text = stripMarkdown(text)
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", ErrEmpty
+	}
+	return text, nil
+}
+
+// decode detects the charset: BOM first, then valid UTF-8, otherwise Latin-1.
+func decode(raw []byte) string {
+	switch {
+	case bytes.HasPrefix(raw, []byte{0xEF, 0xBB, 0xBF}):
+		return string(raw[3:])
+	case bytes.HasPrefix(raw, []byte{0xFF, 0xFE}):
+		return decodeUTF16(raw[2:], false)
+	case bytes.HasPrefix(raw, []byte{0xFE, 0xFF}):
+		return decodeUTF16(raw[2:], true)
+	case utf8.Valid(raw):
+		return string(raw)
+	}
+	runes := make([]rune, len(raw))
+	for i, b := range raw {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+func decodeUTF16(raw []byte, bigEndian bool) string {
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(raw[2*i])<<8 | uint16(raw[2*i+1])
+		} else {
+			units[i] = uint16(raw[2*i+1])<<8 | uint16(raw[2*i])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+func normalizeLineEndings(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+var (
+	mdFence      = regexp.MustCompile("(?m)^[ \\t]*(```|~~~).*$\n?")
+	mdHeading    = regexp.MustCompile(`(?m)^[ \t]{0,3}#{1,6}[ \t]+(.*?)[ \t]*#*[ \t]*$`)
+	mdQuote      = regexp.MustCompile(`(?m)^[ \t]{0,3}>[ \t]?`)
+	mdListItem   = regexp.MustCompile(`(?m)^([ \t]*)(?:[-*+]|\d+[.)])[ \t]+`)
+	mdRule       = regexp.MustCompile(`(?m)^[ \t]{0,3}(?:[-*_][ \t]*){3,}$\n?`)
+	mdImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	mdInlineCode = regexp.MustCompile("`([^`\n]*)`")
+	mdStrong     = regexp.MustCompile(`(\*\*|__)([^\n]+?)(\*\*|__)`)
+	mdEmphasis   = regexp.MustCompile(`\*([^*\n]+)\*|\b_([^_\n]+)_\b`)
+	mdHTML       = regexp.MustCompile(`</?[a-zA-Z][^>\n]*>`)
+	blankRuns    = regexp.MustCompile(`\n{3,}`)
+)
+
+// stripMarkdown removes formatting syntax while keeping the readable text (link labels,
+// code contents, heading text).
+func stripMarkdown(text string) string {
+	text = mdFence.ReplaceAllString(text, "")
+	text = mdRule.ReplaceAllString(text, "")
+	text = mdHeading.ReplaceAllString(text, "$1")
+	text = mdQuote.ReplaceAllString(text, "")
+	text = mdListItem.ReplaceAllString(text, "$1")
+	text = mdImage.ReplaceAllString(text, "$1")
+	text = mdLink.ReplaceAllString(text, "$1")
+	text = mdInlineCode.ReplaceAllString(text, "$1")
+	text = mdStrong.ReplaceAllString(text, "$2")
+	text = mdEmphasis.ReplaceAllString(text, "$1$2")
+	text = mdHTML.ReplaceAllString(text, "")
+	return blankRuns.ReplaceAllString(text, "\n\n")
+}
@@ -0,0 +1,76 @@
+// Package grading scores free-text quiz answers against the expected answer.
+package grading
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Result of grading one answer. Score is 1 for an exact (normalized) match, otherwise the
+// token-overlap F1 between the given and expected answers.
+type Result struct {
+	Correct bool
+	Exact   bool
+	Score   float64
+}
+
+// Grade compares given with expected; the answer is correct when its score reaches threshold.
+func Grade(expected, given string, threshold float64) Result {
+	want, got := Normalize(expected), Normalize(given)
+	if got == "" {
+		return Result{}
+	}
+	if want == got {
+		return Result{Correct: true, Exact: true, Score: 1}
+	}
+	score := tokenF1(strings.Fields(want), strings.Fields(got))
+	return Result{Correct: score >= threshold, Score: score}
+}
+
+// Normalize lowercases s, strips diacritics and punctuation and collapses whitespace, so
+// "São Paulo." and "sao paulo" compare equal.
+func Normalize(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining mark left by NFD: drop the accent, keep the base letter
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// tokenF1 is the harmonic mean of precision and recall over the multiset of tokens.
+func tokenF1(want, got []string) float64 {
+	if len(want) == 0 || len(got) == 0 {
+		return 0
+	}
+	counts := make(map[string]int, len(want))
+	for _, t := range want {
+		counts[t]++
+	}
+	common := 0
+	for _, t := range got {
+		if counts[t] > 0 {
+			counts[t]--
+			common++
+		}
+	}
+	if common == 0 {
+		return 0
+	}
+	precision := float64(common) / float64(len(got))
+	recall := float64(common) / float64(len(want))
+	return 2 * precision * recall / (precision + recall)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// AccountHandler erases the authenticated user's data.
+type AccountHandler struct {
+	Service services.AccountServiceInterface
+}
+
+func NewAccountHandler(service services.AccountServiceInterface) *AccountHandler {
+	return &AccountHandler{Service: service}
+}
+
+// DeleteAccount handles DELETE /me: every row and unshared original upload of the user is
+// removed and the signed deletion report is returned. The sign-in account itself is not touched.
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	report, err := h.Service.DeleteAccount(c.Request.Context(), userID, models.DeletionRequestedByUser)
+	if err != nil {
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		return
+	}
+	utils.GinData(c, http.StatusOK, report)
+}
@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/analysisdiff"
+	"github.com/samusafe/genericapi/internal/models"
 	"github.com/samusafe/genericapi/internal/repositories"
 	"github.com/samusafe/genericapi/internal/utils"
 )
@@ -45,9 +47,14 @@ func (h *AnalysisHistoryHandler) SaveDocumentToCollection(c *gin.Context) {
 
 	err = h.Repo.UpdateDocumentCollection(userID, req.DocumentID, req.CollectionID)
 	if err != nil {
-		if err.Error() == "already_assigned" {
+		switch {
+		case errors.Is(err, repositories.ErrDocumentAlreadyAssigned):
 			utils.GinError(c, http.StatusConflict, "DocumentAlreadyInCollection", nil)
-		} else {
+		case errors.Is(err, repositories.ErrDocumentDuplicate):
+			utils.GinError(c, http.StatusConflict, "DocumentDuplicate", nil)
+		case errors.Is(err, sql.ErrNoRows):
+			utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		default:
 			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
 		}
 		return
@@ -81,27 +88,109 @@ func (h *AnalysisHistoryHandler) GetLatestByDocument(c *gin.Context) {
 func (h *AnalysisHistoryHandler) ListAllDocuments(c *gin.Context) {
 	userID := c.GetString("userID")
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	allowedLimits := make(map[int]bool)
-	for _, l := range config.AllowedPageLimits {
-		allowedLimits[l] = true
+	limit, offset := parsePageQuery(c)
+
+	items, total, err := h.Repo.ListAllDocuments(userID, limit, offset)
+	if err != nil {
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		return
 	}
 
-	if _, ok := allowedLimits[limit]; !ok {
-		limit = config.AllowedPageLimits[0]
+	utils.GinData(c, http.StatusOK, gin.H{"items": items, "total": total})
+}
+
+// ListVersions handles GET /documents/:documentId/analyses: every analysis version of the
+// document, oldest first.
+func (h *AnalysisHistoryHandler) ListVersions(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	docID, ok := parsePositiveIntParam(c, "documentId")
+	if !ok {
+		return
 	}
+	versions, ok := h.listVersions(c, userID, docID)
+	if !ok {
+		return
+	}
+	utils.GinData(c, http.StatusOK, gin.H{"documentId": docID, "versions": versions})
+}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if page < 1 {
-		page = 1
+// DiffVersions handles GET /documents/:documentId/analyses/diff?from=&to=. "to" defaults to the
+// latest version and "from" to the one before it (the same version when there is only one).
+func (h *AnalysisHistoryHandler) DiffVersions(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	docID, ok := parsePositiveIntParam(c, "documentId")
+	if !ok {
+		return
+	}
+	from, ok := parseVersionQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseVersionQuery(c, "to")
+	if !ok {
+		return
+	}
+	versions, ok := h.listVersions(c, userID, docID)
+	if !ok {
+		return
+	}
+	if len(versions) == 0 {
+		utils.GinMsg(c, http.StatusNotFound, "AnalysisVersionNotFound")
+		return
 	}
-	offset := (page - 1) * limit
 
-	items, total, err := h.Repo.ListAllDocuments(userID, limit, offset)
+	toIdx := len(versions) - 1
+	if to > 0 {
+		if toIdx = versionIndex(versions, to); toIdx < 0 {
+			utils.GinError(c, http.StatusNotFound, "AnalysisVersionNotFound", to)
+			return
+		}
+	}
+	fromIdx := max(toIdx-1, 0)
+	if from > 0 {
+		if fromIdx = versionIndex(versions, from); fromIdx < 0 {
+			utils.GinError(c, http.StatusNotFound, "AnalysisVersionNotFound", from)
+			return
+		}
+	}
+
+	utils.GinData(c, http.StatusOK, gin.H{"documentId": docID, "diff": analysisdiff.Compare(versions[fromIdx], versions[toIdx])})
+}
+
+func (h *AnalysisHistoryHandler) listVersions(c *gin.Context, userID string, docID int) ([]models.AnalysisVersion, bool) {
+	versions, err := h.Repo.ListAnalysisVersions(userID, docID)
 	if err != nil {
-		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
-		return
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		} else {
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		}
+		return nil, false
 	}
+	return versions, true
+}
 
-	utils.GinData(c, http.StatusOK, gin.H{"items": items, "total": total})
+// parseVersionQuery reads an optional positive version number (0 when absent).
+func parseVersionQuery(c *gin.Context, key string) (int, bool) {
+	raw := c.Query(key)
+	if raw == "" {
+		return 0, true
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v <= 0 {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", key)
+		return 0, false
+	}
+	return v, true
+}
+
+func versionIndex(versions []models.AnalysisVersion, version int) int {
+	for i, v := range versions {
+		if v.Version == version {
+			return i
+		}
+	}
+	return -1
 }
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/services"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// AnalysisJobsHandler exposes asynchronous analysis (submit now, poll for results later).
+type AnalysisJobsHandler struct {
+	Jobs services.JobServiceInterface
+}
+
+func NewAnalysisJobsHandler(jobs services.JobServiceInterface) *AnalysisJobsHandler {
+	return &AnalysisJobsHandler{Jobs: jobs}
+}
+
+// Submit stores the uploads and returns the queued job without waiting for the analysis.
+func (h *AnalysisJobsHandler) Submit(c *gin.Context) {
+	lang := c.GetString("lang")
+	userID := c.GetString("userID")
+	cid := c.GetString(utils.CorrelationIDHeader)
+
+	files, ok := validateUploadedFiles(c, "documents", 10)
+	if !ok {
+		return
+	}
+	if !validateTotalUploadSize(c, files) || !validateUploadSizeLimit(c, files, config.JobMaxUploadBytes) {
+		return
+	}
+	collectionID := parseCollectionIDForm(c, "collectionId")
+	backend, ok := parseBackendForm(c, h.Jobs.HasBackend)
+	if !ok {
+		return
+	}
+	force, ok := parseBoolForm(c, "force")
+	if !ok {
+		return
+	}
+
+	ctx := utils.WithCorrelationID(c.Request.Context(), cid)
+	job, err := h.Jobs.Submit(ctx, files, lang, userID, collectionID, services.AnalyzeOptions{Backend: backend, Force: force})
+	if err != nil {
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+
+	utils.GinData(c, http.StatusAccepted, gin.H{"job": job})
+}
+
+// Get reports per-file progress and the results gathered so far.
+func (h *AnalysisJobsHandler) Get(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	jobID := c.Param("id")
+	if _, err := uuid.Parse(jobID); err != nil {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "id")
+		return
+	}
+
+	job, err := h.Jobs.Get(userID, jobID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		} else {
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		}
+		return
+	}
+
+	utils.GinData(c, http.StatusOK, gin.H{"job": job})
+}
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
+	"io"
+	"mime/multipart"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/samusafe/genericapi/internal/config"
 	"github.com/samusafe/genericapi/internal/i18n"
+	"github.com/samusafe/genericapi/internal/models"
 	"github.com/samusafe/genericapi/internal/services"
 	"github.com/samusafe/genericapi/internal/utils"
 )
@@ -24,6 +28,7 @@ func NewAnalyzeHandler(service services.AnalyzerServiceInterface) *AnalyzeHandle
 
 // Analyze is the main handler function for the /analyze endpoint.
 // Its only job is to handle the request/response cycle and call the service.
+// With ?stream=sse the results are sent as Server-Sent Events instead (see analyzeStream).
 func (h *AnalyzeHandler) Analyze(c *gin.Context) {
 	lang := c.GetString("lang")
 	userID := c.GetString("userID")
@@ -34,18 +39,27 @@ func (h *AnalyzeHandler) Analyze(c *gin.Context) {
 		return
 	}
 	collectionID := parseCollectionIDForm(c, "collectionId")
+	backend, ok := parseBackendForm(c, h.Service.HasBackend)
+	if !ok {
+		return
+	}
+	force, ok := parseBoolForm(c, "force")
+	if !ok {
+		return
+	}
+	opts := services.AnalyzeOptions{Backend: backend, Force: force}
 
-	var total int64
-	for _, f := range files {
-		total += f.Size
-		if total > config.MaxUploadBytes {
-			utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "total size exceeds limit")
-			return
-		}
+	if !validateTotalUploadSize(c, files) {
+		return
+	}
+
+	if c.Query("stream") == "sse" {
+		h.analyzeStream(c, files, lang, userID, collectionID, opts)
+		return
 	}
 
 	ctx := utils.WithCorrelationID(c.Request.Context(), cid)
-	results := h.Service.AnalyzeFilesWithContext(ctx, files, lang, userID, collectionID)
+	results := h.Service.AnalyzeFilesWithOptions(ctx, files, lang, userID, collectionID, opts)
 
 	// Check for Python service unavailability
 	pyServiceUnavailable := false
@@ -64,37 +78,78 @@ func (h *AnalyzeHandler) Analyze(c *gin.Context) {
 	utils.GinData(c, http.StatusOK, gin.H{"results": results})
 }
 
-// GenerateQuiz handles the request to generate a quiz from text.
-func (h *AnalyzeHandler) GenerateQuiz(c *gin.Context) {
-	lang := c.GetString("lang")
+// analyzeStream writes one "result" event per file as soon as it finishes, then a closing
+// "summary" event. Validation errors before this point are still plain JSON responses.
+func (h *AnalyzeHandler) analyzeStream(c *gin.Context, files []*multipart.FileHeader, lang, userID string, collectionID *int, opts services.AnalyzeOptions) {
 	cid := c.GetString(utils.CorrelationIDHeader)
+	ctx := utils.WithCorrelationID(c.Request.Context(), cid)
 
-	var requestBody struct {
-		Text string `json:"text"`
-	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable proxy buffering (nginx ingress)
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
 
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", err.Error())
+	summary := h.Service.AnalyzeFilesStream(ctx, files, lang, userID, collectionID, opts, func(r models.AnalysisResult) {
+		if ctx.Err() != nil {
+			return // client went away
+		}
+		c.SSEvent("result", r)
+		c.Writer.Flush()
+	})
+	if ctx.Err() != nil {
 		return
 	}
+	c.SSEvent("summary", gin.H{"summary": summary, "correlationId": cid})
+	c.Writer.Flush()
+}
 
-	if requestBody.Text == "" {
-		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "empty text")
+// Reanalyze handles POST /documents/:documentId/reanalyze: the stored text is analyzed again and
+// the result returned as the document's new latest analysis. An optional JSON body
+// {"backend": "..."} picks the backend.
+func (h *AnalyzeHandler) Reanalyze(c *gin.Context) {
+	userID := c.GetString("userID")
+	cid := c.GetString(utils.CorrelationIDHeader)
+
+	docID, ok := parsePositiveIntParam(c, "documentId")
+	if !ok {
 		return
 	}
-
-	// Input size guard using config.QuizMaxChars
-	if len(requestBody.Text) > config.QuizMaxChars {
-		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "text too large")
+	var body struct {
+		Backend string `json:"backend"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	if body.Backend != "" && !h.Service.HasBackend(body.Backend) {
+		utils.GinError(c, http.StatusBadRequest, "UnknownBackend", body.Backend)
 		return
 	}
 
 	ctx := utils.WithCorrelationID(c.Request.Context(), cid)
-	quiz, err := h.Service.GenerateQuizWithContext(ctx, requestBody.Text, lang)
+	analysis, err := h.Service.ReanalyzeDocument(ctx, userID, docID, services.AnalyzeOptions{Backend: body.Backend, Force: true})
 	if err != nil {
-		utils.GinError(c, http.StatusInternalServerError, "InternalError", err.Error())
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		case errors.Is(err, services.ErrNoStoredText):
+			utils.GinMsg(c, http.StatusUnprocessableEntity, "EmptyDocument")
+		case errors.Is(err, services.ErrAnalysisCancelled):
+			utils.GinMsg(c, http.StatusServiceUnavailable, "AnalysisCancelled")
+		case errors.Is(err, services.ErrBackendUnsupported):
+			utils.GinMsg(c, http.StatusUnprocessableEntity, "UnsupportedFileType")
+		case errors.Is(err, services.ErrBackendRejected):
+			utils.GinMsg(c, http.StatusUnprocessableEntity, "AnalysisRejected")
+		case errors.Is(err, services.ErrBackendUnavailable):
+			utils.GinMsg(c, http.StatusServiceUnavailable, "PythonServiceUnavailable")
+		case errors.Is(err, services.ErrMalformedAnalysis):
+			utils.GinMsg(c, http.StatusBadGateway, "InternalError")
+		default:
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		}
 		return
 	}
-
-	utils.GinData(c, http.StatusOK, quiz)
+	utils.GinData(c, http.StatusOK, gin.H{"analysis": analysis})
 }
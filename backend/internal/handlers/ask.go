@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/httpclient"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/services"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// AskHandler answers natural-language questions over a collection's documents.
+type AskHandler struct {
+	Collections repositories.CollectionsRepository
+	QA          services.QAServiceInterface
+}
+
+func NewAskHandler(collections repositories.CollectionsRepository, qa services.QAServiceInterface) *AskHandler {
+	return &AskHandler{Collections: collections, QA: qa}
+}
+
+// Ask handles POST /collections/:id/ask with {"question": "..."}.
+func (h *AskHandler) Ask(c *gin.Context) {
+	userID := c.GetString("userID")
+	lang := c.GetString("lang")
+	cid := c.GetString(utils.CorrelationIDHeader)
+
+	id, ok := parsePositiveIntParam(c, "id")
+	if !ok {
+		return
+	}
+	var body struct {
+		Question string `json:"question"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	question := strings.TrimSpace(body.Question)
+	if question == "" {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "empty question")
+		return
+	}
+	if utf8.RuneCountInString(question) > config.AskMaxChars {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "question too long")
+		return
+	}
+
+	exists, err := h.Collections.ExistsForUser(userID, id)
+	if err != nil {
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		return
+	}
+	if !exists {
+		utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		return
+	}
+
+	ctx := utils.WithCorrelationID(c.Request.Context(), cid)
+	result, err := h.QA.Ask(ctx, userID, id, question, lang)
+	switch {
+	case errors.Is(err, services.ErrNoRelevantContent):
+		utils.GinMsg(c, http.StatusNotFound, "NoRelevantContent")
+	case errors.Is(err, httpclient.ErrPythonUnavailable), errors.Is(err, httpclient.ErrCircuitOpen), errors.Is(err, httpclient.ErrBadStatus):
+		utils.GinError(c, http.StatusServiceUnavailable, "PythonServiceUnavailable", nil)
+	case err != nil:
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+	default:
+		utils.GinData(c, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/samusafe/genericapi/internal/i18n"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/services"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// BatchesHandler lists multi-file uploads (and jobs) with the outcome of each file, and retries
+// their failed files.
+type BatchesHandler struct {
+	Batches  repositories.BatchesRepository
+	Analyzer services.AnalyzerServiceInterface
+}
+
+func NewBatchesHandler(batches repositories.BatchesRepository, analyzer services.AnalyzerServiceInterface) *BatchesHandler {
+	return &BatchesHandler{Batches: batches, Analyzer: analyzer}
+}
+
+// List returns the caller's batches, most recent first.
+func (h *BatchesHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	limit, offset := parsePageQuery(c)
+
+	items, total, err := h.Batches.ListBatches(userID, limit, offset)
+	if err != nil {
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		return
+	}
+	utils.GinData(c, http.StatusOK, gin.H{"items": items, "total": total})
+}
+
+// batchIDParam reads the ":id" path param; an invalid UUID is answered with 400.
+func batchIDParam(c *gin.Context) (string, bool) {
+	batchID := c.Param("id")
+	if _, err := uuid.Parse(batchID); err != nil {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "id")
+		return "", false
+	}
+	return batchID, true
+}
+
+// Get returns one batch with its analyses and failures; failure messages are translated.
+func (h *BatchesHandler) Get(c *gin.Context) {
+	lang := c.GetString("lang")
+	userID := c.GetString("userID")
+
+	batchID, ok := batchIDParam(c)
+	if !ok {
+		return
+	}
+
+	batch, err := h.Batches.GetBatch(userID, batchID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		} else {
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		}
+		return
+	}
+	for i := range batch.Failures {
+		batch.Failures[i].Error = i18n.GetMessage(lang, batch.Failures[i].ErrorKey)
+	}
+
+	utils.GinData(c, http.StatusOK, gin.H{"batch": batch})
+}
+
+// Retry analyzes again the failed files of the batch whose upload was kept and answers with the
+// merged results of the batch, in the shape of POST /analyze (503 while the backend is still down).
+func (h *BatchesHandler) Retry(c *gin.Context) {
+	lang := c.GetString("lang")
+	userID := c.GetString("userID")
+	cid := c.GetString(utils.CorrelationIDHeader)
+
+	batchID, ok := batchIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := utils.WithCorrelationID(c.Request.Context(), cid)
+	results, err := h.Analyzer.RetryBatch(ctx, userID, batchID, lang)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		} else {
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		}
+		return
+	}
+
+	for _, result := range results {
+		if result.ErrorKey == "PythonServiceUnavailable" {
+			utils.GinError(c, http.StatusServiceUnavailable, "PythonServiceUnavailable", results)
+			return
+		}
+	}
+	utils.GinData(c, http.StatusOK, gin.H{"batchId": batchID, "results": results})
+}
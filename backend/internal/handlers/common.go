@@ -3,9 +3,11 @@ package handlers
 import (
 	"mime/multipart"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/config"
 	"github.com/samusafe/genericapi/internal/utils"
 )
 
@@ -42,6 +44,19 @@ func paginateSlice(total, defaultLimit int, c *gin.Context) (start, end int) {
 	return offset, end
 }
 
+// parsePageQuery reads "limit" (one of config.AllowedPageLimits) and 1-based "page" query params.
+func parsePageQuery(c *gin.Context) (limit, offset int) {
+	limit, _ = strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if !slices.Contains(config.AllowedPageLimits, limit) {
+		limit = config.AllowedPageLimits[0]
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	return limit, (page - 1) * limit
+}
+
 // parseCollectionIDForm optional form field.
 func parseCollectionIDForm(c *gin.Context, field string) *int {
 	if raw := c.PostForm(field); raw != "" {
@@ -52,6 +67,30 @@ func parseCollectionIDForm(c *gin.Context, field string) *int {
 	return nil
 }
 
+// parseBackendForm reads the optional "backend" form field; an unknown name is rejected with 400.
+func parseBackendForm(c *gin.Context, known func(string) bool) (string, bool) {
+	name := c.PostForm("backend")
+	if name != "" && !known(name) {
+		utils.GinError(c, http.StatusBadRequest, "UnknownBackend", name)
+		return "", false
+	}
+	return name, true
+}
+
+// parseBoolForm reads an optional boolean form field (absent = false); other values are rejected with 400.
+func parseBoolForm(c *gin.Context, key string) (bool, bool) {
+	raw := c.PostForm(key)
+	if raw == "" {
+		return false, true
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", key)
+		return false, false
+	}
+	return v, true
+}
+
 // ==== Upload Validation ====
 
 // validateUploadedFiles basic validations.
@@ -72,3 +111,21 @@ func validateUploadedFiles(c *gin.Context, formKey string, max int) ([]*multipar
 	}
 	return files, true
 }
+
+// validateTotalUploadSize enforces config.MaxUploadBytes across all files of a request.
+func validateTotalUploadSize(c *gin.Context, files []*multipart.FileHeader) bool {
+	return validateUploadSizeLimit(c, files, config.MaxUploadBytes)
+}
+
+// validateUploadSizeLimit enforces limit across all files of a request.
+func validateUploadSizeLimit(c *gin.Context, files []*multipart.FileHeader, limit int64) bool {
+	var total int64
+	for _, f := range files {
+		total += f.Size
+		if total > limit {
+			utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "total size exceeds limit")
+			return false
+		}
+	}
+	return true
+}
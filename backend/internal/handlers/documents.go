@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/samusafe/genericapi/internal/blobstore"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/similarity"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// DocumentsHandler serves per-document resources (original upload download, related documents)
+// and document management (rename, move, delete).
+type DocumentsHandler struct {
+	Docs       repositories.DocumentsRepository
+	Blobs      blobstore.BlobStore
+	Embeddings repositories.EmbeddingsRepository
+}
+
+func NewDocumentsHandler(docs repositories.DocumentsRepository, blobs blobstore.BlobStore, embeddings repositories.EmbeddingsRepository) *DocumentsHandler {
+	return &DocumentsHandler{Docs: docs, Blobs: blobs, Embeddings: embeddings}
+}
+
+const (
+	defaultRelatedLimit = 5
+	maxRelatedLimit     = 20
+	maxBulkDocuments    = 100
+	maxFileNameChars    = 255
+)
+
+// DownloadOriginal streams the file exactly as it was uploaded.
+func (h *DocumentsHandler) DownloadOriginal(c *gin.Context) {
+	userID := c.GetString("userID")
+	cid := c.GetString(utils.CorrelationIDHeader)
+
+	docID, ok := parsePositiveIntParam(c, "documentId")
+	if !ok {
+		return
+	}
+
+	doc, err := h.Docs.GetDocumentFile(userID, docID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		} else {
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		}
+		return
+	}
+	if doc.ContentHash == "" {
+		utils.GinMsg(c, http.StatusNotFound, "OriginalNotAvailable")
+		return
+	}
+
+	rc, size, err := h.Blobs.Get(c.Request.Context(), doc.ContentHash)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			// Documents analyzed before originals were kept have no blob.
+			utils.GinMsg(c, http.StatusNotFound, "OriginalNotAvailable")
+		} else {
+			log.Error().Str("cid", cid).Int("document", docID).Err(err).Msg("blob get error")
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", nil)
+		}
+		return
+	}
+	defer rc.Close()
+
+	contentType := doc.DetectedType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(doc.FileName))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": doc.FileName})
+	c.DataFromReader(http.StatusOK, size, contentType, rc, map[string]string{"Content-Disposition": disposition})
+}
+
+// Related returns the user's documents most similar to the given one (cosine over embeddings).
+func (h *DocumentsHandler) Related(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	docID, ok := parsePositiveIntParam(c, "documentId")
+	if !ok {
+		return
+	}
+	limit := defaultRelatedLimit
+	if v := c.Query("limit"); v != "" {
+		if iv, err := strconv.Atoi(v); err == nil && iv > 0 {
+			limit = min(iv, maxRelatedLimit)
+		}
+	}
+
+	target, err := h.Embeddings.GetEmbedding(userID, docID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+			return
+		}
+		// Tell "not yours / missing" apart from "analyzed before embeddings existed".
+		if _, err := h.Docs.GetDocumentFile(userID, docID); errors.Is(err, sql.ErrNoRows) {
+			utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		} else if err != nil {
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		} else {
+			utils.GinMsg(c, http.StatusNotFound, "EmbeddingNotAvailable")
+		}
+		return
+	}
+
+	candidates, err := h.Embeddings.ListEmbeddings(userID, target.Model, docID)
+	if err != nil {
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		return
+	}
+	utils.GinData(c, http.StatusOK, gin.H{"items": similarity.TopK(target.Vector, candidates, limit)})
+}
+
+// UpdateDocument handles PATCH /documents/:documentId with {"fileName"?, "collectionId"?}.
+// A null collectionId moves the document back to uncategorized.
+func (h *DocumentsHandler) UpdateDocument(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	docID, ok := parsePositiveIntParam(c, "documentId")
+	if !ok {
+		return
+	}
+	var body struct {
+		FileName     *string         `json:"fileName"`
+		CollectionID json.RawMessage `json:"collectionId"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	var patch models.DocumentPatch
+	if body.FileName != nil {
+		name := strings.TrimSpace(*body.FileName)
+		if name == "" || utf8.RuneCountInString(name) > maxFileNameChars || strings.ContainsAny(name, "/\\") {
+			utils.GinError(c, http.StatusBadRequest, "InvalidDocumentName", nil)
+			return
+		}
+		patch.FileName = &name
+	}
+	if body.CollectionID != nil {
+		if patch.CollectionID, ok = parseTargetCollection(body.CollectionID); !ok {
+			utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "collectionId")
+			return
+		}
+		patch.Move = true
+	}
+	if patch.FileName == nil && !patch.Move {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "fileName or collectionId required")
+		return
+	}
+
+	item, err := h.Docs.UpdateDocument(userID, docID, patch)
+	if err != nil {
+		respondDocumentError(c, err)
+		return
+	}
+	utils.GinData(c, http.StatusOK, gin.H{"document": item})
+}
+
+// DeleteDocument handles DELETE /documents/:documentId: the document, its analyses and quizzes.
+func (h *DocumentsHandler) DeleteDocument(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	docID, ok := parsePositiveIntParam(c, "documentId")
+	if !ok {
+		return
+	}
+	orphaned, err := h.Docs.DeleteDocuments(userID, []int{docID})
+	if err != nil {
+		respondDocumentError(c, err)
+		return
+	}
+	h.deleteBlobs(c, orphaned)
+	utils.GinMsg(c, http.StatusOK, "DocumentDeleted")
+}
+
+// BulkMove handles POST /documents/bulk/move with {"documentIds": [...], "collectionId": id|null}.
+// Either every document is moved or none is.
+func (h *DocumentsHandler) BulkMove(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var body struct {
+		DocumentIDs  []int           `json:"documentIds"`
+		CollectionID json.RawMessage `json:"collectionId"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	ids, ok := uniqueDocumentIDs(body.DocumentIDs)
+	if !ok {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "documentIds")
+		return
+	}
+	// collectionId is required: only an explicit null moves documents to uncategorized.
+	collectionID, ok := parseTargetCollection(body.CollectionID)
+	if body.CollectionID == nil || !ok {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "collectionId")
+		return
+	}
+
+	if err := h.Docs.MoveDocuments(userID, ids, collectionID); err != nil {
+		respondDocumentError(c, err)
+		return
+	}
+	utils.GinData(c, http.StatusOK, gin.H{"moved": len(ids)})
+}
+
+// BulkDelete handles POST /documents/bulk/delete with {"documentIds": [...]}. Either every
+// document is deleted or none is.
+func (h *DocumentsHandler) BulkDelete(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var body struct {
+		DocumentIDs []int `json:"documentIds"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	ids, ok := uniqueDocumentIDs(body.DocumentIDs)
+	if !ok {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "documentIds")
+		return
+	}
+
+	orphaned, err := h.Docs.DeleteDocuments(userID, ids)
+	if err != nil {
+		respondDocumentError(c, err)
+		return
+	}
+	h.deleteBlobs(c, orphaned)
+	utils.GinData(c, http.StatusOK, gin.H{"deleted": len(ids)})
+}
+
+// deleteBlobs removes original uploads no document references any more. The documents are gone
+// already, so failures are only logged (the blob is then left unreferenced).
+func (h *DocumentsHandler) deleteBlobs(c *gin.Context, keys []string) {
+	if h.Blobs == nil {
+		return
+	}
+	cid := c.GetString(utils.CorrelationIDHeader)
+	ctx := context.WithoutCancel(c.Request.Context())
+	for _, key := range keys {
+		if err := h.Blobs.Delete(ctx, key); err != nil {
+			log.Error().Str("cid", cid).Str("hash", key).Err(err).Msg("blob delete error")
+		}
+	}
+}
+
+func respondDocumentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		utils.GinMsg(c, http.StatusNotFound, "NotFound")
+	case errors.Is(err, repositories.ErrCollectionNotFound):
+		utils.GinError(c, http.StatusNotFound, "NotFound", "collectionId")
+	case errors.Is(err, repositories.ErrDocumentDuplicate):
+		utils.GinError(c, http.StatusConflict, "DocumentDuplicate", nil)
+	default:
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+	}
+}
+
+// parseTargetCollection reads a collectionId that may be null (uncategorized).
+func parseTargetCollection(raw json.RawMessage) (*int, bool) {
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil, true
+	}
+	var id int
+	if err := json.Unmarshal(raw, &id); err != nil || id <= 0 {
+		return nil, false
+	}
+	return &id, true
+}
+
+// uniqueDocumentIDs validates a bulk selection (1..maxBulkDocuments positive IDs) and drops repeats.
+func uniqueDocumentIDs(ids []int) ([]int, bool) {
+	if len(ids) == 0 || len(ids) > maxBulkDocuments {
+		return nil, false
+	}
+	seen := make(map[int]bool, len(ids))
+	out := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, false
+		}
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out, true
+}
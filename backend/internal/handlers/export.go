@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/samusafe/genericapi/internal/export"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// ExportHandler renders analyses for download (single documents and whole collections).
+type ExportHandler struct {
+	Analysis    repositories.AnalysisRepository
+	Collections repositories.CollectionsRepository
+	Renderer    *export.Renderer
+}
+
+func NewExportHandler(analysis repositories.AnalysisRepository, collections repositories.CollectionsRepository, renderer *export.Renderer) *ExportHandler {
+	return &ExportHandler{Analysis: analysis, Collections: collections, Renderer: renderer}
+}
+
+// ExportDocument handles GET /documents/:documentId/export?format=md|html|json (default md):
+// the latest analysis of the document.
+func (h *ExportHandler) ExportDocument(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	docID, ok := parsePositiveIntParam(c, "documentId")
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", export.FormatMarkdown)
+	if !export.IsDocumentFormat(format) {
+		utils.GinError(c, http.StatusBadRequest, "InvalidExportFormat", format)
+		return
+	}
+
+	analysis, err := h.Analysis.GetLatestAnalysisByDocument(userID, docID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		} else {
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		}
+		return
+	}
+	var collectionName string
+	if analysis.CollectionID != nil {
+		col, err := h.findCollection(userID, *analysis.CollectionID)
+		if err != nil {
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+			return
+		}
+		if col != nil {
+			collectionName = col.Name
+		}
+	}
+
+	// A single document is small: render it fully so a template error is still a clean 500.
+	var buf bytes.Buffer
+	if err := h.Renderer.RenderDocument(&buf, format, export.NewDocument(analysis, collectionName, c.GetString("lang"), time.Now())); err != nil {
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		return
+	}
+	c.Header("Content-Disposition", attachment(export.EntryName(docID, analysis.FileName, format)))
+	c.Data(http.StatusOK, export.ContentType(format), buf.Bytes())
+}
+
+// ExportCollection handles GET /collections/:id/export?format=zip|csv (default zip). ZIP entries
+// are rendered in ?documentFormat=md|html|json (default md). The response is streamed document by
+// document; a failure midway is logged and leaves a truncated (invalid) archive.
+func (h *ExportHandler) ExportCollection(c *gin.Context) {
+	userID := c.GetString("userID")
+	lang := c.GetString("lang")
+	cid := c.GetString(utils.CorrelationIDHeader)
+
+	collectionID, ok := parsePositiveIntParam(c, "id")
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", export.FormatZip)
+	if format != export.FormatZip && format != export.FormatCSV {
+		utils.GinError(c, http.StatusBadRequest, "InvalidExportFormat", format)
+		return
+	}
+	documentFormat := c.DefaultQuery("documentFormat", export.FormatMarkdown)
+	if !export.IsDocumentFormat(documentFormat) {
+		utils.GinError(c, http.StatusBadRequest, "InvalidExportFormat", documentFormat)
+		return
+	}
+
+	col, err := h.findCollection(userID, collectionID)
+	if err != nil {
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		return
+	}
+	if col == nil {
+		utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		return
+	}
+	items, err := h.Analysis.ListDocumentsByCollection(userID, &collectionID)
+	if err != nil {
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		return
+	}
+
+	now := time.Now()
+	load := func(it models.DocumentItem) (*export.Document, error) {
+		if err := c.Request.Context().Err(); err != nil {
+			return nil, err
+		}
+		analysis, err := h.Analysis.GetLatestAnalysisByDocument(userID, it.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return export.NewDocument(analysis, col.Name, lang, now), nil
+	}
+
+	c.Header("Content-Disposition", attachment(collectionFileName(col.Name, format)))
+	c.Header("Content-Type", export.ContentType(format))
+	c.Status(http.StatusOK)
+	if format == export.FormatZip {
+		err = h.Renderer.WriteZip(c.Writer, documentFormat, *col, items, load, now)
+	} else {
+		err = export.WriteCSV(c.Writer, items, load)
+	}
+	if err != nil {
+		log.Error().Str("cid", cid).Int("collection", collectionID).Str("format", format).Err(err).Msg("collection export aborted")
+		c.Abort()
+	}
+}
+
+func (h *ExportHandler) findCollection(userID string, id int) (*models.Collection, error) {
+	cols, err := h.Collections.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cols {
+		if cols[i].ID == id {
+			return &cols[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func attachment(name string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": name})
+}
+
+// collectionFileName is "<collection name>.<format>" with path separators removed.
+func collectionFileName(name, format string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(strings.TrimSpace(name))
+	if name == "" || path.Base(name) != name {
+		name = "collection"
+	}
+	return name + "." + format
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/httpclient"
 	"github.com/samusafe/genericapi/internal/utils"
 )
 
@@ -16,12 +17,22 @@ var version = func() string {
 	return "dev"
 }()
 
+// Health reports liveness; an open Python circuit marks the service degraded but still answers 200
+// so orchestrators do not restart the API for an upstream outage.
 func Health(c *gin.Context) {
 	uptime := time.Since(startTime).Seconds()
+	status := "ok"
+	breakers := httpclient.BreakerStates()
+	for _, state := range breakers {
+		if state == httpclient.BreakerOpen {
+			status = "degraded"
+		}
+	}
 	utils.GinData(c, 200, gin.H{
-		"status":  "ok",
-		"version": version,
-		"uptime":  uptime,
-		"ready":   true,
+		"status":          status,
+		"version":         version,
+		"uptime":          uptime,
+		"ready":           true,
+		"circuitBreakers": breakers,
 	})
 }
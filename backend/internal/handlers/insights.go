@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/httpclient"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/services"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// InsightsHandler serves the aggregated view of a collection's documents.
+type InsightsHandler struct {
+	Collections repositories.CollectionsRepository
+	Insights    services.InsightsServiceInterface
+}
+
+func NewInsightsHandler(collections repositories.CollectionsRepository, insights services.InsightsServiceInterface) *InsightsHandler {
+	return &InsightsHandler{Collections: collections, Insights: insights}
+}
+
+// Get handles GET /collections/:id/insights; ?summary=true adds the meta-summary.
+func (h *InsightsHandler) Get(c *gin.Context) {
+	userID := c.GetString("userID")
+	cid := c.GetString(utils.CorrelationIDHeader)
+
+	id, ok := parsePositiveIntParam(c, "id")
+	if !ok {
+		return
+	}
+	withSummary := false
+	if raw := c.Query("summary"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "summary")
+			return
+		}
+		withSummary = v
+	}
+
+	exists, err := h.Collections.ExistsForUser(userID, id)
+	if err != nil {
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		return
+	}
+	if !exists {
+		utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		return
+	}
+
+	ctx := utils.WithCorrelationID(c.Request.Context(), cid)
+	insights, err := h.Insights.Insights(ctx, userID, id, withSummary)
+	switch {
+	case errors.Is(err, httpclient.ErrPythonUnavailable), errors.Is(err, httpclient.ErrCircuitOpen), errors.Is(err, httpclient.ErrBadStatus):
+		utils.GinError(c, http.StatusServiceUnavailable, "PythonServiceUnavailable", nil)
+	case err != nil:
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+	default:
+		utils.GinData(c, http.StatusOK, gin.H{"insights": insights})
+	}
+}
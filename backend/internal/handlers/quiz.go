@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// QuizHandler generates quizzes (from text or from a stored analysis) and serves stored ones.
+type QuizHandler struct {
+	Service services.QuizServiceInterface
+}
+
+func NewQuizHandler(service services.QuizServiceInterface) *QuizHandler {
+	return &QuizHandler{Service: service}
+}
+
+// GenerateQuiz handles POST /generate-quiz. With "analysisId" the quiz is built from the stored
+// document and persisted (the stored one is returned unless "regenerate" is set or it was
+// generated with other options); with "text" it is generated and not stored. "type", "count"
+// and "difficulty" select the questions.
+func (h *QuizHandler) GenerateQuiz(c *gin.Context) {
+	userID := c.GetString("userID")
+	lang := c.GetString("lang")
+	cid := c.GetString(utils.CorrelationIDHeader)
+
+	var requestBody struct {
+		Text       string `json:"text"`
+		AnalysisID int    `json:"analysisId"`
+		Regenerate bool   `json:"regenerate"`
+		models.QuizOptions
+	}
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	opts, ok := parseQuizOptions(c, requestBody.QuizOptions)
+	if !ok {
+		return
+	}
+	ctx := utils.WithCorrelationID(c.Request.Context(), cid)
+
+	if requestBody.AnalysisID != 0 {
+		if requestBody.AnalysisID < 0 {
+			utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "analysisId")
+			return
+		}
+		quiz, err := h.Service.GenerateForAnalysis(ctx, userID, requestBody.AnalysisID, lang, opts, requestBody.Regenerate)
+		if err != nil {
+			respondQuizError(c, err)
+			return
+		}
+		utils.GinData(c, http.StatusOK, quiz)
+		return
+	}
+
+	if requestBody.Text == "" {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "empty text")
+		return
+	}
+
+	// Input size guard using config.QuizMaxChars
+	if len(requestBody.Text) > config.QuizMaxChars {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "text too large")
+		return
+	}
+
+	quiz, err := h.Service.GenerateFromText(ctx, requestBody.Text, lang, opts)
+	if err != nil {
+		respondQuizError(c, err)
+		return
+	}
+
+	utils.GinData(c, http.StatusOK, quiz)
+}
+
+// parseQuizOptions applies the defaults (open questions, config.QuizQuestions, medium) and
+// rejects unknown types/difficulties and counts outside 1..config.QuizMaxQuestions.
+func parseQuizOptions(c *gin.Context, opts models.QuizOptions) (models.QuizOptions, bool) {
+	if opts.Type == "" {
+		opts.Type = models.QuizTypeOpen
+	}
+	if opts.Difficulty == "" {
+		opts.Difficulty = models.QuizDifficultyMedium
+	}
+	if opts.Count == 0 {
+		opts.Count = min(config.QuizQuestions, config.QuizMaxQuestions)
+	}
+	switch {
+	case !slices.Contains(config.QuizTypes, opts.Type):
+		utils.GinError(c, http.StatusBadRequest, "InvalidQuizOptions", "type")
+	case !slices.Contains(config.QuizDifficulties, opts.Difficulty):
+		utils.GinError(c, http.StatusBadRequest, "InvalidQuizOptions", "difficulty")
+	case opts.Count < 1 || opts.Count > config.QuizMaxQuestions:
+		utils.GinError(c, http.StatusBadRequest, "InvalidQuizOptions", "count")
+	default:
+		return opts, true
+	}
+	return opts, false
+}
+
+func respondQuizError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		utils.GinMsg(c, http.StatusNotFound, "NotFound")
+	case errors.Is(err, services.ErrEmptyQuiz):
+		utils.GinError(c, http.StatusUnprocessableEntity, "QuizNotGenerated", nil)
+	case errors.Is(err, services.ErrMalformedQuiz):
+		utils.GinError(c, http.StatusBadGateway, "QuizMalformed", nil)
+	default:
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err.Error())
+	}
+}
+
+// GetAnalysisQuiz handles GET /analyses/:id/quiz (latest version, or ?version=N).
+func (h *QuizHandler) GetAnalysisQuiz(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	analysisID, ok := parsePositiveIntParam(c, "id")
+	if !ok {
+		return
+	}
+	version := 0
+	if raw := c.Query("version"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "version")
+			return
+		}
+		version = v
+	}
+
+	quiz, err := h.Service.GetQuiz(userID, analysisID, version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.GinMsg(c, http.StatusNotFound, "NotFound")
+		} else {
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		}
+		return
+	}
+	utils.GinData(c, http.StatusOK, quiz)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// ReviewHandler records quiz attempts and lists the questions due for review.
+type ReviewHandler struct {
+	Service services.ReviewServiceInterface
+}
+
+func NewReviewHandler(service services.ReviewServiceInterface) *ReviewHandler {
+	return &ReviewHandler{Service: service}
+}
+
+// SubmitAttempt handles POST /quizzes/:id/attempts, where the quiz id is the analysis it was
+// generated from. "version" selects a stored quiz version (default: the latest).
+func (h *ReviewHandler) SubmitAttempt(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	analysisID, ok := parsePositiveIntParam(c, "id")
+	if !ok {
+		return
+	}
+	var body struct {
+		Version int                      `json:"version"`
+		Answers []models.SubmittedAnswer `json:"answers"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	if body.Version < 0 {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "version")
+		return
+	}
+	if len(body.Answers) == 0 {
+		utils.GinError(c, http.StatusBadRequest, "QuizAnswersRequired", nil)
+		return
+	}
+	for _, a := range body.Answers {
+		if utf8.RuneCountInString(a.Answer) > config.QuizMaxAnswerChars {
+			utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "answer too long")
+			return
+		}
+	}
+
+	attempt, err := h.Service.SubmitAttempt(userID, analysisID, body.Version, body.Answers)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		utils.GinMsg(c, http.StatusNotFound, "NotFound")
+	case errors.Is(err, services.ErrInvalidAnswer):
+		utils.GinError(c, http.StatusBadRequest, "InvalidQuizAnswer", err.Error())
+	case err != nil:
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+	default:
+		utils.GinData(c, http.StatusCreated, attempt)
+	}
+}
+
+// DueReviews handles GET /reviews/due?limit=&page=: the user's questions due now, most overdue first.
+func (h *ReviewHandler) DueReviews(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	limit, offset := parsePageQuery(c)
+	items, total, err := h.Service.DueReviews(userID, limit, offset)
+	if err != nil {
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		return
+	}
+	utils.GinData(c, http.StatusOK, gin.H{"items": items, "total": total})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// SearchHandler serves full-text search across the user's documents and analyses.
+type SearchHandler struct {
+	Repo            repositories.SearchRepository
+	CollectionsRepo repositories.CollectionsRepository
+}
+
+func NewSearchHandler(repo repositories.SearchRepository, collectionsRepo repositories.CollectionsRepository) *SearchHandler {
+	return &SearchHandler{Repo: repo, CollectionsRepo: collectionsRepo}
+}
+
+// Search handles GET /search?q=&collectionId=&limit=&page=. The query uses web search syntax
+// ("quoted phrases", OR, -excluded) and is stemmed in the request language.
+func (h *SearchHandler) Search(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		utils.GinError(c, http.StatusBadRequest, "SearchQueryRequired", nil)
+		return
+	}
+	if utf8.RuneCountInString(text) > config.SearchMaxChars {
+		utils.GinError(c, http.StatusBadRequest, "SearchQueryTooLong", nil)
+		return
+	}
+
+	var collectionID *int
+	if raw := c.Query("collectionId"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "collectionId")
+			return
+		}
+		exists, err := h.CollectionsRepo.ExistsForUser(userID, id)
+		if err != nil {
+			utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+			return
+		}
+		if !exists {
+			utils.GinMsg(c, http.StatusNotFound, "NotFound")
+			return
+		}
+		collectionID = &id
+	}
+
+	limit, offset := parsePageQuery(c)
+	hits, total, err := h.Repo.Search(userID, models.SearchQuery{Text: text, Lang: c.GetString("lang"), CollectionID: collectionID, Limit: limit, Offset: offset})
+	if err != nil {
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+		return
+	}
+
+	utils.GinData(c, http.StatusOK, gin.H{"items": hits, "total": total})
+}
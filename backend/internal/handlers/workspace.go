@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/services"
+	"github.com/samusafe/genericapi/internal/utils"
+	"github.com/samusafe/genericapi/internal/workspace"
+)
+
+// WorkspaceHandler exports and imports the authenticated user's whole workspace.
+type WorkspaceHandler struct {
+	Service services.WorkspaceServiceInterface
+}
+
+func NewWorkspaceHandler(service services.WorkspaceServiceInterface) *WorkspaceHandler {
+	return &WorkspaceHandler{Service: service}
+}
+
+// Export handles GET /me/export: a gzip-compressed workspace archive, streamed. A failure midway
+// is logged and leaves a truncated archive, which import rejects.
+func (h *WorkspaceHandler) Export(c *gin.Context) {
+	userID := c.GetString("userID")
+	cid := c.GetString(utils.CorrelationIDHeader)
+
+	name := fmt.Sprintf("workspace-%s.json.gz", time.Now().UTC().Format("2006-01-02"))
+	c.Header("Content-Disposition", attachment(name))
+	c.Header("Content-Type", "application/gzip")
+	c.Status(http.StatusOK)
+	if err := h.Service.Export(c.Request.Context(), userID, c.Writer); err != nil {
+		log.Error().Str("cid", cid).Err(err).Msg("workspace export aborted")
+		c.Abort()
+	}
+}
+
+// Import handles POST /me/import?dryRun=true|false (default false). The body is the archive,
+// gzip-compressed or plain JSON, up to config.WorkspaceImportMaxBytes.
+func (h *WorkspaceHandler) Import(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		utils.GinError(c, http.StatusBadRequest, "InvalidRequest", "dryRun")
+		return
+	}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, config.WorkspaceImportMaxBytes)
+
+	report, err := h.Service.Import(c.Request.Context(), userID, body, dryRun)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge), errors.Is(err, workspace.ErrArchiveTooLarge):
+		utils.GinMsg(c, http.StatusRequestEntityTooLarge, "WorkspaceArchiveTooLarge")
+	case errors.Is(err, workspace.ErrUnsupportedVersion):
+		utils.GinError(c, http.StatusBadRequest, "UnsupportedWorkspaceVersion", err.Error())
+	case errors.Is(err, workspace.ErrInvalidArchive):
+		utils.GinError(c, http.StatusBadRequest, "InvalidWorkspaceArchive", err.Error())
+	case err != nil:
+		utils.GinError(c, http.StatusInternalServerError, "InternalError", err)
+	default:
+		utils.GinData(c, http.StatusOK, report)
+	}
+}
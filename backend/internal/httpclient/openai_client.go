@@ -0,0 +1,162 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+// ErrUnsupported means the backend has no equivalent of the requested operation.
+var ErrUnsupported = errors.New("operation not supported by backend")
+
+// OpenAI-compatible servers (vLLM, llama.cpp, Ollama, ...) only offer chat completions, so
+// openAIClient prompts the model for the analysis fields and answers with the same JSON bodies the
+// Python service returns. It only analyzes text extracted on our side (plain text and Markdown) and
+// has no embedding, QA or quiz endpoints, which is why it cannot be the default backend.
+type openAIClient struct {
+	*pythonClient
+	model         string
+	maxInputChars int
+}
+
+const defaultOpenAIMaxInputChars = 12000
+
+const openAIAnalyzePrompt = `Analyze the document sent by the user. Reply with a single JSON object and nothing else:
+{"summary": "<one paragraph>", "summary_points": ["<key point>", ...], "keywords": ["<keyword>", ...], "sentiment": "positive" | "neutral" | "negative"}`
+
+const openAISummarizePrompt = `Summarize the text sent by the user in one paragraph. Reply with the summary only.`
+
+// NewOpenAIClient returns a PythonClient backed by an OpenAI-compatible chat completions API.
+// baseURL includes the version prefix (e.g. http://llm:8000/v1); maxInputChars bounds the text
+// put into the prompt (0 uses the default).
+func NewOpenAIClient(name, baseURL, model string, maxInputChars int, timeout time.Duration) PythonClient {
+	return newOpenAIClient(newPythonClient(name, strings.TrimRight(baseURL, "/"), timeout), model, maxInputChars)
+}
+
+// NewOpenAIClientWithPolicy allows injecting the http client, retry policy and breaker (tests).
+func NewOpenAIClientWithPolicy(baseURL, model string, maxInputChars int, client *http.Client, retry RetryPolicy, breaker *CircuitBreaker) PythonClient {
+	return newOpenAIClient(&pythonClient{baseURL: strings.TrimRight(baseURL, "/"), client: client, retry: retry, breaker: breaker}, model, maxInputChars)
+}
+
+func newOpenAIClient(p *pythonClient, model string, maxInputChars int) *openAIClient {
+	if maxInputChars <= 0 {
+		maxInputChars = defaultOpenAIMaxInputChars
+	}
+	return &openAIClient{pythonClient: p, model: model, maxInputChars: maxInputChars}
+}
+
+// AnalyzeWithCtx is unsupported: the model cannot parse documents, only extracted text.
+func (o *openAIClient) AnalyzeWithCtx(ctx context.Context, file io.ReadSeeker, filename string, correlationID string) (*http.Response, error) {
+	return nil, fmt.Errorf("%w: %s needs text extracted first", ErrUnsupported, filename)
+}
+
+// AnalyzeTextWithCtx asks the model for the analysis fields; fullText is the text itself. A reply
+// that is not the requested JSON is passed through as the body, so decoding it fails like any
+// other malformed analysis.
+func (o *openAIClient) AnalyzeTextWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error) {
+	reply, err := o.complete(ctx, openAIAnalyzePrompt, text, correlationID)
+	if err != nil {
+		return nil, err
+	}
+	var out models.AnalysisResponse
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start || json.Unmarshal([]byte(reply[start:end+1]), &out) != nil {
+		return syntheticResponse([]byte(reply)), nil
+	}
+	out.FullText = text
+	out.Meta = &models.AnalysisMeta{ModelVersion: o.model}
+	return jsonResponse(out)
+}
+
+// SummarizeWithCtx answers with a {"summary": ...} body like the Python service.
+func (o *openAIClient) SummarizeWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error) {
+	reply, err := o.complete(ctx, openAISummarizePrompt, text, correlationID)
+	if err != nil {
+		return nil, err
+	}
+	return jsonResponse(map[string]string{"summary": strings.TrimSpace(reply)})
+}
+
+// ModelsWithCtx reports the configured model as the model version, so stored analyses are
+// analyzed again when the backend is pointed at another model.
+func (o *openAIClient) ModelsWithCtx(ctx context.Context, correlationID string) (*http.Response, error) {
+	return jsonResponse(models.BackendModels{ModelVersion: o.model})
+}
+
+func (o *openAIClient) EmbedWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error) {
+	return nil, fmt.Errorf("%w: embeddings", ErrUnsupported)
+}
+
+func (o *openAIClient) AnswerWithCtx(ctx context.Context, body []byte, correlationID string) (*http.Response, error) {
+	return nil, fmt.Errorf("%w: question answering", ErrUnsupported)
+}
+
+func (o *openAIClient) GenerateQuizWithCtx(ctx context.Context, body []byte, correlationID string) (*http.Response, error) {
+	return nil, fmt.Errorf("%w: quiz generation", ErrUnsupported)
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// complete sends one chat completion and returns the content of the first choice.
+func (o *openAIClient) complete(ctx context.Context, prompt, text, correlationID string) (string, error) {
+	if len(text) > o.maxInputChars {
+		text = strings.ToValidUTF8(text[:o.maxInputChars], "")
+	}
+	body, err := json.Marshal(struct {
+		Model       string        `json:"model"`
+		Messages    []chatMessage `json:"messages"`
+		Temperature float64       `json:"temperature"`
+	}{Model: o.model, Messages: []chatMessage{{Role: "system", Content: prompt}, {Role: "user", Content: text}}})
+	if err != nil {
+		return "", err
+	}
+	resp, err := o.postJSON(ctx, "/chat/completions", body, correlationID)
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("%w: decode chat completion: %v", ErrBadStatus, err)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("%w: chat completion without choices", ErrBadStatus)
+	}
+	return out.Choices[0].Message.Content, nil
+}
+
+func jsonResponse(v any) (*http.Response, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return syntheticResponse(b), nil
+}
+
+// syntheticResponse wraps a translated body so callers read it like a Python service response.
+func syntheticResponse(body []byte) *http.Response {
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+}
@@ -3,7 +3,9 @@ package httpclient
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"sync"
@@ -19,74 +21,226 @@ var (
 
 // PythonClient defines the contract for calling the Python microservice.
 type PythonClient interface {
-	AnalyzeWithCtx(ctx context.Context, file []byte, filename string, correlationID string) (*http.Response, error)
+	AnalyzeWithCtx(ctx context.Context, file io.ReadSeeker, filename string, correlationID string) (*http.Response, error)
+	AnalyzeTextWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error)
+	EmbedWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error)
+	AnswerWithCtx(ctx context.Context, body []byte, correlationID string) (*http.Response, error)
 	GenerateQuizWithCtx(ctx context.Context, body []byte, correlationID string) (*http.Response, error)
+	ModelsWithCtx(ctx context.Context, correlationID string) (*http.Response, error)
+	SummarizeWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error)
 }
 
 type pythonClient struct {
 	baseURL string
 	client  *http.Client
+	retry   RetryPolicy
+	breaker *CircuitBreaker
 }
 
 var (
-	clientOnce sync.Once
-	singleton  *http.Client
+	clientsMu sync.Mutex
+	clients   = map[time.Duration]*http.Client{}
 )
 
-// NewPythonClient returns a PythonClient with a shared *http.Client.
+// sharedClient returns the process-wide *http.Client for a timeout. Backends with the same
+// timeout share one; all of them share the default transport and its connection pool.
+func sharedClient(timeout time.Duration) *http.Client {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	c, ok := clients[timeout]
+	if !ok {
+		c = &http.Client{Timeout: timeout}
+		clients[timeout] = c
+	}
+	return c
+}
+
+// NewPythonClient returns a PythonClient with a shared *http.Client, the default retry policy
+// and the process-wide "python" circuit breaker.
 func NewPythonClient(baseURL string, timeout time.Duration) PythonClient {
-	clientOnce.Do(func() {
-		singleton = &http.Client{Timeout: timeout}
-	})
-	return &pythonClient{baseURL: baseURL, client: singleton}
+	return NewNamedPythonClient(DefaultBackend, baseURL, timeout)
+}
+
+// NewNamedPythonClient is NewPythonClient for a named backend: each name gets its own breaker,
+// so one unhealthy engine does not fail fast the others, and its own timeout.
+func NewNamedPythonClient(name, baseURL string, timeout time.Duration) PythonClient {
+	return newPythonClient(name, baseURL, timeout)
 }
 
-func (p *pythonClient) AnalyzeWithCtx(ctx context.Context, file []byte, filename string, correlationID string) (*http.Response, error) {
-	var body bytes.Buffer
-	w := multipart.NewWriter(&body)
-	part, err := w.CreateFormFile("file", filename)
+func newPythonClient(name, baseURL string, timeout time.Duration) *pythonClient {
+	return &pythonClient{baseURL: baseURL, client: sharedClient(timeout), retry: DefaultRetryPolicy(), breaker: sharedBreaker(name)}
+}
+
+// NewPythonClientWithPolicy allows injecting the http client, retry policy and breaker (tests).
+func NewPythonClientWithPolicy(baseURL string, client *http.Client, retry RetryPolicy, breaker *CircuitBreaker) PythonClient {
+	return &pythonClient{baseURL: baseURL, client: client, retry: retry, breaker: breaker}
+}
+
+// AnalyzeWithCtx streams the file as a single-part multipart body: the part header, the file
+// itself and the closing boundary are chained, so the upload is never copied into memory. The
+// file is rewound for every attempt.
+func (p *pythonClient) AnalyzeWithCtx(ctx context.Context, file io.ReadSeeker, filename string, correlationID string) (*http.Response, error) {
+	size, err := file.Seek(0, io.SeekEnd)
 	if err != nil {
 		return nil, err
 	}
-	if _, err = part.Write(file); err != nil {
+	var envelope bytes.Buffer
+	w := multipart.NewWriter(&envelope)
+	if _, err := w.CreateFormFile("file", filename); err != nil {
 		return nil, err
 	}
-	if err = w.Close(); err != nil {
+	headerLen := envelope.Len()
+	if err := w.Close(); err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/analyze", &body)
+	header, trailer := envelope.Bytes()[:headerLen], envelope.Bytes()[headerLen:]
+
+	var prev *attemptBody
+	resp, err := p.do(ctx, correlationID, func() (*http.Request, error) {
+		if prev != nil {
+			<-prev.done // the transport may still be reading the previous attempt's body
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		prev = &attemptBody{Reader: io.MultiReader(bytes.NewReader(header), io.LimitReader(file, size), bytes.NewReader(trailer)), done: make(chan struct{})}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/analyze", prev)
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = int64(len(header)) + size + int64(len(trailer))
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		return req, nil
+	})
+	// Hand the file back only once the transport is done with it (callers rewind it again).
+	if prev != nil {
+		select {
+		case <-prev.done:
+		case <-ctx.Done():
+		}
+	}
+	return resp, err
+}
+
+// attemptBody reports when the transport has closed a request body.
+type attemptBody struct {
+	io.Reader
+	once sync.Once
+	done chan struct{}
+}
+
+func (b *attemptBody) Close() error {
+	b.once.Do(func() { close(b.done) })
+	return nil
+}
+
+// AnalyzeTextWithCtx analyzes text that was already extracted on our side (no file parsing).
+func (p *pythonClient) AnalyzeTextWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error) {
+	body, err := json.Marshal(map[string]string{"text": text})
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", w.FormDataContentType())
-	if correlationID != "" {
-		req.Header.Set(utils.CorrelationIDHeader, correlationID)
-	}
-	resp, err := p.client.Do(req)
+	return p.postJSON(ctx, "/analyze-text", body, correlationID)
+}
+
+// EmbedWithCtx computes the document embedding used for similarity search.
+func (p *pythonClient) EmbedWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error) {
+	body, err := json.Marshal(map[string]string{"text": text})
 	if err != nil {
-		return nil, ErrPythonUnavailable
-	}
-	if resp.StatusCode != http.StatusOK {
-		return resp, ErrBadStatus
+		return nil, err
 	}
-	return resp, nil
+	return p.postJSON(ctx, "/embed", body, correlationID)
+}
+
+// AnswerWithCtx asks the extractive QA endpoint; body is a models.AnswerRequest.
+func (p *pythonClient) AnswerWithCtx(ctx context.Context, body []byte, correlationID string) (*http.Response, error) {
+	return p.postJSON(ctx, "/answer", body, correlationID)
 }
 
 func (p *pythonClient) GenerateQuizWithCtx(ctx context.Context, body []byte, correlationID string) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/generate-quiz", bytes.NewBuffer(body))
+	return p.postJSON(ctx, "/generate-quiz", body, correlationID)
+}
+
+// SummarizeWithCtx summarizes text without the rest of the analysis (a {"summary": ...} body).
+func (p *pythonClient) SummarizeWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error) {
+	body, err := json.Marshal(map[string]string{"text": text})
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	if correlationID != "" {
-		req.Header.Set(utils.CorrelationIDHeader, correlationID)
-	}
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return nil, ErrPythonUnavailable
+	return p.postJSON(ctx, "/summarize", body, correlationID)
+}
+
+// ModelsWithCtx asks which models currently back /analyze (a models.BackendModels body).
+func (p *pythonClient) ModelsWithCtx(ctx context.Context, correlationID string) (*http.Response, error) {
+	return p.do(ctx, correlationID, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/models", nil)
+	})
+}
+
+func (p *pythonClient) postJSON(ctx context.Context, path string, body []byte, correlationID string) (*http.Response, error) {
+	return p.do(ctx, correlationID, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+}
+
+// do sends the request built by newReq through the breaker and retry policy.
+// Contract is unchanged for callers: transport failures become ErrPythonUnavailable and non-200
+// responses are returned together with ErrBadStatus.
+func (p *pythonClient) do(ctx context.Context, correlationID string, newReq func() (*http.Request, error)) (*http.Response, error) {
+	if err := p.breaker.Allow(correlationID); err != nil {
+		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
+	for attempt := 1; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			p.breaker.Abandon()
+			return nil, err
+		}
+		if correlationID != "" {
+			req.Header.Set(utils.CorrelationIDHeader, correlationID)
+		}
+		resp, err := p.client.Do(req)
+
+		retryable := false
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				p.breaker.Abandon()
+				return nil, ErrPythonUnavailable
+			}
+			retryable = retryableError(err)
+		case resp.StatusCode == http.StatusOK:
+			p.breaker.Success(correlationID)
+			return resp, nil
+		case resp.StatusCode < http.StatusInternalServerError:
+			// The service answered (e.g. 400 for an unreadable file): healthy, just not retryable.
+			p.breaker.Success(correlationID)
+			return resp, ErrBadStatus
+		default:
+			retryable = retryableStatus(resp.StatusCode)
+		}
+
+		if retryable && attempt < p.retry.MaxAttempts {
+			if resp != nil {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			if p.retry.sleep(ctx, attempt) == nil {
+				continue
+			}
+			p.breaker.Abandon()
+			return nil, ErrPythonUnavailable
+		}
+
+		p.breaker.Failure(correlationID)
+		if err != nil {
+			return nil, ErrPythonUnavailable
+		}
 		return resp, ErrBadStatus
 	}
-	return resp, nil
 }
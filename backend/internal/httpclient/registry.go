@@ -0,0 +1,207 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/samusafe/genericapi/internal/config"
+)
+
+// DefaultBackend is the name of the backend built from PYTHON_SERVICE_URL.
+const DefaultBackend = "python"
+
+// RoutingRule sends matching files to Backend. Every non-empty criterion must match;
+// rules are evaluated in order and the first match wins.
+type RoutingRule struct {
+	Backend       string   `json:"backend"`
+	Extensions    []string `json:"extensions,omitempty"`
+	CollectionIDs []int    `json:"collectionIds,omitempty"`
+	UserIDs       []string `json:"userIds,omitempty"`
+}
+
+func (r RoutingRule) matches(req RouteRequest) bool {
+	if len(r.Extensions) > 0 && !slices.Contains(r.Extensions, strings.ToLower(path.Ext(req.FileName))) {
+		return false
+	}
+	if len(r.CollectionIDs) > 0 && (req.CollectionID == nil || !slices.Contains(r.CollectionIDs, *req.CollectionID)) {
+		return false
+	}
+	if len(r.UserIDs) > 0 && !slices.Contains(r.UserIDs, req.UserID) {
+		return false
+	}
+	return true
+}
+
+// RouteRequest describes the file being analyzed. Explicit (the "backend" form field) wins over rules.
+type RouteRequest struct {
+	Explicit     string
+	FileName     string
+	CollectionID *int
+	UserID       string
+}
+
+// Registry holds named analysis backends behind the PythonClient contract: Python service
+// deployments and OpenAI-compatible servers (see openAIClient).
+type Registry struct {
+	backends    map[string]PythonClient
+	rules       []RoutingRule
+	defaultName string
+}
+
+// NewRegistry creates a registry whose fallback backend is name.
+func NewRegistry(name string, client PythonClient) *Registry {
+	return &Registry{backends: map[string]PythonClient{name: client}, defaultName: name}
+}
+
+// Register adds (or replaces) a named backend.
+func (r *Registry) Register(name string, client PythonClient) {
+	r.backends[name] = client
+}
+
+// AddRule appends a routing rule; its backend must already be registered.
+func (r *Registry) AddRule(rule RoutingRule) error {
+	if !r.Has(rule.Backend) {
+		return fmt.Errorf("routing rule references unknown backend %q", rule.Backend)
+	}
+	for i, ext := range rule.Extensions {
+		rule.Extensions[i] = strings.ToLower(ext)
+	}
+	r.rules = append(r.rules, rule)
+	return nil
+}
+
+// Has reports whether a backend with that name is configured.
+func (r *Registry) Has(name string) bool {
+	_, ok := r.backends[name]
+	return ok
+}
+
+// Default returns the fallback backend.
+func (r *Registry) Default() (string, PythonClient) {
+	return r.defaultName, r.backends[r.defaultName]
+}
+
+// Route picks the backend for a file: explicit choice, then the first matching rule, then the default.
+func (r *Registry) Route(req RouteRequest) (string, PythonClient) {
+	if c, ok := r.backends[req.Explicit]; ok {
+		return req.Explicit, c
+	}
+	for _, rule := range r.rules {
+		if rule.matches(req) {
+			return rule.Backend, r.backends[rule.Backend]
+		}
+	}
+	return r.Default()
+}
+
+// Backend types accepted in ANALYSIS_BACKENDS_FILE.
+const (
+	BackendTypePython = "python"
+	BackendTypeOpenAI = "openai"
+)
+
+// backendConfig is one entry of ANALYSIS_BACKENDS_FILE. Type defaults to "python"; "openai" needs
+// Model. TimeoutSeconds overrides HTTP_CLIENT_TIMEOUT_SECONDS for this backend only.
+type backendConfig struct {
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	URL            string `json:"url"`
+	Model          string `json:"model"`
+	TimeoutSeconds int    `json:"timeoutSeconds"`
+	MaxInputChars  int    `json:"maxInputChars"`
+}
+
+func (b backendConfig) client() (PythonClient, error) {
+	if b.Name == "" || b.URL == "" {
+		return nil, fmt.Errorf("backend entries need name and url")
+	}
+	timeout := config.HTTPClientTimeout
+	if b.TimeoutSeconds > 0 {
+		timeout = time.Duration(b.TimeoutSeconds) * time.Second
+	}
+	switch b.Type {
+	case "", BackendTypePython:
+		return NewNamedPythonClient(b.Name, b.URL, timeout), nil
+	case BackendTypeOpenAI:
+		if b.Model == "" {
+			return nil, fmt.Errorf("openai backend %q needs a model", b.Name)
+		}
+		return NewOpenAIClient(b.Name, b.URL, b.Model, b.MaxInputChars, timeout), nil
+	default:
+		return nil, fmt.Errorf("backend %q has unknown type %q", b.Name, b.Type)
+	}
+}
+
+// backendsFile is the JSON layout accepted by ANALYSIS_BACKENDS_FILE.
+type backendsFile struct {
+	Default  string          `json:"default"`
+	Backends []backendConfig `json:"backends"`
+	Rules    []RoutingRule   `json:"rules"`
+}
+
+// LoadRegistry builds the registry from env:
+//   - PYTHON_SERVICE_URL always provides the "python" backend;
+//   - ANALYSIS_BACKENDS adds more Python service deployments as "name=url,name=url";
+//   - ANALYSIS_BACKENDS_FILE (JSON: default, backends, rules) adds backends of any type, with
+//     their own timeouts, and routing rules;
+//   - ANALYSIS_DEFAULT_BACKEND overrides the fallback name. Embeddings, QA and quizzes use the
+//     default, so it must be a Python service.
+func LoadRegistry() (*Registry, error) {
+	reg := NewRegistry(DefaultBackend, NewNamedPythonClient(DefaultBackend, config.PythonServiceURL, config.HTTPClientTimeout))
+
+	for _, pair := range strings.Split(config.AnalysisBackends, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, url, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" || strings.TrimSpace(url) == "" {
+			return nil, fmt.Errorf("invalid ANALYSIS_BACKENDS entry %q", pair)
+		}
+		name = strings.TrimSpace(name)
+		reg.Register(name, NewNamedPythonClient(name, strings.TrimSpace(url), config.HTTPClientTimeout))
+	}
+
+	defaultName := config.AnalysisDefaultBackend
+	if config.AnalysisBackendsFile != "" {
+		raw, err := os.ReadFile(config.AnalysisBackendsFile)
+		if err != nil {
+			return nil, err
+		}
+		var f backendsFile
+		if err := json.Unmarshal(raw, &f); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", config.AnalysisBackendsFile, err)
+		}
+		for _, b := range f.Backends {
+			client, err := b.client()
+			if err != nil {
+				return nil, err
+			}
+			reg.Register(b.Name, client)
+		}
+		for _, rule := range f.Rules {
+			if err := reg.AddRule(rule); err != nil {
+				return nil, err
+			}
+		}
+		if defaultName == "" {
+			defaultName = f.Default
+		}
+	}
+
+	if defaultName != "" {
+		if !reg.Has(defaultName) {
+			return nil, fmt.Errorf("default backend %q is not configured", defaultName)
+		}
+		if _, ok := reg.backends[defaultName].(*openAIClient); ok {
+			return nil, fmt.Errorf("default backend %q must be a python backend", defaultName)
+		}
+		reg.defaultName = defaultName
+	}
+	return reg, nil
+}
@@ -0,0 +1,198 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/samusafe/genericapi/internal/config"
+)
+
+// Resilience layer for calls to the Python service:
+// - RetryPolicy: bounded attempts with exponential backoff + full jitter, only for failures that are
+//   safe to repeat (the request never reached the service, or a gateway/unavailable status).
+// - CircuitBreaker: after Threshold consecutive failures calls fail fast with ErrCircuitOpen for
+//   Cooldown, then a single probe decides whether to close again. Every transition is logged with
+//   the correlation id of the call that caused it.
+
+var ErrCircuitOpen = errors.New("python service circuit open")
+
+// RetryPolicy controls how often a failed call is repeated.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy is configured from env.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: config.PythonRetryMaxAttempts, BaseDelay: config.PythonRetryBaseDelay, MaxDelay: config.PythonRetryMaxDelay}
+}
+
+// backoff returns the wait before the given retry (1-based) using full jitter.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.BaseDelay << (retry - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d + 1)
+}
+
+// sleep waits for the backoff or until ctx is done.
+func (p RetryPolicy) sleep(ctx context.Context, retry int) error {
+	t := time.NewTimer(p.backoff(retry))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// retryableStatus reports statuses that mean "not processed, try again".
+func retryableStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// retryableError reports transport errors where the request never reached the service
+// (connection refused, DNS failure while the pod restarts). Timeouts are not retried: the
+// service may still be working on the file.
+func retryableError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// CircuitBreaker is a consecutive-failure breaker with a single half-open probe.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	name      string
+	threshold int
+	cooldown  time.Duration
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{name: name, threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*CircuitBreaker{}
+)
+
+// sharedBreaker returns the process-wide breaker for a named upstream.
+func sharedBreaker(name string) *CircuitBreaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[name]
+	if !ok {
+		b = NewCircuitBreaker(name, config.PythonBreakerThreshold, config.PythonBreakerCooldown)
+		breakers[name] = b
+	}
+	return b
+}
+
+// BreakerStates reports the state of every shared breaker (used by /health).
+func BreakerStates() map[string]BreakerState {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	out := make(map[string]BreakerState, len(breakers))
+	for name, b := range breakers {
+		out[name] = b.State()
+	}
+	return out
+}
+
+// State returns the current state, reporting an expired open breaker as half-open.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Allow reports whether a call may proceed. In half-open state only one probe is let through.
+func (b *CircuitBreaker) Allow(cid string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.transition(BreakerHalfOpen, cid)
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	}
+	return nil
+}
+
+// Success records a call that reached a healthy service.
+func (b *CircuitBreaker) Success(cid string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+	if b.state != BreakerClosed {
+		b.transition(BreakerClosed, cid)
+	}
+}
+
+// Failure records a call that failed after all retries.
+func (b *CircuitBreaker) Failure(cid string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		b.openedAt = time.Now()
+		b.transition(BreakerOpen, cid)
+	}
+}
+
+// Abandon releases a half-open probe whose outcome is unknown (e.g. the caller cancelled).
+func (b *CircuitBreaker) Abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *CircuitBreaker) transition(to BreakerState, cid string) {
+	log.Warn().Str("cid", cid).Str("breaker", b.name).Str("from", string(b.state)).Str("to", string(to)).Int("failures", b.failures).Msg("circuit breaker state change")
+	b.state = to
+}
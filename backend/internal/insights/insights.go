@@ -0,0 +1,103 @@
+// Package insights aggregates the latest analyses of a collection's documents.
+package insights
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/samusafe/genericapi/internal/grading"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+// Compute returns the topKeywords most common keywords (by documents, then occurrences), the
+// sentiment distribution and the documents added per month. Keywords are grouped after
+// grading.Normalize and keep the wording they first appear with.
+func Compute(collectionID int, docs []models.InsightsDocument, topKeywords int) models.CollectionInsights {
+	out := models.CollectionInsights{
+		CollectionID: collectionID,
+		Documents:    len(docs),
+		Keywords:     []models.KeywordStat{},
+		Sentiments:   []models.SentimentStat{},
+		Timeline:     []models.PeriodStat{},
+		ComputedAt:   time.Now().UTC(),
+	}
+
+	keywords := map[string]*models.KeywordStat{}
+	var order []string
+	sentiments := map[string]int{}
+	periods := map[string]int{}
+	for _, d := range docs {
+		seen := map[string]bool{}
+		for _, kw := range d.Keywords {
+			k := grading.Normalize(kw)
+			if k == "" {
+				continue
+			}
+			stat, ok := keywords[k]
+			if !ok {
+				stat = &models.KeywordStat{Keyword: strings.TrimSpace(kw)}
+				keywords[k] = stat
+				order = append(order, k)
+			}
+			stat.Count++
+			if !seen[k] {
+				seen[k] = true
+				stat.Documents++
+			}
+		}
+
+		sentiment := strings.ToLower(strings.TrimSpace(d.Sentiment))
+		if sentiment == "" {
+			sentiment = "unknown"
+		}
+		sentiments[sentiment]++
+		periods[d.CreatedAt.UTC().Format("2006-01")]++
+	}
+
+	for _, k := range order {
+		out.Keywords = append(out.Keywords, *keywords[k])
+	}
+	// Stable: equal keywords keep the order they first appear in
+	slices.SortStableFunc(out.Keywords, func(a, b models.KeywordStat) int {
+		return cmp.Or(cmp.Compare(b.Documents, a.Documents), cmp.Compare(b.Count, a.Count))
+	})
+	if len(out.Keywords) > topKeywords {
+		out.Keywords = out.Keywords[:topKeywords]
+	}
+
+	for s, n := range sentiments {
+		out.Sentiments = append(out.Sentiments, models.SentimentStat{Sentiment: s, Documents: n})
+	}
+	slices.SortFunc(out.Sentiments, func(a, b models.SentimentStat) int {
+		return cmp.Or(cmp.Compare(b.Documents, a.Documents), cmp.Compare(a.Sentiment, b.Sentiment))
+	})
+
+	for p, n := range periods {
+		out.Timeline = append(out.Timeline, models.PeriodStat{Period: p, Documents: n})
+	}
+	slices.SortFunc(out.Timeline, func(a, b models.PeriodStat) int { return cmp.Compare(a.Period, b.Period) })
+	return out
+}
+
+// SummaryInput joins the document summaries sent for the meta-summary. Summaries are taken in
+// order while they fit in maxChars; one that does not fit is left out whole.
+func SummaryInput(docs []models.InsightsDocument, maxChars int) string {
+	var parts []string
+	total := 0
+	for _, d := range docs {
+		s := strings.TrimSpace(d.Summary)
+		if s == "" {
+			continue
+		}
+		n := utf8.RuneCountInString(s)
+		if total+n > maxChars {
+			continue
+		}
+		parts = append(parts, s)
+		total += n + 2
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Who asked for an account deletion.
+const (
+	DeletionRequestedByUser  = "user"
+	DeletionRequestedByAdmin = "admin"
+)
+
+// DeletionCounts tallies the rows (and original uploads) removed by an account deletion.
+type DeletionCounts struct {
+	Collections      int `json:"collections"`
+	Documents        int `json:"documents"`
+	Analyses         int `json:"analyses"`
+	QuizQuestions    int `json:"quizQuestions"`
+	QuizAttempts     int `json:"quizAttempts"`
+	QuizAnswers      int `json:"quizAnswers"`
+	ReviewSchedules  int `json:"reviewSchedules"`
+	Embeddings       int `json:"embeddings"`
+	Chunks           int `json:"chunks"`
+	AnalysisJobs     int `json:"analysisJobs"`
+	AnalysisFailures int `json:"analysisFailures"`
+	QuarantineEvents int `json:"quarantineEvents"`
+	// Blobs counts the original uploads released for deletion from the blob store after the commit
+	// (a failed delete is only logged); blobs still used by another user's documents are kept.
+	Blobs int `json:"blobs"`
+}
+
+// DeletionReport is the signed outcome of an account deletion. Subject is a keyed hash of the
+// user ID, so the report (and the audit record holding the same fields) carries no personal
+// content; Signature is an HMAC-SHA256 over the other fields.
+type DeletionReport struct {
+	ID          string         `json:"id"`
+	Subject     string         `json:"subject"`
+	RequestedBy string         `json:"requestedBy"`
+	DeletedAt   time.Time      `json:"deletedAt"`
+	Counts      DeletionCounts `json:"counts"`
+	Signature   string         `json:"signature"`
+}
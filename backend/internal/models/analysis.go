@@ -7,6 +7,38 @@ type AnalysisResponse struct {
 	Keywords      []string `json:"keywords"`
 	Sentiment     string   `json:"sentiment"`
 	FullText      string   `json:"fullText"`
+	// Meta is reported by backends that support it; nil otherwise.
+	Meta *AnalysisMeta `json:"meta,omitempty"`
+}
+
+// AnalysisMeta records how an analysis was produced. ModelVersion identifies the whole set of
+// models; a stored result is only reused while it matches the backend's current one.
+type AnalysisMeta struct {
+	ModelVersion string               `json:"modelVersion"`
+	Models       map[string]ModelInfo `json:"models,omitempty"`
+	Language     string               `json:"language,omitempty"`
+	Tokens       *TokenCounts         `json:"tokens,omitempty"`
+	// TimingsMs holds the duration of each stage (extract, sentiment, keywords, summary, total).
+	TimingsMs map[string]float64 `json:"timingsMs,omitempty"`
+}
+
+// ModelInfo names one model behind a pipeline stage (summarizer, keywords, sentiment).
+type ModelInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// TokenCounts are measured with Tokenizer (the summarizer's, or "whitespace" as a fallback).
+type TokenCounts struct {
+	Input     int    `json:"input"`
+	Summary   int    `json:"summary"`
+	Tokenizer string `json:"tokenizer,omitempty"`
+}
+
+// BackendModels is the backend's GET /models answer: the models that would analyze a file now.
+type BackendModels struct {
+	ModelVersion string               `json:"modelVersion"`
+	Models       map[string]ModelInfo `json:"models,omitempty"`
 }
 
 // AnalysisResult holds the outcome of a single file analysis.
@@ -14,13 +46,50 @@ type AnalysisResult struct {
 	FileName string            `json:"fileName"`
 	Data     *AnalysisResponse `json:"data,omitempty"`
 	Error    string            `json:"error,omitempty"`
-	Reused   bool              `json:"reused,omitempty"`
+	// ErrorKey is the message key of Error (e.g. "PythonServiceUnavailable"), stable across languages.
+	ErrorKey string `json:"errorKey,omitempty"`
+	Reused   bool   `json:"reused,omitempty"`
+	Backend  string `json:"backend,omitempty"`
+}
+
+// BatchSummary closes a streamed analysis with aggregate counts.
+type BatchSummary struct {
+	BatchID   *string `json:"batchId,omitempty"`
+	Total     int     `json:"total"`
+	Succeeded int     `json:"succeeded"`
+	Failed    int     `json:"failed"`
+	Reused    int     `json:"reused"`
+}
+
+// Quiz question types and difficulties accepted by the quiz generator.
+const (
+	QuizTypeOpen           = "open"
+	QuizTypeMultipleChoice = "multiple_choice"
+	QuizTypeTrueFalse      = "true_false"
+	QuizTypeCloze          = "cloze"
+
+	QuizDifficultyEasy   = "easy"
+	QuizDifficultyMedium = "medium"
+	QuizDifficultyHard   = "hard"
+)
+
+// QuizOptions selects what the generator produces.
+type QuizOptions struct {
+	Type       string `json:"type"`
+	Count      int    `json:"count"`
+	Difficulty string `json:"difficulty"`
 }
 
-// QuizQuestion represents a single question in a quiz.
+// QuizQuestion represents a single question in a quiz. ID is set once the question is stored.
+// Multiple-choice questions carry Options and CorrectIndex (Answer is the correct option);
+// true/false answers are "True" or "False"; cloze questions contain a "_____" blank.
 type QuizQuestion struct {
-	Question string `json:"question"`
-	Answer   string `json:"answer"`
+	ID           int      `json:"id,omitempty"`
+	Type         string   `json:"type"`
+	Question     string   `json:"question"`
+	Answer       string   `json:"answer"`
+	Options      []string `json:"options,omitempty"`
+	CorrectIndex *int     `json:"correctIndex,omitempty"`
 }
 
 // QuizResponse represents the full quiz structure.
@@ -28,21 +97,75 @@ type QuizResponse struct {
 	Quiz []QuizQuestion `json:"quiz"`
 }
 
+// StoredQuiz is one persisted version of an analysis' quiz.
+type StoredQuiz struct {
+	AnalysisID int            `json:"analysisId"`
+	Version    int            `json:"version"`
+	Versions   []int          `json:"versions"`
+	CreatedAt  string         `json:"createdAt"`
+	Options    QuizOptions    `json:"options"`
+	Quiz       []QuizQuestion `json:"quiz"`
+	// Reused is true when a stored quiz was returned instead of generating a new one.
+	Reused bool `json:"reused,omitempty"`
+}
+
 // AnalysisDetail contains fields used by latest-analysis endpoint.
 type AnalysisDetail struct {
-	AnalysisID      int      `json:"analysisId"`
-	DocumentID      int      `json:"documentId"`
-	FileName        string   `json:"fileName"`
-	Summary         string   `json:"summary"`
-	SummaryPoints   []string `json:"summaryPoints,omitempty"`
-	Sentiment       string   `json:"sentiment"`
-	Keywords        []string `json:"keywords"`
-	CollectionID    *int     `json:"collectionId,omitempty"`
-	CreatedAt       string   `json:"createdAt"`
-	AnalysisVersion int      `json:"analysisVersion"`
-	BatchID         *string  `json:"batchId,omitempty"`
-	BatchSize       *int     `json:"batchSize,omitempty"`
-	FullText        string   `json:"fullText"`
+	AnalysisID      int           `json:"analysisId"`
+	DocumentID      int           `json:"documentId"`
+	FileName        string        `json:"fileName"`
+	Summary         string        `json:"summary"`
+	SummaryPoints   []string      `json:"summaryPoints,omitempty"`
+	Sentiment       string        `json:"sentiment"`
+	Keywords        []string      `json:"keywords"`
+	CollectionID    *int          `json:"collectionId,omitempty"`
+	CreatedAt       string        `json:"createdAt"`
+	AnalysisVersion int           `json:"analysisVersion"`
+	BatchID         *string       `json:"batchId,omitempty"`
+	BatchSize       *int          `json:"batchSize,omitempty"`
+	Backend         string        `json:"backend"`
+	FullText        string        `json:"fullText"`
+	Meta            *AnalysisMeta `json:"meta,omitempty"`
+}
+
+// AnalysisVersion is one distinct analysis result of a document (GET /documents/:documentId/analyses).
+// Uses counts the history rows carrying it: the fresh analysis plus every reuse.
+type AnalysisVersion struct {
+	Version       int           `json:"version"`
+	AnalysisID    int           `json:"analysisId"`
+	CreatedAt     string        `json:"createdAt"`
+	Backend       string        `json:"backend"`
+	Summary       string        `json:"summary"`
+	SummaryPoints []string      `json:"summaryPoints"`
+	Sentiment     string        `json:"sentiment"`
+	Keywords      []string      `json:"keywords"`
+	Meta          *AnalysisMeta `json:"meta,omitempty"`
+	Uses          int           `json:"uses"`
+}
+
+// AnalysisDiff compares two analysis versions of a document.
+type AnalysisDiff struct {
+	From          int           `json:"from"`
+	To            int           `json:"to"`
+	Keywords      ListDiff      `json:"keywords"`
+	SummaryPoints ListDiff      `json:"summaryPoints"`
+	Sentiment     SentimentDiff `json:"sentiment"`
+	// SummaryChanged reports whether the summary text differs (ignoring case and surrounding space).
+	SummaryChanged bool `json:"summaryChanged"`
+}
+
+// ListDiff splits two lists into entries only in the newer one, only in the older one, and in both.
+type ListDiff struct {
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+	Unchanged []string `json:"unchanged"`
+}
+
+// SentimentDiff holds the sentiment of both versions.
+type SentimentDiff struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Changed bool   `json:"changed"`
 }
 
 type DocumentItem struct {
@@ -52,3 +175,19 @@ type DocumentItem struct {
 	LastAnalysisAt string `json:"lastAnalysisAt,omitempty"`
 	CollectionID   *int   `json:"collectionId,omitempty"`
 }
+
+// DocumentFile locates the original upload of a document in the blob store.
+type DocumentFile struct {
+	ID           int
+	FileName     string
+	ContentHash  string
+	DetectedType string
+}
+
+// DocumentPatch changes a document (PATCH /documents/:documentId). FileName nil keeps the name;
+// Move is set when a collection was given, and CollectionID nil then means uncategorized.
+type DocumentPatch struct {
+	FileName     *string
+	Move         bool
+	CollectionID *int
+}
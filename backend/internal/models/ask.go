@@ -0,0 +1,57 @@
+package models
+
+// DocumentChunk is a stored passage retrieved for question answering.
+type DocumentChunk struct {
+	ID         int64
+	DocumentID int
+	Index      int
+	FileName   string
+	Start      int
+	End        int
+	Content    string
+}
+
+// DocumentText is a document's extracted text, used to chunk documents stored before chunking existed.
+type DocumentText struct {
+	ID       int
+	FullText string
+}
+
+// AnswerContext is one passage sent to the Python /answer endpoint.
+type AnswerContext struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+// AnswerRequest is the Python /answer payload.
+type AnswerRequest struct {
+	Question string          `json:"question"`
+	Contexts []AnswerContext `json:"contexts"`
+}
+
+// AnswerResponse is the Python /answer reply; citation offsets are relative to the context text.
+type AnswerResponse struct {
+	Answer    string  `json:"answer"`
+	Score     float64 `json:"score"`
+	Citations []struct {
+		ContextID int `json:"contextId"`
+		Start     int `json:"start"`
+		End       int `json:"end"`
+	} `json:"citations"`
+}
+
+// Citation points at the supporting span in a document's full text (character offsets).
+type Citation struct {
+	DocumentID int    `json:"documentId"`
+	FileName   string `json:"fileName"`
+	Start      int    `json:"start"`
+	End        int    `json:"end"`
+	Quote      string `json:"quote"`
+}
+
+// AskResult is the answer returned by POST /collections/:id/ask.
+type AskResult struct {
+	Answer    string     `json:"answer"`
+	Score     float64    `json:"score"`
+	Citations []Citation `json:"citations"`
+}
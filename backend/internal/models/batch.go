@@ -0,0 +1,61 @@
+package models
+
+// AnalysisFailure is a file of a batched upload that produced no analysis. ContentStored means
+// the upload was kept in the blob store (under ContentHash) so the file can be retried.
+type AnalysisFailure struct {
+	ID            int
+	UserID        string
+	BatchID       string
+	BatchSize     int
+	CollectionID  *int
+	FileName      string
+	ContentHash   string
+	ErrorKey      string
+	Backend       string
+	Force         bool
+	ContentStored bool
+	CorrelationID string
+}
+
+// BatchItem summarizes one multi-file upload (GET /batches). Succeeded counts the analyses
+// stored for the batch and Failed the files recorded as failures.
+type BatchItem struct {
+	BatchID    string `json:"batchId"`
+	FileCount  int    `json:"fileCount"`
+	Succeeded  int    `json:"succeeded"`
+	Failed     int    `json:"failed"`
+	StartedAt  string `json:"startedAt"`
+	FinishedAt string `json:"finishedAt"`
+}
+
+// BatchAnalysis is an analysis stored for a batch.
+type BatchAnalysis struct {
+	AnalysisID      int      `json:"analysisId"`
+	DocumentID      int      `json:"documentId"`
+	FileName        string   `json:"fileName"`
+	AnalysisVersion int      `json:"analysisVersion"`
+	Backend         string   `json:"backend"`
+	Summary         string   `json:"summary"`
+	SummaryPoints   []string `json:"summaryPoints"`
+	Sentiment       string   `json:"sentiment"`
+	Keywords        []string `json:"keywords"`
+	CreatedAt       string   `json:"createdAt"`
+}
+
+// BatchFailure is a failed file of a batch; Error is ErrorKey translated for the caller.
+// Retryable files are analyzed again by POST /batches/:id/retry.
+type BatchFailure struct {
+	ID        int    `json:"id"`
+	FileName  string `json:"fileName"`
+	ErrorKey  string `json:"errorKey"`
+	Error     string `json:"error"`
+	Retryable bool   `json:"retryable"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// BatchDetail is GET /batches/:id: the summary plus every analysis and failure of the batch.
+type BatchDetail struct {
+	BatchItem
+	Analyses []BatchAnalysis `json:"analyses"`
+	Failures []BatchFailure  `json:"failures"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// EmbeddingResponse is the Python /embed payload.
+type EmbeddingResponse struct {
+	Embedding  []float32 `json:"embedding"`
+	Model      string    `json:"model"`
+	Dimensions int       `json:"dimensions"`
+}
+
+// DocumentEmbedding is a stored document vector with what is needed to list it as related.
+type DocumentEmbedding struct {
+	DocumentID   int
+	FileName     string
+	CollectionID *int
+	Model        string
+	Vector       []float32
+}
+
+// RelatedDocument is a document ranked by cosine similarity to another one.
+type RelatedDocument struct {
+	DocumentID   int     `json:"documentId"`
+	FileName     string  `json:"fileName"`
+	CollectionID *int    `json:"collectionId,omitempty"`
+	Similarity   float64 `json:"similarity"`
+}
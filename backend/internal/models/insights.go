@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// InsightsDocument is the latest analysis of a document, as aggregated by the collection insights.
+type InsightsDocument struct {
+	DocumentID int
+	Summary    string
+	Keywords   []string
+	Sentiment  string
+	CreatedAt  time.Time // when the document was added
+}
+
+// KeywordStat is a keyword across the collection: Count is how many times the documents' keyword
+// lists contain it, Documents how many documents do.
+type KeywordStat struct {
+	Keyword   string `json:"keyword"`
+	Count     int    `json:"count"`
+	Documents int    `json:"documents"`
+}
+
+// SentimentStat counts the documents with one sentiment ("unknown" when none was reported).
+type SentimentStat struct {
+	Sentiment string `json:"sentiment"`
+	Documents int    `json:"documents"`
+}
+
+// PeriodStat counts the documents added in one month ("2006-01").
+type PeriodStat struct {
+	Period    string `json:"period"`
+	Documents int    `json:"documents"`
+}
+
+// CollectionInsights is GET /collections/:id/insights, computed from the latest analysis of each
+// document. Summary is only set when the meta-summary was requested.
+type CollectionInsights struct {
+	CollectionID int             `json:"collectionId"`
+	Documents    int             `json:"documents"`
+	Keywords     []KeywordStat   `json:"keywords"`
+	Sentiments   []SentimentStat `json:"sentiments"`
+	Timeline     []PeriodStat    `json:"timeline"`
+	Summary      string          `json:"summary,omitempty"`
+	ComputedAt   time.Time       `json:"computedAt"`
+	Cached       bool            `json:"cached"`
+}
+
+// CachedInsights is a stored computation and the collection fingerprint it was computed for.
+type CachedInsights struct {
+	Fingerprint string
+	Insights    CollectionInsights
+	Summary     *string
+}
@@ -0,0 +1,56 @@
+package models
+
+// Job and job file statuses.
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusDone      = "done"
+	JobStatusFailed    = "failed"
+)
+
+// AnalysisJob is an asynchronous multi-file analysis submitted via /analyze/jobs.
+type AnalysisJob struct {
+	ID           string            `json:"id"`
+	Status       string            `json:"status"`
+	CollectionID *int              `json:"collectionId,omitempty"`
+	Total        int               `json:"total"`
+	Completed    int               `json:"completed"`
+	Failed       int               `json:"failed"`
+	CreatedAt    string            `json:"createdAt"`
+	Files        []AnalysisJobFile `json:"files"`
+}
+
+// AnalysisJobFile reports the progress of a single file inside a job.
+// Result uses the same shape returned by the blocking /analyze endpoint.
+type AnalysisJobFile struct {
+	FileName  string          `json:"fileName"`
+	Status    string          `json:"status"`
+	Result    *AnalysisResult `json:"result,omitempty"`
+	UpdatedAt string          `json:"updatedAt"`
+}
+
+// JobFileTask is a claimed job file handed to a worker.
+type JobFileTask struct {
+	FileID        int
+	JobID         string
+	UserID        string
+	Lang          string
+	CorrelationID string
+	CollectionID  *int
+	Backend       string
+	Force         bool
+	FileCount     int
+	FileName      string
+	Content       []byte
+	// Attempt identifies this claim; updates for an older attempt are refused.
+	Attempt int
+}
+
+// JobUpload is a file accepted for asynchronous analysis. Rejected holds the final result of a
+// file that failed the malware scan on submit; its content is never stored.
+type JobUpload struct {
+	FileName string
+	Content  []byte
+	Rejected *AnalysisResult
+}
@@ -0,0 +1,15 @@
+package models
+
+// QuarantineInfected is the verdict stored for files the scanner flagged.
+const QuarantineInfected = "infected"
+
+// QuarantineEvent records an upload rejected by the malware scanner.
+type QuarantineEvent struct {
+	UserID        string
+	FileName      string
+	ContentHash   string
+	Scanner       string
+	Verdict       string
+	Signature     string
+	CorrelationID string
+}
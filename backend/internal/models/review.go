@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// SubmittedAnswer is one answer of a quiz attempt.
+type SubmittedAnswer struct {
+	QuestionID int    `json:"questionId"`
+	Answer     string `json:"answer"`
+}
+
+// AnswerResult is the grading of one submitted answer and when the question is due again.
+type AnswerResult struct {
+	QuestionID   int     `json:"questionId"`
+	Question     string  `json:"question"`
+	Answer       string  `json:"answer"`
+	Expected     string  `json:"expected"`
+	Correct      bool    `json:"correct"`
+	Score        float64 `json:"score"`
+	Quality      int     `json:"quality"`
+	NextReviewAt string  `json:"nextReviewAt"`
+}
+
+// QuizAttempt is a graded submission against one stored quiz version.
+type QuizAttempt struct {
+	ID         int            `json:"id"`
+	AnalysisID int            `json:"analysisId"`
+	Version    int            `json:"version"`
+	Correct    int            `json:"correct"`
+	Total      int            `json:"total"`
+	Score      float64        `json:"score"`
+	CreatedAt  string         `json:"createdAt"`
+	Results    []AnswerResult `json:"results"`
+}
+
+// ReviewState is the spaced-repetition schedule of one question for one user.
+type ReviewState struct {
+	QuestionID   int
+	Repetitions  int
+	IntervalDays int
+	Ease         float64
+	DueAt        time.Time
+}
+
+// DueReview is a question due for review, with its answer history.
+type DueReview struct {
+	QuestionID   int    `json:"questionId"`
+	AnalysisID   int    `json:"analysisId"`
+	Question     string `json:"question"`
+	DueAt        string `json:"dueAt"`
+	Repetitions  int    `json:"repetitions"`
+	IntervalDays int    `json:"intervalDays"`
+	Attempts     int    `json:"attempts"`
+	CorrectCount int    `json:"correctCount"`
+	LastCorrect  bool   `json:"lastCorrect"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// SearchQuery is a full-text search over one user's documents.
+type SearchQuery struct {
+	Text         string
+	Lang         string
+	CollectionID *int
+	Limit        int
+	Offset       int
+}
+
+// SearchHit is one matching document with its latest analysis. Snippet is HTML-escaped text with
+// the matched terms wrapped in <mark>.
+type SearchHit struct {
+	DocumentID   int      `json:"documentId"`
+	FileName     string   `json:"fileName"`
+	CollectionID *int     `json:"collectionId,omitempty"`
+	AnalysisID   *int     `json:"analysisId,omitempty"`
+	Summary      string   `json:"summary"`
+	Keywords     []string `json:"keywords"`
+	Snippet      string   `json:"snippet"`
+	Rank         float64  `json:"rank"`
+	CreatedAt    string   `json:"createdAt"`
+}
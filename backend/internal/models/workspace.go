@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// WorkspaceArchive is the portable copy of one user's workspace (GET /me/export, POST /me/import).
+// IDs are the ones of the exporting deployment and only link the records of the archive to each
+// other; they are remapped on import.
+type WorkspaceArchive struct {
+	Format        string                  `json:"format"`
+	Version       int                     `json:"version"`
+	ExportedAt    time.Time               `json:"exportedAt"`
+	Collections   []WorkspaceCollection   `json:"collections"`
+	Documents     []WorkspaceDocument     `json:"documents"`
+	Analyses      []WorkspaceAnalysis     `json:"analyses"`
+	QuizQuestions []WorkspaceQuizQuestion `json:"quizQuestions"`
+}
+
+type WorkspaceCollection struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type WorkspaceDocument struct {
+	ID           int       `json:"id"`
+	CollectionID *int      `json:"collectionId,omitempty"`
+	FileName     string    `json:"fileName"`
+	FullText     string    `json:"fullText"`
+	ContentHash  string    `json:"contentHash,omitempty"`
+	FileExt      string    `json:"fileExt,omitempty"`
+	DetectedType string    `json:"detectedType,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type WorkspaceAnalysis struct {
+	ID              int           `json:"id"`
+	DocumentID      int           `json:"documentId"`
+	Summary         string        `json:"summary"`
+	Keywords        []string      `json:"keywords"`
+	Sentiment       string        `json:"sentiment"`
+	SummaryPoints   []string      `json:"summaryPoints"`
+	AnalysisVersion int           `json:"analysisVersion"`
+	BatchID         *string       `json:"batchId,omitempty"`
+	BatchSize       *int          `json:"batchSize,omitempty"`
+	Backend         string        `json:"backend"`
+	Meta            *AnalysisMeta `json:"meta,omitempty"`
+	CreatedAt       time.Time     `json:"createdAt"`
+}
+
+type WorkspaceQuizQuestion struct {
+	ID             int       `json:"id"`
+	AnalysisID     int       `json:"analysisId"`
+	QuizVersion    int       `json:"quizVersion"`
+	Position       int       `json:"position"`
+	Type           string    `json:"type"`
+	Question       string    `json:"question"`
+	Answer         string    `json:"answer"`
+	Options        []string  `json:"options,omitempty"`
+	CorrectIndex   *int      `json:"correctIndex,omitempty"`
+	Difficulty     string    `json:"difficulty"`
+	RequestedCount int       `json:"requestedCount"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// ImportCounts tallies one record kind of an import.
+type ImportCounts struct {
+	Created int `json:"created"`
+	// Existing records were matched to ones already in the workspace and not imported again.
+	Existing int `json:"existing"`
+	// Skipped records belong to a document that already existed.
+	Skipped int `json:"skipped"`
+}
+
+// ImportConflict is an archive record that matched existing data.
+type ImportConflict struct {
+	Kind       string `json:"kind"` // "collection" (same name) or "document" (same content in the same collection)
+	ArchiveID  int    `json:"archiveId"`
+	Name       string `json:"name"`
+	ExistingID int    `json:"existingId"`
+}
+
+// ImportReport describes what an import did, or would do on a dry run.
+type ImportReport struct {
+	DryRun        bool             `json:"dryRun"`
+	Collections   ImportCounts     `json:"collections"`
+	Documents     ImportCounts     `json:"documents"`
+	Analyses      ImportCounts     `json:"analyses"`
+	QuizQuestions ImportCounts     `json:"quizQuestions"`
+	Conflicts     []ImportConflict `json:"conflicts"`
+}
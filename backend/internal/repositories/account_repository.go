@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+// AccountFinisher runs inside the deletion transaction once the user's rows are gone. It gets
+// the row counts and the content hashes no remaining document references (the blobs to delete
+// once the transaction commits), and returns the report to store as the audit record. An error
+// rolls everything back.
+type AccountFinisher func(counts models.DeletionCounts, orphanedBlobs []string) (*models.DeletionReport, error)
+
+type AccountRepository interface {
+	DeleteAccount(ctx context.Context, userID string, finish AccountFinisher) (*models.DeletionReport, []string, error)
+}
+
+type accountRepository struct{ db *sql.DB }
+
+func NewAccountRepository() AccountRepository { return &accountRepository{db: database.DB} }
+
+// accountTables lists every table holding rows of a user, children first so each count only
+// covers rows deleted by its own statement (not by a cascade).
+var accountTables = []struct {
+	table string
+	count func(*models.DeletionCounts) *int
+}{
+	{"quiz_answers", func(c *models.DeletionCounts) *int { return &c.QuizAnswers }},
+	{"review_schedule", func(c *models.DeletionCounts) *int { return &c.ReviewSchedules }},
+	{"quiz_attempts", func(c *models.DeletionCounts) *int { return &c.QuizAttempts }},
+	{"quiz_questions", func(c *models.DeletionCounts) *int { return &c.QuizQuestions }},
+	{"document_chunks", func(c *models.DeletionCounts) *int { return &c.Chunks }},
+	{"document_embeddings", func(c *models.DeletionCounts) *int { return &c.Embeddings }},
+	{"analyses", func(c *models.DeletionCounts) *int { return &c.Analyses }},
+	{"analysis_failures", func(c *models.DeletionCounts) *int { return &c.AnalysisFailures }},
+	{"documents", nil}, // counted below, together with the content hashes
+	{"collections", func(c *models.DeletionCounts) *int { return &c.Collections }},
+	{"analysis_jobs", func(c *models.DeletionCounts) *int { return &c.AnalysisJobs }}, // files cascade
+	{"quarantine_events", func(c *models.DeletionCounts) *int { return &c.QuarantineEvents }},
+}
+
+// DeleteAccount removes every row of the user in one transaction and stores the audit record
+// returned by finish before committing. It is serialized with workspace imports of the same user.
+// The orphaned hashes are returned for the caller to delete from the blob store after the commit.
+func (r *accountRepository) DeleteAccount(ctx context.Context, userID string, finish AccountFinisher) (*models.DeletionReport, []string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('workspace:' || $1))`, userID); err != nil {
+		return nil, nil, err
+	}
+
+	// Uploads kept to retry failed files go the same way as the documents' originals
+	retryHashes, err := storedFailureHashes(ctx, tx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var counts models.DeletionCounts
+	var hashes []string
+	for _, t := range accountTables {
+		if t.count == nil {
+			if hashes, err = deleteDocuments(ctx, tx, userID, &counts); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM `+t.table+` WHERE user_id=$1`, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, nil, err
+		}
+		*t.count(&counts) = int(n)
+	}
+
+	// Blobs are shared by content hash: only the ones no other user's document uses can go.
+	orphaned, err := unreferencedHashes(ctx, tx, append(hashes, retryHashes...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report, err := finish(counts, orphaned)
+	if err != nil {
+		return nil, nil, err
+	}
+	countsJSON, err := json.Marshal(report.Counts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO account_deletions(id, subject, requested_by, counts, signature, deleted_at)
+		VALUES($1,$2,$3,$4,$5,$6)`, report.ID, report.Subject, report.RequestedBy, countsJSON, report.Signature, report.DeletedAt); err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return report, orphaned, nil
+}
+
+// deleteDocuments removes the user's documents and returns their distinct content hashes.
+func deleteDocuments(ctx context.Context, tx *sql.Tx, userID string, counts *models.DeletionCounts) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `DELETE FROM documents WHERE user_id=$1 RETURNING COALESCE(content_hash,'')`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	seen := map[string]bool{}
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		counts.Documents++
+		if h != "" && !seen[h] {
+			seen[h] = true
+			hashes = append(hashes, h)
+		}
+	}
+	return hashes, rows.Err()
+}
+
+// storedFailureHashes returns the content hashes of the user's failed files whose upload was kept.
+func storedFailureHashes(ctx context.Context, tx *sql.Tx, userID string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT content_hash FROM analysis_failures
+		WHERE user_id=$1 AND content_stored AND content_hash IS NOT NULL`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes, rows.Err()
+}
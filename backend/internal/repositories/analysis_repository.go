@@ -2,7 +2,7 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
+	"encoding/json"
 	"log"
 	"strings"
 
@@ -12,31 +12,42 @@ import (
 )
 
 type AnalysisRepository interface {
-	InsertDocument(userID string, collectionID *int, fileName, fullText string, contentHash string) (int, error)
-	InsertAnalysis(userID string, documentID int, summary string, keywords []string, sentiment string, summaryPoints []string, batchID *string, batchSize *int) (int, error)
+	InsertDocument(userID string, collectionID *int, fileName, fullText string, contentHash string, fileExt, detectedType string) (int, error)
+	InsertAnalysis(userID string, documentID int, summary string, keywords []string, sentiment string, summaryPoints []string, batchID *string, batchSize *int, backend string, meta *models.AnalysisMeta) (int, error)
 	FindDocument(userID string, collectionID *int, contentHash string) (int, error)
 	GetLatestAnalysisByDocument(userID string, documentID int) (*models.AnalysisDetail, error)
 	ListDocumentsByCollection(userID string, collectionID *int) ([]models.DocumentItem, error)
 	ListAllDocuments(userID string, limit, offset int) ([]models.DocumentItem, int, error)
 	UpdateDocumentCollection(userID string, documentID int, collectionID int) error
+	InsertReusedAnalysis(userID string, sourceAnalysisID int, batchID *string, batchSize *int) (int, error)
+	UpdateDocumentText(userID string, documentID int, fullText string) error
+	ListAnalysisVersions(userID string, documentID int) ([]models.AnalysisVersion, error)
 }
 
 type analysisRepository struct{ db *sql.DB }
 
 func NewAnalysisRepository() AnalysisRepository { return &analysisRepository{db: database.DB} }
 
-func (r *analysisRepository) InsertDocument(userID string, collectionID *int, fileName, fullText string, contentHash string) (int, error) {
+// NewAnalysisRepositoryWithDB is NewAnalysisRepository on another connection pool (tests).
+func NewAnalysisRepositoryWithDB(db *sql.DB) AnalysisRepository { return &analysisRepository{db: db} }
+
+func (r *analysisRepository) InsertDocument(userID string, collectionID *int, fileName, fullText string, contentHash string, fileExt, detectedType string) (int, error) {
 	var id int
 	if collectionID != nil {
-		err := r.db.QueryRow(`INSERT INTO documents(user_id, collection_id, file_name, full_text, content_hash) VALUES($1,$2,$3,$4,$5) RETURNING id`, userID, *collectionID, fileName, fullText, contentHash).Scan(&id)
+		err := r.db.QueryRow(`INSERT INTO documents(user_id, collection_id, file_name, full_text, content_hash, file_ext, detected_type) VALUES($1,$2,$3,$4,$5,$6,$7) RETURNING id`, userID, *collectionID, fileName, fullText, contentHash, fileExt, detectedType).Scan(&id)
 		return id, err
 	}
-	err := r.db.QueryRow(`INSERT INTO documents(user_id, file_name, full_text, content_hash) VALUES($1,$2,$3,$4) RETURNING id`, userID, fileName, fullText, contentHash).Scan(&id)
+	err := r.db.QueryRow(`INSERT INTO documents(user_id, file_name, full_text, content_hash, file_ext, detected_type) VALUES($1,$2,$3,$4,$5,$6) RETURNING id`, userID, fileName, fullText, contentHash, fileExt, detectedType).Scan(&id)
 	return id, err
 }
 
-func (r *analysisRepository) InsertAnalysis(userID string, documentID int, summary string, keywords []string, sentiment string, summaryPoints []string, batchID *string, batchSize *int) (int, error) {
-	var id int
+// InsertAnalysis stores a fresh analysis as the document's next version. The document row is
+// locked so concurrent analyses of the same document get distinct versions.
+func (r *analysisRepository) InsertAnalysis(userID string, documentID int, summary string, keywords []string, sentiment string, summaryPoints []string, batchID *string, batchSize *int, backend string, meta *models.AnalysisMeta) (int, error) {
+	rawMeta, err := metaJSON(meta)
+	if err != nil {
+		return 0, err
+	}
 	clean := make([]string, 0, len(keywords))
 	for _, k := range keywords {
 		k = strings.TrimSpace(strings.ReplaceAll(k, ",", " "))
@@ -44,10 +55,90 @@ func (r *analysisRepository) InsertAnalysis(userID string, documentID int, summa
 			clean = append(clean, k)
 		}
 	}
-	if err := r.db.QueryRow(`INSERT INTO analyses(user_id, document_id, summary, keywords, sentiment, summary_points, analysis_version, batch_id, batch_size) VALUES($1,$2,$3,$4,$5,$6,1,$7,$8) RETURNING id`, userID, documentID, summary, pq.Array(clean), sentiment, pq.Array(summaryPoints), batchID, batchSize).Scan(&id); err != nil {
+	tx, err := r.db.Begin()
+	if err != nil {
 		return 0, err
 	}
-	return id, nil
+	defer tx.Rollback()
+	var id, version int
+	if err := tx.QueryRow(`SELECT id FROM documents WHERE id=$1 AND user_id=$2 FOR UPDATE`, documentID, userID).Scan(&id); err != nil {
+		return 0, err
+	}
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(analysis_version),0)+1 FROM analyses WHERE document_id=$1 AND user_id=$2`, documentID, userID).Scan(&version); err != nil {
+		return 0, err
+	}
+	if err := tx.QueryRow(`INSERT INTO analyses(user_id, document_id, summary, keywords, sentiment, summary_points, analysis_version, batch_id, batch_size, backend, meta) VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING id`, userID, documentID, summary, pq.Array(clean), sentiment, pq.Array(summaryPoints), version, batchID, batchSize, backend, rawMeta).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// InsertReusedAnalysis records a reuse of an existing analysis (history / batch grouping). The copy
+// keeps the source's version, backend and metadata: it is the same result, not a new version.
+func (r *analysisRepository) InsertReusedAnalysis(userID string, sourceAnalysisID int, batchID *string, batchSize *int) (int, error) {
+	var id int
+	err := r.db.QueryRow(`INSERT INTO analyses(user_id, document_id, summary, keywords, sentiment, summary_points, analysis_version, batch_id, batch_size, backend, meta)
+		SELECT user_id, document_id, summary, keywords, sentiment, summary_points, analysis_version, $3, $4, backend, meta
+		FROM analyses WHERE id=$1 AND user_id=$2
+		RETURNING id`, sourceAnalysisID, userID, batchID, batchSize).Scan(&id)
+	return id, err
+}
+
+// UpdateDocumentText replaces the stored text of a document after a forced re-analysis.
+func (r *analysisRepository) UpdateDocumentText(userID string, documentID int, fullText string) error {
+	res, err := r.db.Exec(`UPDATE documents SET full_text=$1 WHERE id=$2 AND user_id=$3`, fullText, documentID, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		return err
+	}
+	return nil
+}
+
+// ListAnalysisVersions returns one entry per analysis version of a document, oldest first, with the
+// first row stored for that version and how many rows (fresh + reuses) carry it. sql.ErrNoRows
+// means the document is not the user's.
+func (r *analysisRepository) ListAnalysisVersions(userID string, documentID int) ([]models.AnalysisVersion, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT ON (a.analysis_version) a.analysis_version, a.id, a.created_at, a.backend, a.summary, a.sentiment,
+			COALESCE(a.keywords, '{}'::text[]), COALESCE(a.summary_points, '{}'::text[]), a.meta, COUNT(*) OVER (PARTITION BY a.analysis_version)
+		FROM analyses a
+		JOIN documents d ON a.document_id = d.id AND a.user_id = d.user_id
+		WHERE a.user_id = $1 AND d.id = $2
+		ORDER BY a.analysis_version, a.created_at, a.id`, userID, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	versions := []models.AnalysisVersion{}
+	for rows.Next() {
+		var v models.AnalysisVersion
+		var keywords, summaryPoints []string
+		var rawMeta []byte
+		if err := rows.Scan(&v.Version, &v.AnalysisID, &v.CreatedAt, &v.Backend, &v.Summary, &v.Sentiment, pq.Array(&keywords), pq.Array(&summaryPoints), &rawMeta, &v.Uses); err != nil {
+			return nil, err
+		}
+		v.Keywords = keywords
+		v.SummaryPoints = summaryPoints
+		v.Meta = parseMeta(rawMeta)
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		var exists bool
+		if err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM documents WHERE id=$1 AND user_id=$2)`, documentID, userID).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, sql.ErrNoRows
+		}
+	}
+	return versions, nil
 }
 
 func (r *analysisRepository) FindDocument(userID string, collectionID *int, contentHash string) (int, error) {
@@ -67,7 +158,7 @@ func (r *analysisRepository) FindDocument(userID string, collectionID *int, cont
 }
 
 func (r *analysisRepository) GetLatestAnalysisByDocument(userID string, documentID int) (*models.AnalysisDetail, error) {
-	q := `SELECT a.id, d.id, d.file_name, a.summary, a.sentiment, COALESCE(a.keywords, '{}'::text[]), d.collection_id, a.created_at, COALESCE(d.full_text,'') as full_text, a.analysis_version, a.batch_id, a.batch_size, COALESCE(a.summary_points, '{}'::text[])
+	q := `SELECT a.id, d.id, d.file_name, a.summary, a.sentiment, COALESCE(a.keywords, '{}'::text[]), d.collection_id, a.created_at, COALESCE(d.full_text,'') as full_text, a.analysis_version, a.batch_id, a.batch_size, COALESCE(a.summary_points, '{}'::text[]), a.backend, a.meta
 		FROM analyses a
 		JOIN documents d ON a.document_id = d.id AND a.user_id = d.user_id
 		WHERE a.user_id = $1 AND d.id = $2
@@ -75,12 +166,14 @@ func (r *analysisRepository) GetLatestAnalysisByDocument(userID string, document
 	var detail models.AnalysisDetail
 	var colID sql.NullInt64
 	var keywords, summaryPoints []string
-	if err := r.db.QueryRow(q, userID, documentID).Scan(&detail.AnalysisID, &detail.DocumentID, &detail.FileName, &detail.Summary, &detail.Sentiment, pq.Array(&keywords), &colID, &detail.CreatedAt, &detail.FullText, &detail.AnalysisVersion, &detail.BatchID, &detail.BatchSize, pq.Array(&summaryPoints)); err != nil {
+	var rawMeta []byte
+	if err := r.db.QueryRow(q, userID, documentID).Scan(&detail.AnalysisID, &detail.DocumentID, &detail.FileName, &detail.Summary, &detail.Sentiment, pq.Array(&keywords), &colID, &detail.CreatedAt, &detail.FullText, &detail.AnalysisVersion, &detail.BatchID, &detail.BatchSize, pq.Array(&summaryPoints), &detail.Backend, &rawMeta); err != nil {
 		log.Printf("GetLatestAnalysisByDocument error user=%s doc=%d: %v", userID, documentID, err)
 		return nil, err
 	}
 	detail.Keywords = keywords
 	detail.SummaryPoints = summaryPoints
+	detail.Meta = parseMeta(rawMeta)
 	if colID.Valid {
 		v := int(colID.Int64)
 		detail.CollectionID = &v
@@ -158,14 +251,47 @@ func (r *analysisRepository) ListAllDocuments(userID string, limit, offset int)
 	return out, total, nil
 }
 
+// UpdateDocumentCollection assigns an uncategorized document to a collection:
+// ErrDocumentAlreadyAssigned if it is in one already, sql.ErrNoRows if it is not the user's and
+// ErrDocumentDuplicate if the collection holds the same content.
 func (r *analysisRepository) UpdateDocumentCollection(userID string, documentID int, collectionID int) error {
 	res, err := r.db.Exec(`UPDATE documents SET collection_id=$1 WHERE id=$2 AND user_id=$3 AND collection_id IS NULL`, collectionID, documentID, userID)
 	if err != nil {
-		return err
+		return documentWriteError(err)
 	}
 	affected, err := res.RowsAffected()
-	if err == nil && affected == 0 {
-		return errors.New("already_assigned")
+	if err != nil || affected > 0 {
+		return err
+	}
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM documents WHERE id=$1 AND user_id=$2)`, documentID, userID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	return ErrDocumentAlreadyAssigned
+}
+
+// metaJSON encodes analysis metadata for the JSONB column. A nil meta is returned as an untyped
+// nil so it is stored as NULL: lib/pq sends a nil []byte as an empty value, which JSONB rejects.
+func metaJSON(meta *models.AnalysisMeta) (any, error) {
+	if meta == nil {
+		return nil, nil
+	}
+	return json.Marshal(meta)
+}
+
+// parseMeta decodes the JSONB column. Unreadable metadata is logged and dropped: the analysis
+// itself is still valid, it is just treated as coming from an unknown model.
+func parseMeta(raw []byte) *models.AnalysisMeta {
+	if len(raw) == 0 {
+		return nil
+	}
+	var meta models.AnalysisMeta
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		log.Printf("parse analysis meta error: %v", err)
+		return nil
 	}
-	return err
+	return &meta
 }
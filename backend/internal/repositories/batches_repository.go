@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+// BatchesRepository reads multi-file uploads back from the batch_id of their analyses and the
+// failures recorded for them. Successfully retried failures are resolved and no longer count.
+type BatchesRepository interface {
+	RecordFailure(f models.AnalysisFailure) error
+	ListBatches(userID string, limit, offset int) ([]models.BatchItem, int, error)
+	GetBatch(userID, batchID string) (*models.BatchDetail, error)
+	ClaimRetryableFailures(userID, batchID string, lease time.Duration) ([]models.AnalysisFailure, error)
+	ResolveFailure(id int) error
+	ReleaseFailure(id int, errorKey string, contentStored bool) error
+	UnreferencedHashes(hashes []string) ([]string, error)
+}
+
+type batchesRepository struct{ db *sql.DB }
+
+func NewBatchesRepository() BatchesRepository { return &batchesRepository{db: database.DB} }
+
+func (r *batchesRepository) RecordFailure(f models.AnalysisFailure) error {
+	_, err := r.db.Exec(`INSERT INTO analysis_failures(user_id, batch_id, batch_size, collection_id, file_name, content_hash, error_key, backend, force, content_stored, correlation_id)
+		VALUES($1,$2,$3,$4,$5,NULLIF($6,''),$7,$8,$9,$10,NULLIF($11,''))`,
+		f.UserID, f.BatchID, f.BatchSize, f.CollectionID, f.FileName, f.ContentHash, f.ErrorKey, f.Backend, f.Force, f.ContentStored, f.CorrelationID)
+	return err
+}
+
+// batchRowsCTE lists one row per analysis or unresolved failure of the user's batches.
+const batchRowsCTE = `WITH batch_rows AS (
+		SELECT batch_id, batch_size, created_at, 1 AS succeeded, 0 AS failed FROM analyses WHERE user_id=$1 AND batch_id IS NOT NULL
+		UNION ALL
+		SELECT batch_id, batch_size, created_at, 0, 1 FROM analysis_failures WHERE user_id=$1 AND resolved_at IS NULL
+	)`
+
+// ListBatches returns the user's batches, most recent first.
+func (r *batchesRepository) ListBatches(userID string, limit, offset int) ([]models.BatchItem, int, error) {
+	var total int
+	if err := r.db.QueryRow(batchRowsCTE+` SELECT COUNT(DISTINCT batch_id) FROM batch_rows`, userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.Query(batchRowsCTE+` SELECT batch_id, MAX(batch_size), SUM(succeeded), SUM(failed), MIN(created_at), MAX(created_at)
+		FROM batch_rows
+		GROUP BY batch_id
+		ORDER BY MIN(created_at) DESC, batch_id
+		LIMIT $2 OFFSET $3`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	items := []models.BatchItem{}
+	for rows.Next() {
+		var it models.BatchItem
+		if err := rows.Scan(&it.BatchID, &it.FileCount, &it.Succeeded, &it.Failed, &it.StartedAt, &it.FinishedAt); err != nil {
+			return nil, 0, err
+		}
+		items = append(items, it)
+	}
+	return items, total, rows.Err()
+}
+
+// GetBatch returns the batch with its analyses and failures in the order they were stored;
+// sql.ErrNoRows when the user has no rows for it.
+func (r *batchesRepository) GetBatch(userID, batchID string) (*models.BatchDetail, error) {
+	detail := models.BatchDetail{Analyses: []models.BatchAnalysis{}, Failures: []models.BatchFailure{}}
+	err := r.db.QueryRow(batchRowsCTE+` SELECT batch_id, MAX(batch_size), SUM(succeeded), SUM(failed), MIN(created_at), MAX(created_at)
+		FROM batch_rows WHERE batch_id=$2 GROUP BY batch_id`, userID, batchID).
+		Scan(&detail.BatchID, &detail.FileCount, &detail.Succeeded, &detail.Failed, &detail.StartedAt, &detail.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`SELECT a.id, d.id, d.file_name, a.analysis_version, a.backend, COALESCE(a.summary,''), COALESCE(a.summary_points, '{}'::text[]),
+			COALESCE(a.sentiment,''), COALESCE(a.keywords, '{}'::text[]), a.created_at
+		FROM analyses a
+		JOIN documents d ON a.document_id = d.id AND a.user_id = d.user_id
+		WHERE a.user_id=$1 AND a.batch_id=$2
+		ORDER BY a.created_at, a.id`, userID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a models.BatchAnalysis
+		if err := rows.Scan(&a.AnalysisID, &a.DocumentID, &a.FileName, &a.AnalysisVersion, &a.Backend, &a.Summary, pq.Array(&a.SummaryPoints),
+			&a.Sentiment, pq.Array(&a.Keywords), &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		detail.Analyses = append(detail.Analyses, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	failures, err := r.db.Query(`SELECT id, file_name, error_key, content_stored, created_at FROM analysis_failures
+		WHERE user_id=$1 AND batch_id=$2 AND resolved_at IS NULL ORDER BY created_at, id`, userID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer failures.Close()
+	for failures.Next() {
+		var f models.BatchFailure
+		if err := failures.Scan(&f.ID, &f.FileName, &f.ErrorKey, &f.Retryable, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		detail.Failures = append(detail.Failures, f)
+	}
+	return &detail, failures.Err()
+}
+
+// ClaimRetryableFailures claims the batch's pending failures whose upload was kept and returns
+// them, so concurrent retries of one batch never analyze a file twice. A claim older than lease
+// was left by a crashed retry and is taken over. Every claimed failure must be resolved or
+// released once its retry is over.
+func (r *batchesRepository) ClaimRetryableFailures(userID, batchID string, lease time.Duration) ([]models.AnalysisFailure, error) {
+	rows, err := r.db.Query(`UPDATE analysis_failures SET retry_claimed_at=now()
+		WHERE user_id=$1 AND batch_id=$2 AND content_stored AND resolved_at IS NULL
+			AND (retry_claimed_at IS NULL OR retry_claimed_at < now() - make_interval(secs => $3))
+		RETURNING id, batch_size, collection_id, file_name, COALESCE(content_hash,''), error_key, backend, force`, userID, batchID, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var claimed []models.AnalysisFailure
+	for rows.Next() {
+		f := models.AnalysisFailure{UserID: userID, BatchID: batchID, ContentStored: true}
+		var colID sql.NullInt64
+		if err := rows.Scan(&f.ID, &f.BatchSize, &colID, &f.FileName, &f.ContentHash, &f.ErrorKey, &f.Backend, &f.Force); err != nil {
+			return nil, err
+		}
+		if colID.Valid {
+			v := int(colID.Int64)
+			f.CollectionID = &v
+		}
+		claimed = append(claimed, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(claimed, func(a, b models.AnalysisFailure) int { return a.ID - b.ID })
+	return claimed, nil
+}
+
+// ResolveFailure marks a claimed failure as successfully retried.
+func (r *batchesRepository) ResolveFailure(id int) error {
+	_, err := r.db.Exec(`UPDATE analysis_failures SET resolved_at=now(), retry_claimed_at=NULL WHERE id=$1`, id)
+	return err
+}
+
+// ReleaseFailure ends the claim of a failure whose retry failed again, recording the new error and
+// whether its upload is still kept for another retry.
+func (r *batchesRepository) ReleaseFailure(id int, errorKey string, contentStored bool) error {
+	_, err := r.db.Exec(`UPDATE analysis_failures SET error_key=$2, content_stored=$3, retry_claimed_at=NULL WHERE id=$1`, id, errorKey, contentStored)
+	return err
+}
+
+// UnreferencedHashes returns the hashes whose blobs neither a document nor a pending retry needs.
+func (r *batchesRepository) UnreferencedHashes(hashes []string) ([]string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	orphaned, err := unreferencedHashes(context.Background(), tx, hashes)
+	if err != nil {
+		return nil, err
+	}
+	return orphaned, tx.Commit()
+}
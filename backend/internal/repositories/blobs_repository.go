@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"io"
+
+	"github.com/samusafe/genericapi/internal/blobstore"
+	"github.com/samusafe/genericapi/internal/database"
+)
+
+// guardedBlobStore serializes writes and deletions of a content hash (across processes) with a
+// per-hash advisory lock, and only deletes a blob that nothing references once the lock is held.
+// Writers record the document or failure row referencing a hash before storing its blob, so a
+// deletion either sees that row or runs entirely before the blob is written again.
+type guardedBlobStore struct {
+	blobstore.BlobStore
+	db *sql.DB
+}
+
+// NewGuardedBlobStore wraps store so that concurrent uploads and deletions of shared blobs cannot
+// lose an upload. Delete leaves blobs that are referenced again alone.
+func NewGuardedBlobStore(store blobstore.BlobStore) blobstore.BlobStore {
+	return &guardedBlobStore{BlobStore: store, db: database.DB}
+}
+
+func (g *guardedBlobStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	return g.withLock(ctx, key, func(tx *sql.Tx) error {
+		return g.BlobStore.Put(ctx, key, r, size)
+	})
+}
+
+func (g *guardedBlobStore) Delete(ctx context.Context, key string) error {
+	return g.withLock(ctx, key, func(tx *sql.Tx) error {
+		orphaned, err := unreferencedHashes(ctx, tx, []string{key})
+		if err != nil || len(orphaned) == 0 {
+			return err
+		}
+		return g.BlobStore.Delete(ctx, key)
+	})
+}
+
+// withLock runs fn while holding the hash's advisory lock; the lock ends with the transaction.
+func (g *guardedBlobStore) withLock(ctx context.Context, key string, fn func(tx *sql.Tx) error) error {
+	tx, err := g.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('blob:' || $1))`, key); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
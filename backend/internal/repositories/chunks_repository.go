@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+type ChunksRepository interface {
+	ReplaceChunks(userID string, documentID int, chunks []models.DocumentChunk) error
+	ListUnchunkedDocuments(userID string, collectionID int) ([]models.DocumentText, error)
+	SearchChunks(userID string, collectionID int, question, lang string, limit int) ([]models.DocumentChunk, error)
+}
+
+type chunksRepository struct{ db *sql.DB }
+
+func NewChunksRepository() ChunksRepository { return &chunksRepository{db: database.DB} }
+
+// ReplaceChunks swaps the document's passages atomically (re-analysis re-chunks).
+func (r *chunksRepository) ReplaceChunks(userID string, documentID int, chunks []models.DocumentChunk) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM document_chunks WHERE document_id=$1 AND user_id=$2`, documentID, userID); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO document_chunks(document_id, user_id, chunk_index, start_offset, end_offset, content) VALUES($1,$2,$3,$4,$5,$6)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, ch := range chunks {
+		if _, err := stmt.Exec(documentID, userID, ch.Index, ch.Start, ch.End, ch.Content); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListUnchunkedDocuments returns the collection's documents that have text but no passages yet.
+func (r *chunksRepository) ListUnchunkedDocuments(userID string, collectionID int) ([]models.DocumentText, error) {
+	rows, err := r.db.Query(`SELECT d.id, d.full_text FROM documents d
+		WHERE d.user_id=$1 AND d.collection_id=$2 AND COALESCE(d.full_text,'') <> ''
+		AND NOT EXISTS (SELECT 1 FROM document_chunks c WHERE c.document_id = d.id)`, userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.DocumentText
+	for rows.Next() {
+		var d models.DocumentText
+		if err := rows.Scan(&d.ID, &d.FullText); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// SearchChunks ranks the collection's passages against a natural-language question. Question
+// terms are OR-ed (plainto_tsquery alone would require every word) and ranked by cover density.
+func (r *chunksRepository) SearchChunks(userID string, collectionID int, question, lang string, limit int) ([]models.DocumentChunk, error) {
+	cfg := searchConfigFor(lang)
+	query := fmt.Sprintf(`WITH query AS (SELECT replace(plainto_tsquery('%[1]s', $3)::text, '&', '|')::tsquery AS tsq)
+		SELECT c.id, c.document_id, c.chunk_index, d.file_name, c.start_offset, c.end_offset, c.content
+		FROM document_chunks c
+		JOIN documents d ON d.id = c.document_id AND d.user_id = c.user_id
+		CROSS JOIN query
+		WHERE c.user_id = $1 AND d.collection_id = $2 AND c.%[2]s @@ query.tsq
+		ORDER BY ts_rank_cd(c.%[2]s, query.tsq) DESC, c.id
+		LIMIT $4`, cfg.regconfig, cfg.column)
+	rows, err := r.db.Query(query, userID, collectionID, question, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.DocumentChunk
+	for rows.Next() {
+		var ch models.DocumentChunk
+		if err := rows.Scan(&ch.ID, &ch.DocumentID, &ch.Index, &ch.FileName, &ch.Start, &ch.End, &ch.Content); err != nil {
+			return nil, err
+		}
+		out = append(out, ch)
+	}
+	return out, rows.Err()
+}
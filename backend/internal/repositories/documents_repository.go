@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+var (
+	// ErrDocumentDuplicate means the target collection (or uncategorized) already holds a
+	// document with the same content (partial unique indexes on content_hash).
+	ErrDocumentDuplicate = errors.New("duplicate document in the target collection")
+	// ErrDocumentAlreadyAssigned means an uncategorized-only assignment found the document in a collection.
+	ErrDocumentAlreadyAssigned = errors.New("document already in a collection")
+	// ErrCollectionNotFound means the target collection does not exist or belongs to someone else.
+	ErrCollectionNotFound = errors.New("collection not found")
+)
+
+type DocumentsRepository interface {
+	GetDocumentFile(userID string, documentID int) (*models.DocumentFile, error)
+	UpdateDocument(userID string, documentID int, patch models.DocumentPatch) (*models.DocumentItem, error)
+	MoveDocuments(userID string, documentIDs []int, collectionID *int) error
+	DeleteDocuments(userID string, documentIDs []int) ([]string, error)
+}
+
+type documentsRepository struct{ db *sql.DB }
+
+func NewDocumentsRepository() DocumentsRepository { return &documentsRepository{db: database.DB} }
+
+// GetDocumentFile returns what is needed to serve the original upload; sql.ErrNoRows if the
+// document does not exist or belongs to someone else.
+func (r *documentsRepository) GetDocumentFile(userID string, documentID int) (*models.DocumentFile, error) {
+	var f models.DocumentFile
+	err := r.db.QueryRow(`SELECT id, file_name, COALESCE(content_hash,''), COALESCE(detected_type,'') FROM documents WHERE id=$1 AND user_id=$2`, documentID, userID).
+		Scan(&f.ID, &f.FileName, &f.ContentHash, &f.DetectedType)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// UpdateDocument renames and/or moves one document and returns it as listed by /documents.
+// sql.ErrNoRows if the document is not the user's.
+func (r *documentsRepository) UpdateDocument(userID string, documentID int, patch models.DocumentPatch) (*models.DocumentItem, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if patch.Move {
+		if err := lockCollection(tx, userID, patch.CollectionID); err != nil {
+			return nil, err
+		}
+	}
+	res, err := tx.Exec(`UPDATE documents SET file_name = COALESCE($3, file_name),
+			collection_id = CASE WHEN $4 THEN $5::int ELSE collection_id END
+		WHERE id=$1 AND user_id=$2`, documentID, userID, patch.FileName, patch.Move, patch.CollectionID)
+	if err != nil {
+		return nil, documentWriteError(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	var it models.DocumentItem
+	var colID sql.NullInt64
+	err = tx.QueryRow(`SELECT d.id, d.file_name, COALESCE(COUNT(a.id),0), COALESCE(MAX(a.created_at)::text,''), d.collection_id
+		FROM documents d
+		LEFT JOIN analyses a ON a.document_id = d.id AND a.user_id = d.user_id
+		WHERE d.id=$1 AND d.user_id=$2
+		GROUP BY d.id`, documentID, userID).Scan(&it.ID, &it.FileName, &it.AnalysesCount, &it.LastAnalysisAt, &colID)
+	if err != nil {
+		return nil, err
+	}
+	if colID.Valid {
+		v := int(colID.Int64)
+		it.CollectionID = &v
+	}
+	return &it, tx.Commit()
+}
+
+// MoveDocuments moves all the documents to the collection (nil: uncategorized), or none of them:
+// sql.ErrNoRows if one is not the user's, ErrDocumentDuplicate if one clashes with the target.
+func (r *documentsRepository) MoveDocuments(userID string, documentIDs []int, collectionID *int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := lockCollection(tx, userID, collectionID); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`UPDATE documents SET collection_id=$1 WHERE user_id=$2 AND id = ANY($3)`, collectionID, userID, pq.Array(documentIDs))
+	if err != nil {
+		return documentWriteError(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if int(n) != len(documentIDs) {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+// DeleteDocuments deletes all the documents with their analyses, quizzes, embeddings and
+// passages (cascade), or none of them (sql.ErrNoRows if one is not the user's). It returns the
+// content hashes no remaining document references: their original uploads can be deleted.
+func (r *documentsRepository) DeleteDocuments(userID string, documentIDs []int) ([]string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.Query(`DELETE FROM documents WHERE user_id=$1 AND id = ANY($2) RETURNING COALESCE(content_hash,'')`, userID, pq.Array(documentIDs))
+	if err != nil {
+		return nil, err
+	}
+	var hashes []string
+	deleted := 0
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		deleted++
+		if h != "" {
+			hashes = append(hashes, h)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if deleted != len(documentIDs) {
+		return nil, sql.ErrNoRows
+	}
+	orphaned, err := unreferencedHashes(context.Background(), tx, hashes)
+	if err != nil {
+		return nil, err
+	}
+	return orphaned, tx.Commit()
+}
+
+// lockCollection checks the target collection is the user's and keeps it from being deleted
+// until the transaction ends. nil (uncategorized) always passes.
+func lockCollection(tx *sql.Tx, userID string, collectionID *int) error {
+	if collectionID == nil {
+		return nil
+	}
+	var id int
+	err := tx.QueryRow(`SELECT id FROM collections WHERE id=$1 AND user_id=$2 FOR SHARE`, *collectionID, userID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCollectionNotFound
+	}
+	return err
+}
+
+// documentWriteError maps the content_hash unique indexes to ErrDocumentDuplicate.
+func documentWriteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDocumentDuplicate
+	}
+	return err
+}
+
+// unreferencedHashes returns the distinct hashes no document (of any user) references any more
+// and no pending retry of a failed file needs. Blobs are shared by content hash, so only these may
+// be deleted from the blob store.
+func unreferencedHashes(ctx context.Context, tx *sql.Tx, hashes []string) ([]string, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT h FROM unnest($1::text[]) AS h
+		WHERE NOT EXISTS (SELECT 1 FROM documents d WHERE d.content_hash = h)
+		AND NOT EXISTS (SELECT 1 FROM analysis_failures f WHERE f.content_hash = h AND f.content_stored AND f.resolved_at IS NULL)`, pq.Array(hashes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+type EmbeddingsRepository interface {
+	UpsertEmbedding(userID string, documentID int, model string, vector []float32) error
+	GetEmbedding(userID string, documentID int) (*models.DocumentEmbedding, error)
+	ListEmbeddings(userID string, model string, excludeDocumentID int) ([]models.DocumentEmbedding, error)
+}
+
+type embeddingsRepository struct{ db *sql.DB }
+
+func NewEmbeddingsRepository() EmbeddingsRepository { return &embeddingsRepository{db: database.DB} }
+
+// UpsertEmbedding stores or replaces the document's vector (re-analysis refreshes it).
+func (r *embeddingsRepository) UpsertEmbedding(userID string, documentID int, model string, vector []float32) error {
+	_, err := r.db.Exec(`INSERT INTO document_embeddings(document_id, user_id, model, embedding) VALUES($1,$2,$3,$4)
+		ON CONFLICT (document_id) DO UPDATE SET model = EXCLUDED.model, embedding = EXCLUDED.embedding, updated_at = now()`,
+		documentID, userID, model, pq.Array(vector))
+	return err
+}
+
+// GetEmbedding returns sql.ErrNoRows when the document has no embedding or is not the user's.
+func (r *embeddingsRepository) GetEmbedding(userID string, documentID int) (*models.DocumentEmbedding, error) {
+	var e models.DocumentEmbedding
+	var colID sql.NullInt64
+	err := r.db.QueryRow(`SELECT d.id, d.file_name, d.collection_id, e.model, e.embedding
+		FROM document_embeddings e JOIN documents d ON d.id = e.document_id AND d.user_id = e.user_id
+		WHERE e.user_id=$1 AND e.document_id=$2`, userID, documentID).
+		Scan(&e.DocumentID, &e.FileName, &colID, &e.Model, pq.Array(&e.Vector))
+	if err != nil {
+		return nil, err
+	}
+	if colID.Valid {
+		v := int(colID.Int64)
+		e.CollectionID = &v
+	}
+	return &e, nil
+}
+
+// ListEmbeddings returns every other embedded document of the user computed with model.
+func (r *embeddingsRepository) ListEmbeddings(userID string, model string, excludeDocumentID int) ([]models.DocumentEmbedding, error) {
+	rows, err := r.db.Query(`SELECT d.id, d.file_name, d.collection_id, e.model, e.embedding
+		FROM document_embeddings e JOIN documents d ON d.id = e.document_id AND d.user_id = e.user_id
+		WHERE e.user_id=$1 AND e.model=$2 AND e.document_id <> $3`, userID, model, excludeDocumentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.DocumentEmbedding
+	for rows.Next() {
+		var e models.DocumentEmbedding
+		var colID sql.NullInt64
+		if err := rows.Scan(&e.DocumentID, &e.FileName, &colID, &e.Model, pq.Array(&e.Vector)); err != nil {
+			return nil, err
+		}
+		if colID.Valid {
+			v := int(colID.Int64)
+			e.CollectionID = &v
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+// InsightsRepository reads the latest analysis of each document of a collection and caches the
+// insights computed from them. Callers check the collection belongs to the user.
+type InsightsRepository interface {
+	Fingerprint(userID string, collectionID int) (string, error)
+	LatestAnalyses(userID string, collectionID int) ([]models.InsightsDocument, error)
+	// GetCached returns sql.ErrNoRows when nothing was cached for the collection.
+	GetCached(userID string, collectionID int) (*models.CachedInsights, error)
+	SaveCached(userID string, collectionID int, cached models.CachedInsights) error
+}
+
+type insightsRepository struct{ db *sql.DB }
+
+func NewInsightsRepository() InsightsRepository { return &insightsRepository{db: database.DB} }
+
+// latestAnalysisJoin pairs each document of the collection ($1 user, $2 collection) with its
+// latest analysis; documents without one are left out.
+const latestAnalysisJoin = `FROM documents d
+	JOIN LATERAL (
+		SELECT id, summary, keywords, sentiment FROM analyses
+		WHERE document_id = d.id AND user_id = d.user_id
+		ORDER BY created_at DESC, id DESC LIMIT 1
+	) a ON true
+	WHERE d.user_id = $1 AND d.collection_id = $2`
+
+// Fingerprint identifies the collection's documents and their latest analysis; it changes when a
+// document is added, moved, deleted or analyzed again. Empty for a collection without analyses.
+func (r *insightsRepository) Fingerprint(userID string, collectionID int) (string, error) {
+	var fp string
+	err := r.db.QueryRow(`SELECT COALESCE(md5(string_agg(d.id || ':' || a.id, ',' ORDER BY d.id)), '') `+latestAnalysisJoin, userID, collectionID).Scan(&fp)
+	return fp, err
+}
+
+func (r *insightsRepository) LatestAnalyses(userID string, collectionID int) ([]models.InsightsDocument, error) {
+	rows, err := r.db.Query(`SELECT d.id, COALESCE(a.summary,''), COALESCE(a.keywords, '{}'::text[]), COALESCE(a.sentiment,''), d.created_at `+
+		latestAnalysisJoin+` ORDER BY d.created_at, d.id`, userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var docs []models.InsightsDocument
+	for rows.Next() {
+		var d models.InsightsDocument
+		if err := rows.Scan(&d.DocumentID, &d.Summary, pq.Array(&d.Keywords), &d.Sentiment, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+func (r *insightsRepository) GetCached(userID string, collectionID int) (*models.CachedInsights, error) {
+	var cached models.CachedInsights
+	var raw []byte
+	var summary sql.NullString
+	err := r.db.QueryRow(`SELECT fingerprint, insights, summary FROM collection_insights WHERE collection_id=$1 AND user_id=$2`, collectionID, userID).
+		Scan(&cached.Fingerprint, &raw, &summary)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &cached.Insights); err != nil {
+		return nil, err
+	}
+	if summary.Valid {
+		cached.Summary = &summary.String
+	}
+	return &cached, nil
+}
+
+// SaveCached replaces the collection's cached insights. The collection must be the user's.
+func (r *insightsRepository) SaveCached(userID string, collectionID int, cached models.CachedInsights) error {
+	raw, err := json.Marshal(cached.Insights)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO collection_insights(collection_id, user_id, fingerprint, insights, summary, computed_at)
+		VALUES($1,$2,$3,$4,$5,$6)
+		ON CONFLICT (collection_id) DO UPDATE SET fingerprint=EXCLUDED.fingerprint, insights=EXCLUDED.insights,
+			summary=EXCLUDED.summary, computed_at=EXCLUDED.computed_at`,
+		collectionID, userID, cached.Fingerprint, raw, cached.Summary, cached.Insights.ComputedAt)
+	return err
+}
@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+// ErrClaimLost means the job file is no longer held by the caller's attempt: its lease expired and
+// the file was requeued (and possibly claimed by another worker).
+var ErrClaimLost = errors.New("job file claim lost")
+
+type JobsRepository interface {
+	CreateJob(jobID, userID, lang, correlationID string, collectionID *int, backend string, force bool, uploads []models.JobUpload) error
+	GetJob(userID, jobID string) (*models.AnalysisJob, error)
+	ClaimNextFile() (*models.JobFileTask, error)
+	RenewFile(fileID, attempt int) error
+	CompleteFile(fileID, attempt int, status string, result models.AnalysisResult) error
+	ReleaseFile(fileID, attempt int) error
+	RequeueStale(olderThan time.Duration) (int64, error)
+}
+
+type jobsRepository struct{ db *sql.DB }
+
+func NewJobsRepository() JobsRepository { return &jobsRepository{db: database.DB} }
+
+// CreateJob stores the job and all of its uploads in a single transaction.
+func (r *jobsRepository) CreateJob(jobID, userID, lang, correlationID string, collectionID *int, backend string, force bool, uploads []models.JobUpload) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO analysis_jobs(id, user_id, lang, collection_id, correlation_id, file_count, backend, force) VALUES($1,$2,$3,$4,$5,$6,$7,$8)`, jobID, userID, lang, collectionID, correlationID, len(uploads), backend, force); err != nil {
+		return err
+	}
+	for i, u := range uploads {
+		if u.Rejected != nil {
+			raw, err := json.Marshal(u.Rejected)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`INSERT INTO analysis_job_files(job_id, position, file_name, status, result) VALUES($1,$2,$3,$4,$5)`, jobID, i, u.FileName, models.JobStatusFailed, raw); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO analysis_job_files(job_id, position, file_name, content) VALUES($1,$2,$3,$4)`, jobID, i, u.FileName, u.Content); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *jobsRepository) GetJob(userID, jobID string) (*models.AnalysisJob, error) {
+	job := models.AnalysisJob{ID: jobID}
+	var colID sql.NullInt64
+	if err := r.db.QueryRow(`SELECT collection_id, created_at FROM analysis_jobs WHERE id=$1 AND user_id=$2`, jobID, userID).Scan(&colID, &job.CreatedAt); err != nil {
+		return nil, err
+	}
+	if colID.Valid {
+		v := int(colID.Int64)
+		job.CollectionID = &v
+	}
+
+	rows, err := r.db.Query(`SELECT file_name, status, result, updated_at FROM analysis_job_files WHERE job_id=$1 ORDER BY position`, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	running := 0
+	for rows.Next() {
+		var f models.AnalysisJobFile
+		var raw []byte
+		if err := rows.Scan(&f.FileName, &f.Status, &raw, &f.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if len(raw) > 0 {
+			var res models.AnalysisResult
+			if err := json.Unmarshal(raw, &res); err != nil {
+				return nil, err
+			}
+			f.Result = &res
+		}
+		switch f.Status {
+		case models.JobStatusDone:
+			job.Completed++
+		case models.JobStatusFailed:
+			job.Failed++
+		case models.JobStatusRunning:
+			running++
+		}
+		job.Files = append(job.Files, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	job.Total = len(job.Files)
+	switch {
+	case job.Completed+job.Failed == job.Total:
+		job.Status = models.JobStatusCompleted
+	case job.Completed+job.Failed+running > 0:
+		job.Status = models.JobStatusRunning
+	default:
+		job.Status = models.JobStatusQueued
+	}
+	return &job, nil
+}
+
+// ClaimNextFile marks the oldest queued file as running under a new attempt and returns it. SKIP
+// LOCKED keeps several API replicas from claiming the same file. Returns sql.ErrNoRows when the
+// queue is empty.
+func (r *jobsRepository) ClaimNextFile() (*models.JobFileTask, error) {
+	q := `WITH next AS (
+			SELECT id FROM analysis_job_files
+			WHERE status = 'queued'
+			ORDER BY id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		UPDATE analysis_job_files f SET status = 'running', attempt = f.attempt + 1, updated_at = now()
+		FROM next, analysis_jobs j
+		WHERE f.id = next.id AND j.id = f.job_id
+		RETURNING f.id, j.id, j.user_id, j.lang, COALESCE(j.correlation_id, ''), j.collection_id, j.backend, j.force, j.file_count, f.file_name, f.content, f.attempt`
+	var t models.JobFileTask
+	var colID sql.NullInt64
+	if err := r.db.QueryRow(q).Scan(&t.FileID, &t.JobID, &t.UserID, &t.Lang, &t.CorrelationID, &colID, &t.Backend, &t.Force, &t.FileCount, &t.FileName, &t.Content, &t.Attempt); err != nil {
+		return nil, err
+	}
+	if colID.Valid {
+		v := int(colID.Int64)
+		t.CollectionID = &v
+	}
+	return &t, nil
+}
+
+// RenewFile extends the lease of a file while its attempt is still being analyzed.
+func (r *jobsRepository) RenewFile(fileID, attempt int) error {
+	return claimed(r.db.Exec(`UPDATE analysis_job_files SET updated_at=now() WHERE id=$1 AND attempt=$2 AND status='running'`, fileID, attempt))
+}
+
+// CompleteFile stores the final result and drops the raw upload bytes.
+func (r *jobsRepository) CompleteFile(fileID, attempt int, status string, result models.AnalysisResult) error {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return claimed(r.db.Exec(`UPDATE analysis_job_files SET status=$1, result=$2, content=NULL, updated_at=now()
+		WHERE id=$3 AND attempt=$4 AND status='running'`, status, raw, fileID, attempt))
+}
+
+// ReleaseFile puts a claimed file back in the queue (used on shutdown).
+func (r *jobsRepository) ReleaseFile(fileID, attempt int) error {
+	return claimed(r.db.Exec(`UPDATE analysis_job_files SET status='queued', updated_at=now() WHERE id=$1 AND attempt=$2 AND status='running'`, fileID, attempt))
+}
+
+// claimed turns an update of a claimed file that matched no row into ErrClaimLost.
+func claimed(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrClaimLost
+	}
+	return nil
+}
+
+// RequeueStale recovers files left running by a replica that died mid-analysis.
+func (r *jobsRepository) RequeueStale(olderThan time.Duration) (int64, error) {
+	res, err := r.db.Exec(`UPDATE analysis_job_files SET status='queued', updated_at=now() WHERE status='running' AND updated_at < now() - make_interval(secs => $1)`, olderThan.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+type QuarantineRepository interface {
+	RecordEvent(ev models.QuarantineEvent) error
+}
+
+type quarantineRepository struct{ db *sql.DB }
+
+func NewQuarantineRepository() QuarantineRepository { return &quarantineRepository{db: database.DB} }
+
+func (r *quarantineRepository) RecordEvent(ev models.QuarantineEvent) error {
+	_, err := r.db.Exec(`INSERT INTO quarantine_events(user_id, file_name, content_hash, scanner, verdict, signature, correlation_id) VALUES($1,$2,$3,$4,$5,NULLIF($6,''),NULLIF($7,''))`,
+		ev.UserID, ev.FileName, ev.ContentHash, ev.Scanner, ev.Verdict, ev.Signature, ev.CorrelationID)
+	return err
+}
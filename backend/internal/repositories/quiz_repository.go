@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+type QuizRepository interface {
+	GetAnalysisText(userID string, analysisID int) (string, error)
+	SaveQuiz(userID string, analysisID int, opts models.QuizOptions, questions []models.QuizQuestion) (int, error)
+	GetQuiz(userID string, analysisID int, version int) (*models.StoredQuiz, error)
+}
+
+type quizRepository struct{ db *sql.DB }
+
+func NewQuizRepository() QuizRepository { return &quizRepository{db: database.DB} }
+
+// GetAnalysisText returns the full text of the analysed document; sql.ErrNoRows if the analysis
+// does not exist or belongs to someone else.
+func (r *quizRepository) GetAnalysisText(userID string, analysisID int) (string, error) {
+	var text string
+	err := r.db.QueryRow(`SELECT COALESCE(d.full_text,'') FROM analyses a JOIN documents d ON d.id = a.document_id AND d.user_id = a.user_id
+		WHERE a.id=$1 AND a.user_id=$2`, analysisID, userID).Scan(&text)
+	return text, err
+}
+
+// SaveQuiz stores the questions as the next quiz version of the analysis and returns it. The
+// analysis row is locked so concurrent regenerations get distinct versions.
+func (r *quizRepository) SaveQuiz(userID string, analysisID int, opts models.QuizOptions, questions []models.QuizQuestion) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var id int
+	if err := tx.QueryRow(`SELECT id FROM analyses WHERE id=$1 AND user_id=$2 FOR UPDATE`, analysisID, userID).Scan(&id); err != nil {
+		return 0, err
+	}
+	var version int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(quiz_version),0)+1 FROM quiz_questions WHERE analysis_id=$1`, analysisID).Scan(&version); err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO quiz_questions(user_id, analysis_id, question, answer, quiz_version, position,
+		question_type, options, correct_index, difficulty, requested_count) VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for i, q := range questions {
+		var options interface{}
+		if q.Options != nil {
+			options = pq.Array(q.Options)
+		}
+		if _, err := stmt.Exec(userID, analysisID, q.Question, q.Answer, version, i,
+			q.Type, options, q.CorrectIndex, opts.Difficulty, opts.Count); err != nil {
+			return 0, err
+		}
+	}
+	return version, tx.Commit()
+}
+
+// GetQuiz returns one version (0 = latest) with the list of all versions; sql.ErrNoRows if the
+// analysis has no stored quiz (or not that version).
+func (r *quizRepository) GetQuiz(userID string, analysisID int, version int) (*models.StoredQuiz, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT quiz_version FROM quiz_questions WHERE analysis_id=$1 AND user_id=$2 ORDER BY quiz_version`, analysisID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	quiz := models.StoredQuiz{AnalysisID: analysisID, Quiz: []models.QuizQuestion{}}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		quiz.Versions = append(quiz.Versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(quiz.Versions) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	if version == 0 {
+		version = quiz.Versions[len(quiz.Versions)-1]
+	}
+	quiz.Version = version
+
+	qrows, err := r.db.Query(`SELECT id, question_type, question, answer, options, correct_index, difficulty, requested_count, created_at::text
+		FROM quiz_questions WHERE analysis_id=$1 AND user_id=$2 AND quiz_version=$3 ORDER BY position, id`, analysisID, userID, version)
+	if err != nil {
+		return nil, err
+	}
+	defer qrows.Close()
+	for qrows.Next() {
+		var q models.QuizQuestion
+		var correct sql.NullInt64
+		if err := qrows.Scan(&q.ID, &q.Type, &q.Question, &q.Answer, pq.Array(&q.Options), &correct,
+			&quiz.Options.Difficulty, &quiz.Options.Count, &quiz.CreatedAt); err != nil {
+			return nil, err
+		}
+		if correct.Valid {
+			idx := int(correct.Int64)
+			q.CorrectIndex = &idx
+		}
+		quiz.Options.Type = q.Type
+		quiz.Quiz = append(quiz.Quiz, q)
+	}
+	if err := qrows.Err(); err != nil {
+		return nil, err
+	}
+	if len(quiz.Quiz) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &quiz, nil
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+type ReviewRepository interface {
+	GetReviewStates(userID string, questionIDs []int) (map[int]models.ReviewState, error)
+	SaveAttempt(userID string, attempt *models.QuizAttempt, states []models.ReviewState) error
+	ListDue(userID string, now time.Time, limit, offset int) ([]models.DueReview, int, error)
+}
+
+type reviewRepository struct{ db *sql.DB }
+
+func NewReviewRepository() ReviewRepository { return &reviewRepository{db: database.DB} }
+
+// GetReviewStates returns the user's schedule of the given questions; never-reviewed ones are absent.
+func (r *reviewRepository) GetReviewStates(userID string, questionIDs []int) (map[int]models.ReviewState, error) {
+	rows, err := r.db.Query(`SELECT question_id, repetitions, interval_days, ease, due_at FROM review_schedule
+		WHERE user_id=$1 AND question_id = ANY($2)`, userID, pq.Array(questionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[int]models.ReviewState, len(questionIDs))
+	for rows.Next() {
+		var s models.ReviewState
+		if err := rows.Scan(&s.QuestionID, &s.Repetitions, &s.IntervalDays, &s.Ease, &s.DueAt); err != nil {
+			return nil, err
+		}
+		out[s.QuestionID] = s
+	}
+	return out, rows.Err()
+}
+
+// SaveAttempt stores the attempt with its graded answers and upserts the new review schedules in
+// one transaction; attempt.ID and attempt.CreatedAt are filled in.
+func (r *reviewRepository) SaveAttempt(userID string, attempt *models.QuizAttempt, states []models.ReviewState) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := tx.QueryRow(`INSERT INTO quiz_attempts(user_id, analysis_id, quiz_version, correct, total, score) VALUES($1,$2,$3,$4,$5,$6)
+		RETURNING id, created_at::text`, userID, attempt.AnalysisID, attempt.Version, attempt.Correct, attempt.Total, attempt.Score).
+		Scan(&attempt.ID, &attempt.CreatedAt); err != nil {
+		return err
+	}
+	answerStmt, err := tx.Prepare(`INSERT INTO quiz_answers(attempt_id, question_id, user_id, answer, correct, score, quality) VALUES($1,$2,$3,$4,$5,$6,$7)`)
+	if err != nil {
+		return err
+	}
+	defer answerStmt.Close()
+	for _, res := range attempt.Results {
+		if _, err := answerStmt.Exec(attempt.ID, res.QuestionID, userID, res.Answer, res.Correct, res.Score, res.Quality); err != nil {
+			return err
+		}
+	}
+	scheduleStmt, err := tx.Prepare(`INSERT INTO review_schedule(user_id, question_id, repetitions, interval_days, ease, due_at) VALUES($1,$2,$3,$4,$5,$6)
+		ON CONFLICT (user_id, question_id) DO UPDATE SET repetitions = EXCLUDED.repetitions, interval_days = EXCLUDED.interval_days,
+		ease = EXCLUDED.ease, due_at = EXCLUDED.due_at, updated_at = now()`)
+	if err != nil {
+		return err
+	}
+	defer scheduleStmt.Close()
+	for _, s := range states {
+		if _, err := scheduleStmt.Exec(userID, s.QuestionID, s.Repetitions, s.IntervalDays, s.Ease, s.DueAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListDue returns the user's questions due at now, most overdue first, with their answer history.
+func (r *reviewRepository) ListDue(userID string, now time.Time, limit, offset int) ([]models.DueReview, int, error) {
+	rows, err := r.db.Query(`SELECT q.id, q.analysis_id, q.question, s.due_at::text, s.repetitions, s.interval_days,
+			h.attempts, h.correct_count, COALESCE(h.last_correct, false), COUNT(*) OVER()
+		FROM review_schedule s
+		JOIN quiz_questions q ON q.id = s.question_id AND q.user_id = s.user_id
+		JOIN LATERAL (
+			SELECT COUNT(*) AS attempts, COUNT(*) FILTER (WHERE a.correct) AS correct_count,
+				(ARRAY_AGG(a.correct ORDER BY a.created_at DESC, a.id DESC))[1] AS last_correct
+			FROM quiz_answers a WHERE a.user_id = s.user_id AND a.question_id = s.question_id
+		) h ON true
+		WHERE s.user_id=$1 AND s.due_at <= $2
+		ORDER BY s.due_at, q.id
+		LIMIT $3 OFFSET $4`, userID, now, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := []models.DueReview{}
+	total := 0
+	for rows.Next() {
+		var d models.DueReview
+		if err := rows.Scan(&d.QuestionID, &d.AnalysisID, &d.Question, &d.DueAt, &d.Repetitions, &d.IntervalDays,
+			&d.Attempts, &d.CorrectCount, &d.LastCorrect, &total); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, d)
+	}
+	return out, total, rows.Err()
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+type SearchRepository interface {
+	Search(userID string, q models.SearchQuery) ([]models.SearchHit, int, error)
+}
+
+type searchRepository struct{ db *sql.DB }
+
+func NewSearchRepository() SearchRepository { return &searchRepository{db: database.DB} }
+
+// searchConfig pairs a Postgres text search configuration with the generated column built with it
+// (see migration 000006).
+type searchConfig struct {
+	regconfig string
+	column    string
+}
+
+var searchConfigs = map[string]searchConfig{
+	"en": {regconfig: "english", column: "search_en"},
+	"pt": {regconfig: "portuguese", column: "search_pt"},
+}
+
+// searchConfigFor maps a request language ("pt", "pt-PT", "pt-BR,pt;q=0.9") to its search
+// configuration, falling back to English.
+func searchConfigFor(lang string) searchConfig {
+	primary := strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(primary, "-_,;"); i >= 0 {
+		primary = primary[:i]
+	}
+	if cfg, ok := searchConfigs[primary]; ok {
+		return cfg
+	}
+	return searchConfigs["en"]
+}
+
+const headlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "`
+
+// Search ranks the user's documents matching q. Candidates come from both GIN indexes (document
+// text and any analysis of the document); ranking and the snippet use the latest analysis.
+// Snippets are only built for the returned page since ts_headline re-parses the whole text.
+func (r *searchRepository) Search(userID string, q models.SearchQuery) ([]models.SearchHit, int, error) {
+	cfg := searchConfigFor(q.Lang)
+	query := fmt.Sprintf(`WITH query AS (SELECT websearch_to_tsquery('%[1]s', $2) AS tsq),
+		matches AS (
+			SELECT d.id FROM documents d, query WHERE d.user_id = $1 AND d.%[2]s @@ query.tsq
+			UNION
+			SELECT a.document_id FROM analyses a, query WHERE a.user_id = $1 AND a.%[2]s @@ query.tsq
+		),
+		ranked AS (
+			SELECT d.id, d.file_name, d.collection_id, d.created_at, d.%[2]s @@ query.tsq AS text_match,
+				la.id AS analysis_id, la.summary, la.keywords,
+				ts_rank_cd(d.%[2]s || COALESCE(la.%[2]s, ''::tsvector), query.tsq) AS rank,
+				COUNT(*) OVER () AS total
+			FROM matches m
+			JOIN documents d ON d.id = m.id AND d.user_id = $1
+			CROSS JOIN query
+			LEFT JOIN LATERAL (
+				SELECT a.id, a.summary, a.keywords, a.%[2]s FROM analyses a
+				WHERE a.document_id = d.id AND a.user_id = d.user_id
+				ORDER BY a.created_at DESC LIMIT 1
+			) la ON true
+			WHERE $3::int IS NULL OR d.collection_id = $3
+			ORDER BY rank DESC, d.id DESC
+			LIMIT $4 OFFSET $5
+		)
+		SELECT r.id, r.file_name, r.collection_id, r.created_at::text, r.analysis_id, COALESCE(r.summary, ''),
+			COALESCE(r.keywords, '{}'::text[]), r.rank, r.total,
+			ts_headline('%[1]s', CASE WHEN r.text_match THEN COALESCE(d.full_text, '') ELSE COALESCE(r.summary, '') END, query.tsq, '%[3]s')
+		FROM ranked r
+		JOIN documents d ON d.id = r.id
+		CROSS JOIN query
+		ORDER BY r.rank DESC, r.id DESC`, cfg.regconfig, cfg.column, headlineOptions)
+
+	rows, err := r.db.Query(query, userID, q.Text, q.CollectionID, q.Limit, q.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	total := 0
+	hits := []models.SearchHit{}
+	for rows.Next() {
+		var h models.SearchHit
+		var colID, analysisID sql.NullInt64
+		var keywords []string
+		if err := rows.Scan(&h.DocumentID, &h.FileName, &colID, &h.CreatedAt, &analysisID, &h.Summary, pq.Array(&keywords), &h.Rank, &total, &h.Snippet); err != nil {
+			return nil, 0, err
+		}
+		if colID.Valid {
+			v := int(colID.Int64)
+			h.CollectionID = &v
+		}
+		if analysisID.Valid {
+			v := int(analysisID.Int64)
+			h.AnalysisID = &v
+		}
+		h.Keywords = keywords
+		h.Snippet = safeHighlight(h.Snippet)
+		hits = append(hits, h)
+	}
+	return hits, total, rows.Err()
+}
+
+// safeHighlight escapes document text for HTML while keeping the <mark> tags ts_headline added.
+func safeHighlight(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, "&lt;mark&gt;", "<mark>")
+	return strings.ReplaceAll(s, "&lt;/mark&gt;", "</mark>")
+}
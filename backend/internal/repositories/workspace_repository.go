@@ -0,0 +1,277 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/samusafe/genericapi/internal/database"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+// WorkspaceSink receives the exported records section by section (see workspace.Writer).
+type WorkspaceSink interface {
+	Section(name string) error
+	Add(record any) error
+}
+
+type WorkspaceRepository interface {
+	ExportWorkspace(ctx context.Context, userID string, sink WorkspaceSink) error
+	ImportWorkspace(ctx context.Context, userID string, archive *models.WorkspaceArchive, dryRun bool) (*models.ImportReport, error)
+}
+
+type workspaceRepository struct{ db *sql.DB }
+
+func NewWorkspaceRepository() WorkspaceRepository { return &workspaceRepository{db: database.DB} }
+
+// ExportWorkspace streams the user's collections, documents, analyses and quiz questions from one
+// read-only snapshot, so the sections are consistent with each other.
+func (r *workspaceRepository) ExportWorkspace(ctx context.Context, userID string, sink WorkspaceSink) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sections := []struct {
+		name  string
+		query string
+		scan  func(*sql.Rows) (any, error)
+	}{
+		{"collections", `SELECT id, name, created_at FROM collections WHERE user_id=$1 ORDER BY id`, func(rows *sql.Rows) (any, error) {
+			var c models.WorkspaceCollection
+			var created sql.NullTime
+			err := rows.Scan(&c.ID, &c.Name, &created)
+			c.CreatedAt = created.Time
+			return c, err
+		}},
+		{"documents", `SELECT id, collection_id, file_name, COALESCE(full_text,''), COALESCE(content_hash,''), COALESCE(file_ext,''),
+				COALESCE(detected_type,''), created_at
+			FROM documents WHERE user_id=$1 ORDER BY id`, func(rows *sql.Rows) (any, error) {
+			var d models.WorkspaceDocument
+			var colID sql.NullInt64
+			var created sql.NullTime
+			err := rows.Scan(&d.ID, &colID, &d.FileName, &d.FullText, &d.ContentHash, &d.FileExt, &d.DetectedType, &created)
+			if colID.Valid {
+				v := int(colID.Int64)
+				d.CollectionID = &v
+			}
+			d.CreatedAt = created.Time
+			return d, err
+		}},
+		{"analyses", `SELECT a.id, a.document_id, COALESCE(a.summary,''), COALESCE(a.keywords,'{}'::text[]), COALESCE(a.sentiment,''),
+				COALESCE(a.summary_points,'{}'::text[]), a.analysis_version, a.batch_id, a.batch_size, a.backend, a.meta, a.created_at
+			FROM analyses a JOIN documents d ON d.id = a.document_id AND d.user_id = a.user_id
+			WHERE a.user_id=$1 ORDER BY a.id`, func(rows *sql.Rows) (any, error) {
+			var an models.WorkspaceAnalysis
+			var batchID sql.NullString
+			var batchSize sql.NullInt64
+			var created sql.NullTime
+			var rawMeta []byte
+			err := rows.Scan(&an.ID, &an.DocumentID, &an.Summary, pq.Array(&an.Keywords), &an.Sentiment, pq.Array(&an.SummaryPoints),
+				&an.AnalysisVersion, &batchID, &batchSize, &an.Backend, &rawMeta, &created)
+			an.Meta = parseMeta(rawMeta)
+			if batchID.Valid {
+				an.BatchID = &batchID.String
+			}
+			if batchSize.Valid {
+				v := int(batchSize.Int64)
+				an.BatchSize = &v
+			}
+			an.CreatedAt = created.Time
+			return an, err
+		}},
+		{"quizQuestions", `SELECT q.id, q.analysis_id, q.quiz_version, q.position, q.question_type, q.question, q.answer, q.options,
+				q.correct_index, q.difficulty, q.requested_count, q.created_at
+			FROM quiz_questions q
+			JOIN analyses a ON a.id = q.analysis_id AND a.user_id = q.user_id
+			JOIN documents d ON d.id = a.document_id AND d.user_id = a.user_id
+			WHERE q.user_id=$1 ORDER BY q.id`, func(rows *sql.Rows) (any, error) {
+			var q models.WorkspaceQuizQuestion
+			var correct sql.NullInt64
+			var created sql.NullTime
+			err := rows.Scan(&q.ID, &q.AnalysisID, &q.QuizVersion, &q.Position, &q.Type, &q.Question, &q.Answer, pq.Array(&q.Options),
+				&correct, &q.Difficulty, &q.RequestedCount, &created)
+			if correct.Valid {
+				v := int(correct.Int64)
+				q.CorrectIndex = &v
+			}
+			q.CreatedAt = created.Time
+			return q, err
+		}},
+	}
+
+	for _, s := range sections {
+		if err := sink.Section(s.name); err != nil {
+			return err
+		}
+		if err := exportRows(ctx, tx, s.query, userID, s.scan, sink); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportRows(ctx context.Context, tx *sql.Tx, query, userID string, scan func(*sql.Rows) (any, error), sink WorkspaceSink) error {
+	rows, err := tx.QueryContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		record, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := sink.Add(record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ImportWorkspace restores an archive in one transaction, remapping every archive ID to a new row.
+// Collections are merged by name; a document whose content hash already exists in the (mapped)
+// collection is not imported again and its analyses and quiz questions are skipped. Imports of
+// one user are serialized. With dryRun the transaction is rolled back, so the report describes
+// exactly what a real import would do at this moment.
+func (r *workspaceRepository) ImportWorkspace(ctx context.Context, userID string, a *models.WorkspaceArchive, dryRun bool) (*models.ImportReport, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('workspace:' || $1))`, userID); err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{DryRun: dryRun, Conflicts: []models.ImportConflict{}}
+
+	collections := make(map[int]int, len(a.Collections))
+	for _, c := range a.Collections {
+		var id int
+		err := tx.QueryRowContext(ctx, `SELECT id FROM collections WHERE user_id=$1 AND name=$2`, userID, c.Name).Scan(&id)
+		switch {
+		case err == nil:
+			report.Collections.Existing++
+			report.Conflicts = append(report.Conflicts, models.ImportConflict{Kind: "collection", ArchiveID: c.ID, Name: c.Name, ExistingID: id})
+		case errors.Is(err, sql.ErrNoRows):
+			if err := tx.QueryRowContext(ctx, `INSERT INTO collections(user_id, name, created_at) VALUES($1,$2,COALESCE($3, now())) RETURNING id`,
+				userID, c.Name, nullTime(c.CreatedAt)).Scan(&id); err != nil {
+				return nil, err
+			}
+			report.Collections.Created++
+		default:
+			return nil, err
+		}
+		collections[c.ID] = id
+	}
+
+	documents := make(map[int]int, len(a.Documents))
+	for _, d := range a.Documents {
+		var collectionID *int
+		if d.CollectionID != nil {
+			v := collections[*d.CollectionID]
+			collectionID = &v
+		}
+		if d.ContentHash != "" {
+			var existing int
+			err := tx.QueryRowContext(ctx, `SELECT id FROM documents WHERE user_id=$1 AND content_hash=$2 AND collection_id IS NOT DISTINCT FROM $3`,
+				userID, d.ContentHash, collectionID).Scan(&existing)
+			if err == nil {
+				report.Documents.Existing++
+				report.Conflicts = append(report.Conflicts, models.ImportConflict{Kind: "document", ArchiveID: d.ID, Name: d.FileName, ExistingID: existing})
+				continue
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+		}
+		var id int
+		if err := tx.QueryRowContext(ctx, `INSERT INTO documents(user_id, collection_id, file_name, full_text, content_hash, file_ext, detected_type, created_at)
+			VALUES($1,$2,$3,$4,NULLIF($5,''),NULLIF($6,''),NULLIF($7,''),COALESCE($8, now())) RETURNING id`,
+			userID, collectionID, d.FileName, d.FullText, d.ContentHash, d.FileExt, d.DetectedType, nullTime(d.CreatedAt)).Scan(&id); err != nil {
+			return nil, err
+		}
+		report.Documents.Created++
+		documents[d.ID] = id
+	}
+
+	analyses := make(map[int]int, len(a.Analyses))
+	for _, an := range a.Analyses {
+		documentID, ok := documents[an.DocumentID]
+		if !ok {
+			report.Analyses.Skipped++
+			continue
+		}
+		version := an.AnalysisVersion
+		if version == 0 {
+			version = 1
+		}
+		backend := an.Backend
+		if backend == "" {
+			backend = "python"
+		}
+		rawMeta, err := metaJSON(an.Meta)
+		if err != nil {
+			return nil, err
+		}
+		var id int
+		if err := tx.QueryRowContext(ctx, `INSERT INTO analyses(user_id, document_id, summary, keywords, sentiment, summary_points,
+				analysis_version, batch_id, batch_size, backend, meta, created_at)
+			VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,COALESCE($12, now())) RETURNING id`,
+			userID, documentID, an.Summary, pq.Array(an.Keywords), an.Sentiment, pq.Array(an.SummaryPoints),
+			version, an.BatchID, an.BatchSize, backend, rawMeta, nullTime(an.CreatedAt)).Scan(&id); err != nil {
+			return nil, err
+		}
+		report.Analyses.Created++
+		analyses[an.ID] = id
+	}
+
+	for _, q := range a.QuizQuestions {
+		analysisID, ok := analyses[q.AnalysisID]
+		if !ok {
+			report.QuizQuestions.Skipped++
+			continue
+		}
+		var options interface{}
+		if q.Options != nil {
+			options = pq.Array(q.Options)
+		}
+		qtype, difficulty, version, count := q.Type, q.Difficulty, q.QuizVersion, q.RequestedCount
+		if qtype == "" {
+			qtype = models.QuizTypeOpen
+		}
+		if difficulty == "" {
+			difficulty = models.QuizDifficultyMedium
+		}
+		if version == 0 {
+			version = 1
+		}
+		if count == 0 {
+			count = 5
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO quiz_questions(user_id, analysis_id, question, answer, quiz_version, position,
+				question_type, options, correct_index, difficulty, requested_count, created_at)
+			VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,COALESCE($12, now()))`,
+			userID, analysisID, q.Question, q.Answer, version, q.Position, qtype, options, q.CorrectIndex, difficulty, count, nullTime(q.CreatedAt)); err != nil {
+			return nil, err
+		}
+		report.QuizQuestions.Created++
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	return report, tx.Commit()
+}
+
+// nullTime maps the zero time (absent in the archive) to NULL.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
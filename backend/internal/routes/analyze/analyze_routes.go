@@ -8,5 +8,5 @@ import (
 // RegisterAnalyzeRoutes sets up the routes for the analysis feature.
 func RegisterAnalyzeRoutes(r gin.IRoutes, h *handlers.AnalyzeHandler) {
 	r.POST("/analyze", h.Analyze)
-	r.POST("/generate-quiz", h.GenerateQuiz)
+	r.POST("/documents/:documentId/reanalyze", h.Reanalyze)
 }
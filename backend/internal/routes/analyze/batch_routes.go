@@ -0,0 +1,13 @@
+package analyze
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/handlers"
+)
+
+// RegisterBatchRoutes sets up the routes reading multi-file uploads back and retrying them.
+func RegisterBatchRoutes(r gin.IRoutes, h *handlers.BatchesHandler) {
+	r.GET("/batches", h.List)
+	r.GET("/batches/:id", h.Get)
+	r.POST("/batches/:id/retry", h.Retry)
+}
@@ -7,6 +7,8 @@ import (
 
 func RegisterHistoryRoutes(r gin.IRoutes, h *handlers.AnalysisHistoryHandler) {
 	r.GET("/documents/:documentId/latest-analysis", h.GetLatestByDocument)
+	r.GET("/documents/:documentId/analyses", h.ListVersions)
+	r.GET("/documents/:documentId/analyses/diff", h.DiffVersions)
 	r.GET("/documents", h.ListAllDocuments)
 	r.POST("/documents/save", h.SaveDocumentToCollection)
 }
@@ -0,0 +1,12 @@
+package analyze
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/handlers"
+)
+
+// RegisterJobRoutes sets up the asynchronous analysis job routes.
+func RegisterJobRoutes(r gin.IRoutes, h *handlers.AnalysisJobsHandler) {
+	r.POST("/analyze/jobs", h.Submit)
+	r.GET("/analyze/jobs/:id", h.Get)
+}
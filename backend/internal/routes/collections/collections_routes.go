@@ -15,3 +15,11 @@ func Register(r gin.IRoutes, h *handlers.CollectionsHandler) {
 	r.DELETE("/collections/:id", h.Delete)
 	r.GET("/collections/:id/documents", h.ListDocuments)
 }
+
+func RegisterAsk(r gin.IRoutes, h *handlers.AskHandler) {
+	r.POST("/collections/:id/ask", h.Ask)
+}
+
+func RegisterInsights(r gin.IRoutes, h *handlers.InsightsHandler) {
+	r.GET("/collections/:id/insights", h.Get)
+}
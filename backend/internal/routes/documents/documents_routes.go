@@ -0,0 +1,15 @@
+package documents
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/handlers"
+)
+
+func Register(r gin.IRoutes, h *handlers.DocumentsHandler) {
+	r.GET("/documents/:documentId/original", h.DownloadOriginal)
+	r.GET("/documents/:documentId/related", h.Related)
+	r.PATCH("/documents/:documentId", h.UpdateDocument)
+	r.DELETE("/documents/:documentId", h.DeleteDocument)
+	r.POST("/documents/bulk/move", h.BulkMove)
+	r.POST("/documents/bulk/delete", h.BulkDelete)
+}
@@ -0,0 +1,11 @@
+package export
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/handlers"
+)
+
+func Register(r gin.IRoutes, h *handlers.ExportHandler) {
+	r.GET("/documents/:documentId/export", h.ExportDocument)
+	r.GET("/collections/:id/export", h.ExportCollection)
+}
@@ -0,0 +1,15 @@
+package me
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/handlers"
+)
+
+func Register(r gin.IRoutes, h *handlers.WorkspaceHandler) {
+	r.GET("/me/export", h.Export)
+	r.POST("/me/import", h.Import)
+}
+
+func RegisterAccount(r gin.IRoutes, h *handlers.AccountHandler) {
+	r.DELETE("/me", h.DeleteAccount)
+}
@@ -0,0 +1,16 @@
+package quiz
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/handlers"
+)
+
+func Register(r gin.IRoutes, h *handlers.QuizHandler) {
+	r.POST("/generate-quiz", h.GenerateQuiz)
+	r.GET("/analyses/:id/quiz", h.GetAnalysisQuiz)
+}
+
+func RegisterReviews(r gin.IRoutes, h *handlers.ReviewHandler) {
+	r.POST("/quizzes/:id/attempts", h.SubmitAttempt)
+	r.GET("/reviews/due", h.DueReviews)
+}
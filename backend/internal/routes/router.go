@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,31 +10,48 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 	"github.com/samusafe/genericapi/internal/apidocs"
+	"github.com/samusafe/genericapi/internal/blobstore"
 	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/export"
 	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/httpclient"
 	"github.com/samusafe/genericapi/internal/middleware"
 	"github.com/samusafe/genericapi/internal/repositories"
 	"github.com/samusafe/genericapi/internal/routes/analyze"
 	"github.com/samusafe/genericapi/internal/routes/base"
 	"github.com/samusafe/genericapi/internal/routes/collections"
+	"github.com/samusafe/genericapi/internal/routes/documents"
+	exportroutes "github.com/samusafe/genericapi/internal/routes/export"
+	"github.com/samusafe/genericapi/internal/routes/me"
+	"github.com/samusafe/genericapi/internal/routes/quiz"
+	"github.com/samusafe/genericapi/internal/routes/search"
+	"github.com/samusafe/genericapi/internal/scanner"
 	"github.com/samusafe/genericapi/internal/services"
 	"github.com/samusafe/genericapi/internal/utils"
 )
 
-func SetupRouter() *gin.Engine {
+// SetupRouter builds the engine and starts background workers bound to ctx.
+// The returned func waits for those workers to stop once ctx is cancelled.
+func SetupRouter(ctx context.Context) (*gin.Engine, func()) {
 	r := gin.New()
 
 	// Recovery (custom) placed first to catch panics from later middleware/handlers
 	r.Use(middleware.Recovery())
 
-	// Global multipart memory limit (in-memory parsing before temporary file spill)
-	// Use half of MaxUploadBytes as a safeguard per single request form parsing buffer.
-	r.MaxMultipartMemory = config.MaxUploadBytes / 2
+	// Global multipart memory limit (in-memory parsing before temporary file spill).
+	// Independent of MaxUploadBytes: uploads are streamed from the spilled files, so large
+	// limits do not grow memory per request.
+	r.MaxMultipartMemory = config.MultipartMemory
 
-	// Simple body size guard using Content-Length (best effort; still rely on per-handler checks for accuracy)
+	// Simple body size guard using Content-Length (best effort; still rely on per-handler checks for accuracy).
+	// Workspace imports carry a whole archive and have their own limit.
 	r.Use(func(c *gin.Context) {
 		if cl := c.Request.Header.Get("Content-Length"); cl != "" {
-			if v, err := strconv.ParseInt(cl, 10, 64); err == nil && v > config.MaxUploadBytes {
+			limit := config.MaxUploadBytes
+			if c.Request.URL.Path == "/me/import" {
+				limit = config.WorkspaceImportMaxBytes
+			}
+			if v, err := strconv.ParseInt(cl, 10, 64); err == nil && v > limit {
 				cid := c.GetString(utils.CorrelationIDHeader)
 				log.Warn().Str("cid", cid).Int64("content_length", v).Msg("request rejected: body too large")
 				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Payload too large"})
@@ -94,14 +112,70 @@ func SetupRouter() *gin.Engine {
 	// Repositories
 	analysisRepo := repositories.NewAnalysisRepository()
 	collectionsRepo := repositories.NewCollectionsRepository()
+	jobsRepo := repositories.NewJobsRepository()
+	quarantineRepo := repositories.NewQuarantineRepository()
+	documentsRepo := repositories.NewDocumentsRepository()
+	searchRepo := repositories.NewSearchRepository()
+	embeddingsRepo := repositories.NewEmbeddingsRepository()
+	chunksRepo := repositories.NewChunksRepository()
+	quizRepo := repositories.NewQuizRepository()
+	reviewRepo := repositories.NewReviewRepository()
+	workspaceRepo := repositories.NewWorkspaceRepository()
+	accountRepo := repositories.NewAccountRepository()
+	batchesRepo := repositories.NewBatchesRepository()
+	insightsRepo := repositories.NewInsightsRepository()
+
+	// Analysis backends (PYTHON_SERVICE_URL + optional extra engines and routing rules)
+	backends, err := httpclient.LoadRegistry()
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid analysis backends configuration")
+	}
+
+	// Upload malware scanning (no-op unless SCANNER_ADDR is set)
+	uploadScanner, err := scanner.FromConfig()
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid scanner configuration")
+	}
+
+	// Original uploads, keyed by content hash
+	blobs, err := blobstore.FromConfig()
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid blob store configuration")
+	}
+	blobs = repositories.NewGuardedBlobStore(blobs)
+
+	// Export templates (built-in, or overrides from EXPORT_TEMPLATES_DIR)
+	exporter, err := export.FromConfig()
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid export templates")
+	}
 
 	// Services (inject repo)
-	analyzerService := services.NewAnalyzerServiceWithRepo(analysisRepo)
+	analyzerService := services.NewAnalyzerServiceWithBackends(analysisRepo, backends, services.WithScanner(uploadScanner, quarantineRepo), services.WithBlobStore(blobs), services.WithEmbeddings(embeddingsRepo), services.WithChunks(chunksRepo), services.WithBatches(batchesRepo))
+	jobService := services.NewJobService(jobsRepo, analyzerService)
+	qaService := services.NewQAService(chunksRepo, backends)
+	insightsService := services.NewInsightsService(insightsRepo, backends)
+	quizService := services.NewQuizService(quizRepo, analyzerService)
+	reviewService := services.NewReviewService(quizRepo, reviewRepo)
+	workspaceService := services.NewWorkspaceService(workspaceRepo)
+	accountService := services.NewAccountService(accountRepo, blobs, services.DeletionKeyFromConfig())
+	waitWorkers := jobService.Start(ctx)
 
 	// Handlers
 	analyzeHandler := handlers.NewAnalyzeHandler(analyzerService)
 	collectionsHandler := handlers.NewCollectionsHandler(collectionsRepo, analysisRepo)
 	analysisHistoryHandler := handlers.NewAnalysisHistoryHandler(analysisRepo, collectionsRepo)
+	analysisJobsHandler := handlers.NewAnalysisJobsHandler(jobService)
+	batchesHandler := handlers.NewBatchesHandler(batchesRepo, analyzerService)
+	documentsHandler := handlers.NewDocumentsHandler(documentsRepo, blobs, embeddingsRepo)
+	searchHandler := handlers.NewSearchHandler(searchRepo, collectionsRepo)
+	askHandler := handlers.NewAskHandler(collectionsRepo, qaService)
+	insightsHandler := handlers.NewInsightsHandler(collectionsRepo, insightsService)
+	quizHandler := handlers.NewQuizHandler(quizService)
+	reviewHandler := handlers.NewReviewHandler(reviewService)
+	exportHandler := handlers.NewExportHandler(analysisRepo, collectionsRepo, exporter)
+	workspaceHandler := handlers.NewWorkspaceHandler(workspaceService)
+	accountHandler := handlers.NewAccountHandler(accountService)
 
 	// Routes
 	base.RegisterBaseRoutes(r)
@@ -111,12 +185,23 @@ func SetupRouter() *gin.Engine {
 	authGroup.Use(middleware.ClerkAuth())
 	{
 		analyze.RegisterAnalyzeRoutes(authGroup, analyzeHandler)
+		analyze.RegisterJobRoutes(authGroup, analysisJobsHandler)
+		analyze.RegisterBatchRoutes(authGroup, batchesHandler)
 		collections.Register(authGroup, collectionsHandler)
+		collections.RegisterAsk(authGroup, askHandler)
+		collections.RegisterInsights(authGroup, insightsHandler)
 		analyze.RegisterHistoryRoutes(authGroup, analysisHistoryHandler)
+		documents.Register(authGroup, documentsHandler)
+		search.Register(authGroup, searchHandler)
+		quiz.Register(authGroup, quizHandler)
+		quiz.RegisterReviews(authGroup, reviewHandler)
+		exportroutes.Register(authGroup, exportHandler)
+		me.Register(authGroup, workspaceHandler)
+		me.RegisterAccount(authGroup, accountHandler)
 	}
 
 	// External OpenAPI YAML + UI
 	apidocs.Register(r)
 
-	return r
+	return r, waitWorkers
 }
@@ -0,0 +1,10 @@
+package search
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/handlers"
+)
+
+func Register(r gin.IRoutes, h *handlers.SearchHandler) {
+	r.GET("/search", h.Search)
+}
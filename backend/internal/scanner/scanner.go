@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/samusafe/genericapi/internal/config"
+)
+
+// Malware scanning runs on every upload before reuse lookups or backend calls.
+// The default scanner accepts everything; set SCANNER_ADDR to use a clamd daemon.
+
+// Verdict is the outcome of a scan.
+type Verdict struct {
+	Clean     bool
+	Signature string // name of the detected signature when not clean
+}
+
+// Scanner inspects uploaded bytes.
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, r io.Reader) (Verdict, error)
+}
+
+type noopScanner struct{}
+
+// Noop returns a scanner that reports every file as clean.
+func Noop() Scanner { return noopScanner{} }
+
+func (noopScanner) Name() string { return "noop" }
+func (noopScanner) Scan(context.Context, io.Reader) (Verdict, error) {
+	return Verdict{Clean: true}, nil
+}
+
+// clamdChunkSize is the size of each INSTREAM chunk.
+const clamdChunkSize = 64 * 1024
+
+// Clamd speaks the clamd INSTREAM protocol over TCP or a unix socket.
+type Clamd struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+func NewClamd(network, address string, timeout time.Duration) *Clamd {
+	return &Clamd{network: network, address: address, timeout: timeout}
+}
+
+func (c *Clamd) Name() string { return "clamd" }
+
+// Scan streams r as length-prefixed chunks ending with a zero-length chunk and parses the
+// reply: "stream: OK", "stream: <signature> FOUND" or "<message> ERROR".
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return Verdict{}, err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(c.timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	conn.SetDeadline(deadline)
+
+	w := bufio.NewWriter(conn)
+	w.WriteString("zINSTREAM\x00")
+	var size [4]byte
+	chunk := make([]byte, clamdChunkSize)
+	for {
+		n, err := io.ReadFull(r, chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			w.Write(size[:])
+			w.Write(chunk[:n])
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return Verdict{}, err
+		}
+	}
+	binary.BigEndian.PutUint32(size[:], 0)
+	w.Write(size[:])
+	if err := w.Flush(); err != nil {
+		return Verdict{}, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return Verdict{}, err
+	}
+	return parseClamdReply(reply)
+}
+
+func parseClamdReply(reply string) (Verdict, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return Verdict{Clean: true}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return Verdict{Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	case reply == "":
+		return Verdict{}, errors.New("clamd: empty reply")
+	}
+	return Verdict{}, fmt.Errorf("clamd: %s", reply)
+}
+
+// FromConfig builds the scanner selected by SCANNER_ADDR ("tcp://host:3310" or
+// "unix:///path/clamd.sock"); an empty address disables scanning.
+func FromConfig() (Scanner, error) {
+	addr := config.ScannerAddr
+	if addr == "" {
+		return Noop(), nil
+	}
+	network, address, ok := strings.Cut(addr, "://")
+	if !ok || (network != "tcp" && network != "unix") || address == "" {
+		return nil, fmt.Errorf("invalid SCANNER_ADDR %q", addr)
+	}
+	return NewClamd(network, address, config.ScannerTimeout), nil
+}
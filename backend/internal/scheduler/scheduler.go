@@ -0,0 +1,166 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/samusafe/genericapi/internal/config"
+)
+
+// Scheduler bounds concurrent outbound analysis calls for the whole process.
+// Two limits apply: a global cap (the Python pods only have so many models loaded) and a
+// per-user cap so one heavy uploader cannot occupy every slot. When a slot frees up it is
+// handed to waiting users in round-robin order, FIFO within each user.
+type Scheduler struct {
+	mu       sync.Mutex
+	global   int
+	perUser  int
+	inFlight int
+	running  map[string]int
+	queues   map[string][]*waiter
+	order    []string // users with waiters, rotated on every hand-off
+}
+
+type waiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// New creates a scheduler; limits below 1 are treated as 1 and perUser never exceeds global.
+func New(global, perUser int) *Scheduler {
+	if global < 1 {
+		global = 1
+	}
+	if perUser < 1 || perUser > global {
+		perUser = global
+	}
+	return &Scheduler{global: global, perUser: perUser, running: make(map[string]int), queues: make(map[string][]*waiter)}
+}
+
+var (
+	sharedOnce sync.Once
+	shared     *Scheduler
+)
+
+// Shared returns the process-wide scheduler configured from env.
+func Shared() *Scheduler {
+	sharedOnce.Do(func() {
+		shared = New(config.AnalysisMaxConcurrency, config.AnalysisPerUserConcurrency)
+	})
+	return shared
+}
+
+// Acquire blocks until userID may start a call or ctx is done. The returned release func must be
+// called exactly once when the call finishes (extra calls are ignored).
+func (s *Scheduler) Acquire(ctx context.Context, userID string) (func(), error) {
+	s.mu.Lock()
+	if s.inFlight < s.global && s.running[userID] < s.perUser {
+		s.grantLocked(userID)
+		s.mu.Unlock()
+		return s.releaseFunc(userID), nil
+	}
+	w := &waiter{ready: make(chan struct{})}
+	if len(s.queues[userID]) == 0 {
+		s.order = append(s.order, userID)
+	}
+	s.queues[userID] = append(s.queues[userID], w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return s.releaseFunc(userID), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		if w.granted {
+			// Lost the race: the slot was handed over just as ctx ended. Give it back.
+			s.mu.Unlock()
+			s.releaseFunc(userID)()
+			return nil, ctx.Err()
+		}
+		s.removeWaiterLocked(userID, w)
+		s.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// Stats reports calls in flight and callers waiting for a slot.
+func (s *Scheduler) Stats() (inFlight, queued int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, q := range s.queues {
+		queued += len(q)
+	}
+	return s.inFlight, queued
+}
+
+func (s *Scheduler) grantLocked(userID string) {
+	s.inFlight++
+	s.running[userID]++
+}
+
+func (s *Scheduler) releaseFunc(userID string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.inFlight--
+			if s.running[userID]--; s.running[userID] <= 0 {
+				delete(s.running, userID)
+			}
+			s.dispatchLocked()
+		})
+	}
+}
+
+// dispatchLocked hands free slots to waiting users, one per user per pass.
+func (s *Scheduler) dispatchLocked() {
+	for s.inFlight < s.global && len(s.order) > 0 {
+		granted := false
+		for i := 0; i < len(s.order); i++ {
+			user := s.order[0]
+			s.order = s.order[1:]
+			if s.running[user] >= s.perUser {
+				s.order = append(s.order, user)
+				continue
+			}
+			q := s.queues[user]
+			w := q[0]
+			if len(q) == 1 {
+				delete(s.queues, user)
+			} else {
+				s.queues[user] = q[1:]
+				s.order = append(s.order, user)
+			}
+			w.granted = true
+			s.grantLocked(user)
+			close(w.ready)
+			granted = true
+			break
+		}
+		if !granted {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) removeWaiterLocked(userID string, w *waiter) {
+	q := s.queues[userID]
+	for i, other := range q {
+		if other == w {
+			q = append(q[:i], q[i+1:]...)
+			break
+		}
+	}
+	if len(q) > 0 {
+		s.queues[userID] = q
+		return
+	}
+	delete(s.queues, userID)
+	for i, u := range s.order {
+		if u == userID {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/samusafe/genericapi/internal/blobstore"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// Account service overview:
+// - DeleteAccount erases every row of a user (collections, documents, analyses, quizzes and
+//   their history, embeddings, passages, jobs, quarantine events) in one transaction, together
+//   with the original uploads no other user shares.
+// - Blobs are deleted once the transaction has committed, so a rolled-back deletion never loses an
+//   upload. A blob that cannot be deleted is logged and left unreferenced; the blob store only
+//   deletes hashes that are still unreferenced under their lock (see NewGuardedBlobStore).
+// - The outcome is a report signed with ACCOUNT_DELETION_KEY; the same fields are stored as the
+//   audit record. The user appears only as a keyed hash (subject), never as the raw ID.
+
+type AccountServiceInterface interface {
+	DeleteAccount(ctx context.Context, userID string, requestedBy string) (*models.DeletionReport, error)
+}
+
+type accountService struct {
+	repo  repositories.AccountRepository
+	blobs blobstore.BlobStore
+	key   []byte
+	now   func() time.Time
+}
+
+func NewAccountService(repo repositories.AccountRepository, blobs blobstore.BlobStore, key []byte) AccountServiceInterface {
+	return &accountService{repo: repo, blobs: blobs, key: key, now: time.Now}
+}
+
+// DeletionKeyFromConfig returns ACCOUNT_DELETION_KEY. Without one a random key is used, so
+// reports signed by this process cannot be verified after a restart.
+func DeletionKeyFromConfig() []byte {
+	if config.AccountDeletionKey != "" {
+		return []byte(config.AccountDeletionKey)
+	}
+	log.Warn().Msg("ACCOUNT_DELETION_KEY not set: deletion reports are signed with a temporary key")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func (s *accountService) DeleteAccount(ctx context.Context, userID string, requestedBy string) (*models.DeletionReport, error) {
+	cid := utils.CorrelationIDFromCtx(ctx)
+	report, orphaned, err := s.repo.DeleteAccount(ctx, userID, func(counts models.DeletionCounts, orphanedBlobs []string) (*models.DeletionReport, error) {
+		if s.blobs != nil {
+			counts.Blobs = len(orphanedBlobs)
+		}
+		report := &models.DeletionReport{
+			ID:          uuid.NewString(),
+			Subject:     DeletionSubject(s.key, userID),
+			RequestedBy: requestedBy,
+			DeletedAt:   s.now().UTC().Truncate(time.Microsecond),
+			Counts:      counts,
+		}
+		report.Signature = signDeletionReport(s.key, report)
+		return report, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.blobs != nil {
+		// The rows are gone for good: the deletion must run to the end even if the caller left
+		ctx := context.WithoutCancel(ctx)
+		for _, key := range orphaned {
+			if err := s.blobs.Delete(ctx, key); err != nil {
+				log.Error().Str("cid", cid).Str("report", report.ID).Str("hash", key).Err(err).Msg("blob delete error")
+			}
+		}
+	}
+	log.Info().Str("cid", cid).Str("report", report.ID).Str("requested_by", requestedBy).
+		Int("documents", report.Counts.Documents).Int("blobs", report.Counts.Blobs).Msg("account deleted")
+	return report, nil
+}
+
+// DeletionSubject is the keyed hash identifying a user in deletion reports and audit records.
+// Whoever holds the key can check whether a given user ID was deleted; nobody else can.
+func DeletionSubject(key []byte, userID string) string {
+	return hmacHex(key, "subject:"+userID)
+}
+
+// VerifyDeletionReport reports whether the signature matches the report's other fields.
+func VerifyDeletionReport(key []byte, report *models.DeletionReport) bool {
+	return hmac.Equal([]byte(signDeletionReport(key, report)), []byte(report.Signature))
+}
+
+func signDeletionReport(key []byte, report *models.DeletionReport) string {
+	unsigned := *report
+	unsigned.Signature = ""
+	payload, _ := json.Marshal(unsigned) // fixed field order: a stable encoding to sign
+	return hmacHex(key, "report:"+string(payload))
+}
+
+func hmacHex(key []byte, msg string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return hex.EncodeToString(mac.Sum(nil))
+}
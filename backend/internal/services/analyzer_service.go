@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
+	"os"
 	"path"
 	"slices"
 	"strings"
@@ -17,23 +21,34 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+	"github.com/samusafe/genericapi/internal/blobstore"
 	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/extract"
 	"github.com/samusafe/genericapi/internal/httpclient"
 	"github.com/samusafe/genericapi/internal/i18n"
 	"github.com/samusafe/genericapi/internal/models"
 	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/scanner"
+	"github.com/samusafe/genericapi/internal/scheduler"
+	"github.com/samusafe/genericapi/internal/sniff"
 	"github.com/samusafe/genericapi/internal/utils"
 )
 
-// Analyzer service overview (flow):
-// 1. For each file (parallel goroutines) validate extension (whitelist) early → fast fail.
-// 2. Open + buffer file once while hashing (sha256) to compute content hash.
-// 3. Reuse path: if (user, optional collection, contentHash) already exists → fetch latest analysis
-//    and still insert a new analysis history row (audit / batch grouping) then return reused=true.
-// 4. New path: call Python microservice; classify transport errors into a generic user‑facing
-//    "PythonServiceUnavailable" (details stay in logs). On success persist document + analysis.
-// 5. Always include timing + reused flag in structured logs (cid correlation).
-// Quiz generation is a simple passthrough (no persistence) guarded at handler level by length limit.
+// Analyzer service overview (flow). Files run in parallel goroutines and an unsupported extension
+// fails fast. Each upload is hashed (sha256) in one streaming pass, then re-read from the start by:
+// 1. Scan: the malware scanner rejects infected files ("FileRejected", audited in
+//    quarantine_events). Job files are scanned on submit instead.
+// 2. Sniff: content whose magic bytes do not match the extension is rejected.
+// 3. Reuse: a known (user, collection, hash) gets a new history row for its latest analysis,
+//    unless Force is set or the routed backend now reports another model version.
+// 4. Extract: .txt/.md are decoded here and only their text is sent to the backend.
+// 5. Backend: wait for a scheduler slot, route to a registry backend and call it; transport
+//    errors become "PythonServiceUnavailable" (details stay in logs).
+// 6. Store: document + analysis (backend, meta) as the document's next version, the original in
+//    the blob store, its embedding and its Q&A passages.
+// Failed files of a batch are recorded for GET /batches/:id; transient failures keep their upload
+// so RetryBatch can analyze them again. Logs carry timing and the reused flag (cid correlation).
+// Quiz generation is a simple passthrough; persistence and response checks live in the quiz service.
 
 // FileOpener abstraction enables in‑memory test doubles (avoids disk IO in tests).
 type FileOpener interface {
@@ -48,43 +63,186 @@ func (defaultFileOpener) Open(fh *multipart.FileHeader) (multipart.File, error)
 type AnalyzerServiceInterface interface {
 	AnalyzeFiles(files []*multipart.FileHeader, lang string, userID string, collectionID *int) []models.AnalysisResult
 	AnalyzeFilesWithContext(ctx context.Context, files []*multipart.FileHeader, lang string, userID string, collectionID *int) []models.AnalysisResult
-	GenerateQuiz(text string, lang string) (*models.QuizResponse, error)
-	GenerateQuizWithContext(ctx context.Context, text string, lang string) (*models.QuizResponse, error)
+	AnalyzeFilesWithOptions(ctx context.Context, files []*multipart.FileHeader, lang string, userID string, collectionID *int, opts AnalyzeOptions) []models.AnalysisResult
+	AnalyzeFilesStream(ctx context.Context, files []*multipart.FileHeader, lang string, userID string, collectionID *int, opts AnalyzeOptions, emit func(models.AnalysisResult)) models.BatchSummary
+	AnalyzeContent(ctx context.Context, fileName string, content []byte, lang string, userID string, collectionID *int, batchID *string, batchSize *int, opts AnalyzeOptions) models.AnalysisResult
+	ScanUpload(ctx context.Context, fileName string, f multipart.File, lang string, userID string, collectionID *int, batchID *string, batchSize *int, opts AnalyzeOptions) *models.AnalysisResult
+	ReanalyzeDocument(ctx context.Context, userID string, documentID int, opts AnalyzeOptions) (*models.AnalysisDetail, error)
+	RetryBatch(ctx context.Context, userID, batchID, lang string) ([]models.AnalysisResult, error)
+	HasBackend(name string) bool
+	GenerateQuiz(text string, lang string, opts models.QuizOptions) (*models.QuizResponse, error)
+	GenerateQuizWithContext(ctx context.Context, text string, lang string, opts models.QuizOptions) (*models.QuizResponse, error)
 }
 
+// AnalyzeOptions carries optional per-request choices.
+type AnalyzeOptions struct {
+	// Backend forces a named backend; empty lets the routing rules decide.
+	Backend string
+	// Force analyzes again even when the document already has an analysis.
+	Force bool
+	// Scanned skips the malware scan: the file already passed it (job files are scanned on submit).
+	Scanned bool
+}
+
+var (
+	// ErrAnalysisCancelled means the request ended while waiting for an outbound slot.
+	ErrAnalysisCancelled = errors.New("analysis cancelled")
+	// ErrBackendUnavailable means the analysis backend could not be reached or answered with an error.
+	ErrBackendUnavailable = errors.New("analysis backend unavailable")
+	// ErrBackendUnsupported means the routed backend cannot analyze this file (e.g. an
+	// OpenAI-compatible backend given a format that is not extracted on our side).
+	ErrBackendUnsupported = errors.New("file not supported by analysis backend")
+	// ErrBackendRejected means the backend refused the file with a 4xx status; analyzing it again
+	// would get the same answer.
+	ErrBackendRejected = errors.New("file rejected by analysis backend")
+	// ErrMalformedAnalysis means the backend answered with something that is not an analysis.
+	ErrMalformedAnalysis = errors.New("malformed analysis")
+	// ErrNoStoredText means the document has no stored text to analyze again.
+	ErrNoStoredText = errors.New("document has no stored text")
+)
+
 // concrete implementation (not exported)
 type analyzerService struct {
 	analysisRepo repositories.AnalysisRepository
-	pyClient     httpclient.PythonClient
+	backends     *httpclient.Registry
 	fileOpener   FileOpener
+	limiter      *scheduler.Scheduler
+	scanner      scanner.Scanner
+	quarantine   repositories.QuarantineRepository
+	blobs        blobstore.BlobStore
+	embeddings   repositories.EmbeddingsRepository
+	chunks       repositories.ChunksRepository
+	batches      repositories.BatchesRepository
+	versions     *modelVersions
+}
+
+// modelVersions caches each backend's current model version; "" means it does not report one.
+type modelVersions struct {
+	mu      sync.Mutex
+	entries map[string]modelVersionEntry
+}
+
+type modelVersionEntry struct {
+	version string
+	fetched time.Time
+}
+
+// AnalyzerOption wires optional collaborators into NewAnalyzerServiceWithBackends / NewAnalyzerServiceFull.
+type AnalyzerOption func(*analyzerService)
+
+// WithScanner scans every upload before reuse or backend calls; rejections are recorded in quarantine.
+func WithScanner(sc scanner.Scanner, quarantine repositories.QuarantineRepository) AnalyzerOption {
+	return func(s *analyzerService) {
+		s.scanner = sc
+		s.quarantine = quarantine
+	}
+}
+
+// WithBlobStore keeps the original upload bytes, keyed by content hash.
+func WithBlobStore(blobs blobstore.BlobStore) AnalyzerOption {
+	return func(s *analyzerService) { s.blobs = blobs }
+}
+
+// WithEmbeddings stores a default-backend embedding for every newly analyzed document.
+func WithEmbeddings(embeddings repositories.EmbeddingsRepository) AnalyzerOption {
+	return func(s *analyzerService) { s.embeddings = embeddings }
+}
+
+// WithChunks stores retrieval passages for every newly analyzed document (collection Q&A).
+func WithChunks(chunks repositories.ChunksRepository) AnalyzerOption {
+	return func(s *analyzerService) { s.chunks = chunks }
+}
+
+// WithBatches records the failed files of batched uploads.
+func WithBatches(batches repositories.BatchesRepository) AnalyzerOption {
+	return func(s *analyzerService) { s.batches = batches }
+}
+
+func newAnalyzerService(repo repositories.AnalysisRepository, backends *httpclient.Registry, opener FileOpener, opts []AnalyzerOption) *analyzerService {
+	if opener == nil {
+		opener = defaultFileOpener{}
+	}
+	s := &analyzerService{analysisRepo: repo, backends: backends, fileOpener: opener, limiter: scheduler.Shared(), scanner: scanner.Noop(), versions: &modelVersions{entries: map[string]modelVersionEntry{}}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func defaultRegistry() *httpclient.Registry {
+	return singleBackend(httpclient.NewPythonClient(config.PythonServiceURL, config.HTTPClientTimeout))
+}
+
+func singleBackend(py httpclient.PythonClient) *httpclient.Registry {
+	return httpclient.NewRegistry(httpclient.DefaultBackend, py)
 }
 
 // Factory helpers (tiered for differing injection depth: prod vs tests)
 func NewAnalyzerService() AnalyzerServiceInterface {
-	return &analyzerService{analysisRepo: repositories.NewAnalysisRepository(), pyClient: httpclient.NewPythonClient(config.PythonServiceURL, config.HTTPClientTimeout), fileOpener: defaultFileOpener{}}
+	return newAnalyzerService(repositories.NewAnalysisRepository(), defaultRegistry(), nil, nil)
 }
 func NewAnalyzerServiceWithRepo(repo repositories.AnalysisRepository) AnalyzerServiceInterface {
-	return &analyzerService{analysisRepo: repo, pyClient: httpclient.NewPythonClient(config.PythonServiceURL, config.HTTPClientTimeout), fileOpener: defaultFileOpener{}}
+	return newAnalyzerService(repo, defaultRegistry(), nil, nil)
+}
+func NewAnalyzerServiceWithBackends(repo repositories.AnalysisRepository, backends *httpclient.Registry, opts ...AnalyzerOption) AnalyzerServiceInterface {
+	return newAnalyzerService(repo, backends, nil, opts)
 }
 func NewAnalyzerServiceWithDeps(repo repositories.AnalysisRepository, py httpclient.PythonClient) AnalyzerServiceInterface {
-	return &analyzerService{analysisRepo: repo, pyClient: py, fileOpener: defaultFileOpener{}}
+	return newAnalyzerService(repo, singleBackend(py), nil, nil)
 }
-func NewAnalyzerServiceFull(repo repositories.AnalysisRepository, py httpclient.PythonClient, opener FileOpener) AnalyzerServiceInterface {
-	if opener == nil {
-		opener = defaultFileOpener{}
-	}
-	return &analyzerService{analysisRepo: repo, pyClient: py, fileOpener: opener}
+func NewAnalyzerServiceFull(repo repositories.AnalysisRepository, py httpclient.PythonClient, opener FileOpener, opts ...AnalyzerOption) AnalyzerServiceInterface {
+	return newAnalyzerService(repo, singleBackend(py), opener, opts)
+}
+
+func (s *analyzerService) HasBackend(name string) bool { return s.backends.Has(name) }
+
+func fileExt(name string) string { return strings.ToLower(path.Ext(name)) }
+
+func isSupportedFile(name string) bool {
+	return slices.Contains(config.SupportedFileTypes, fileExt(name))
 }
 
-// bufferAndHash reads the stream once producing both full bytes and a sha256 hex hash.
-func bufferAndHash(r io.Reader) ([]byte, string, error) {
-	var buf bytes.Buffer
-	tee := io.TeeReader(r, &buf)
+// uploadSource is a seekable, random-access view of one file (multipart.File or bytes.Reader).
+// Stages read it from the start instead of keeping their own copy.
+type uploadSource interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+}
+
+// upload is a file being analyzed together with its size and content hash.
+type upload struct {
+	name string
+	src  uploadSource
+	size int64
+	hash string
+}
+
+// newUpload streams src once through sha256 to compute the content hash and size.
+func newUpload(name string, src uploadSource) (*upload, error) {
 	h := sha256.New()
-	if _, err := io.Copy(h, tee); err != nil {
-		return nil, "", err
+	size, err := io.Copy(h, src)
+	if err != nil {
+		return nil, err
 	}
-	return buf.Bytes(), hex.EncodeToString(h.Sum(nil)), nil
+	return &upload{name: name, src: src, size: size, hash: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// rewind positions the source at the start for another sequential read.
+func (u *upload) rewind() (io.Reader, error) {
+	if _, err := u.src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return u.src, nil
+}
+
+// readAll loads the whole file; only used where the content itself is the payload (.txt/.md).
+func (u *upload) readAll() ([]byte, error) {
+	r, err := u.rewind()
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
 }
 
 // AnalyzeFiles public convenience without external ctx.
@@ -93,6 +251,20 @@ func (s *analyzerService) AnalyzeFiles(files []*multipart.FileHeader, lang strin
 }
 
 func (s *analyzerService) AnalyzeFilesWithContext(ctx context.Context, files []*multipart.FileHeader, lang string, userID string, collectionID *int) []models.AnalysisResult {
+	return s.AnalyzeFilesWithOptions(ctx, files, lang, userID, collectionID, AnalyzeOptions{})
+}
+
+func (s *analyzerService) AnalyzeFilesWithOptions(ctx context.Context, files []*multipart.FileHeader, lang string, userID string, collectionID *int, opts AnalyzeOptions) []models.AnalysisResult {
+	var finalResults []models.AnalysisResult
+	s.AnalyzeFilesStream(ctx, files, lang, userID, collectionID, opts, func(r models.AnalysisResult) {
+		finalResults = append(finalResults, r)
+	})
+	return finalResults
+}
+
+// AnalyzeFilesStream analyzes files in parallel and calls emit as soon as each one finishes.
+// emit is only ever called from the calling goroutine, so it may write to the response directly.
+func (s *analyzerService) AnalyzeFilesStream(ctx context.Context, files []*multipart.FileHeader, lang string, userID string, collectionID *int, opts AnalyzeOptions, emit func(models.AnalysisResult)) models.BatchSummary {
 	var wg sync.WaitGroup
 	resultsChan := make(chan models.AnalysisResult, len(files))
 	var batchID *string
@@ -108,102 +280,636 @@ func (s *analyzerService) AnalyzeFilesWithContext(ctx context.Context, files []*
 		wg.Add(1)
 		go func(fh *multipart.FileHeader) {
 			defer wg.Done()
-			resultsChan <- s.analyzeSingleFile(ctx, fh, lang, userID, collectionID, batchID, batchSize)
+			resultsChan <- s.analyzeSingleFile(ctx, fh, lang, userID, collectionID, batchID, batchSize, opts)
 		}(file)
 	}
 
-	// Wait for all goroutines to finish.
-	wg.Wait()
-	close(resultsChan)
+	// Close the channel once every goroutine has finished.
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
 
-	// Collect all results from the channel.
-	var finalResults []models.AnalysisResult
+	// Forward results in completion order.
+	summary := models.BatchSummary{BatchID: batchID, Total: len(files)}
 	for r := range resultsChan {
-		finalResults = append(finalResults, r)
+		switch {
+		case r.Error != "":
+			summary.Failed++
+		case r.Reused:
+			summary.Succeeded++
+			summary.Reused++
+		default:
+			summary.Succeeded++
+		}
+		emit(r)
 	}
-	return finalResults
+	return summary
 }
 
 // analyzeSingleFile encapsulates per-file branching (unsupported, reuse, remote new, failure).
-func (s *analyzerService) analyzeSingleFile(ctx context.Context, fileHeader *multipart.FileHeader, lang string, userID string, collectionID *int, batchID *string, batchSize *int) models.AnalysisResult {
+func (s *analyzerService) analyzeSingleFile(ctx context.Context, fileHeader *multipart.FileHeader, lang string, userID string, collectionID *int, batchID *string, batchSize *int, opts AnalyzeOptions) models.AnalysisResult {
 	start := time.Now()
 	cid := utils.CorrelationIDFromCtx(ctx)
+	fail := func(key string) models.AnalysisResult {
+		r := failedResult(fileHeader.Filename, lang, key)
+		s.recordFailure(ctx, r, nil, userID, collectionID, batchID, batchSize, opts)
+		return r
+	}
 
-	// Validate file type
-	ext := strings.ToLower(path.Ext(fileHeader.Filename))
-	if !slices.Contains(config.SupportedFileTypes, ext) {
-		log.Info().Str("cid", cid).Str("file", fileHeader.Filename).Str("ext", ext).Msg("skip unsupported file type")
-		return models.AnalysisResult{FileName: fileHeader.Filename, Error: i18n.GetMessage(lang, "UnsupportedFileType")}
+	if !isSupportedFile(fileHeader.Filename) {
+		log.Info().Str("cid", cid).Str("file", fileHeader.Filename).Str("ext", fileExt(fileHeader.Filename)).Msg("skip unsupported file type")
+		return fail("UnsupportedFileType")
 	}
 
 	f, err := s.fileOpener.Open(fileHeader)
 	if err != nil {
 		log.Error().Str("cid", cid).Str("file", fileHeader.Filename).Err(err).Msg("open file error")
-		return models.AnalysisResult{FileName: fileHeader.Filename, Error: i18n.GetMessage(lang, "InternalError")}
+		return fail("InternalError")
 	}
 	defer f.Close()
 
-	origBytes, contentHash, err := bufferAndHash(f)
+	u, err := newUpload(fileHeader.Filename, f)
 	if err != nil {
 		log.Error().Str("cid", cid).Str("file", fileHeader.Filename).Err(err).Msg("hash file error")
-		return models.AnalysisResult{FileName: fileHeader.Filename, Error: i18n.GetMessage(lang, "InternalError")}
+		return fail("InternalError")
+	}
+
+	result := s.analyzeUpload(ctx, start, u, lang, userID, collectionID, batchID, batchSize, opts)
+	s.recordFailure(ctx, result, u, userID, collectionID, batchID, batchSize, opts)
+	return result
+}
+
+// AnalyzeContent runs the single-file pipeline on bytes that were already read (e.g. by the job workers).
+func (s *analyzerService) AnalyzeContent(ctx context.Context, fileName string, content []byte, lang string, userID string, collectionID *int, batchID *string, batchSize *int, opts AnalyzeOptions) models.AnalysisResult {
+	sum := sha256.Sum256(content)
+	u := &upload{name: fileName, src: bytes.NewReader(content), size: int64(len(content)), hash: hex.EncodeToString(sum[:])}
+	result := s.analyzeKept(ctx, time.Now(), u, lang, userID, collectionID, batchID, batchSize, opts)
+	s.recordFailure(ctx, result, u, userID, collectionID, batchID, batchSize, opts)
+	return result
+}
+
+// analyzeKept runs the pipeline on a file kept since its upload (job content or a retried upload).
+// Failures are not recorded here: a retried file already has its failure.
+func (s *analyzerService) analyzeKept(ctx context.Context, start time.Time, u *upload, lang string, userID string, collectionID *int, batchID *string, batchSize *int, opts AnalyzeOptions) models.AnalysisResult {
+	var result models.AnalysisResult
+	if isSupportedFile(u.name) {
+		result = s.analyzeUpload(ctx, start, u, lang, userID, collectionID, batchID, batchSize, opts)
+	} else {
+		log.Info().Str("cid", utils.CorrelationIDFromCtx(ctx)).Str("file", u.name).Str("ext", fileExt(u.name)).Msg("skip unsupported file type")
+		result = failedResult(u.name, lang, "UnsupportedFileType")
+	}
+	return result
+}
+
+// ScanUpload runs the malware scan of the pipeline on a file that is about to be kept for later
+// analysis (job submissions). A rejected file gets its final result now and is recorded as a
+// failure of its batch like any other; nil means the file may be stored.
+func (s *analyzerService) ScanUpload(ctx context.Context, fileName string, f multipart.File, lang string, userID string, collectionID *int, batchID *string, batchSize *int, opts AnalyzeOptions) *models.AnalysisResult {
+	u, err := newUpload(fileName, f)
+	if err != nil {
+		log.Error().Str("cid", utils.CorrelationIDFromCtx(ctx)).Str("file", fileName).Err(err).Msg("hash file error")
+		r := failedResult(fileName, lang, "InternalError")
+		s.recordFailure(ctx, r, nil, userID, collectionID, batchID, batchSize, opts)
+		return &r
+	}
+	rejected := s.scan(ctx, u, lang, userID)
+	if rejected != nil {
+		s.recordFailure(ctx, *rejected, u, userID, collectionID, batchID, batchSize, opts)
+	}
+	return rejected
+}
+
+// failedResult is the result of a file that produced no analysis.
+func failedResult(fileName, lang, key string) models.AnalysisResult {
+	return models.AnalysisResult{FileName: fileName, Error: i18n.GetMessage(lang, key), ErrorKey: key}
+}
+
+// retryableErrors are the transient failures worth analyzing again; the upload of a file failing
+// with one of them is kept for RetryBatch. Rejected or unreadable content is never kept.
+var retryableErrors = map[string]bool{
+	"PythonServiceUnavailable": true,
+	"AnalysisCancelled":        true,
+	"ScanUnavailable":          true,
+	"InternalError":            true,
+}
+
+// recordFailure keeps a failed file of a batch for GET /batches/:id. u is nil when the file could
+// not be read. Single-file uploads and successes are not recorded; storage errors are only logged.
+func (s *analyzerService) recordFailure(ctx context.Context, result models.AnalysisResult, u *upload, userID string, collectionID *int, batchID *string, batchSize *int, opts AnalyzeOptions) {
+	if s.batches == nil || batchID == nil || result.ErrorKey == "" {
+		return
+	}
+	cid := utils.CorrelationIDFromCtx(ctx)
+	f := models.AnalysisFailure{UserID: userID, BatchID: *batchID, BatchSize: *batchSize, CollectionID: collectionID, FileName: result.FileName, ErrorKey: result.ErrorKey, Backend: opts.Backend, Force: opts.Force, CorrelationID: cid}
+	if u != nil {
+		f.ContentHash = u.hash
+		f.ContentStored = retryableErrors[result.ErrorKey] && s.blobs != nil
+	}
+	if err := s.batches.RecordFailure(f); err != nil {
+		log.Error().Str("cid", cid).Str("file", result.FileName).Str("batch", *batchID).Err(err).Msg("record batch failure error")
+		return
+	}
+	// The row references the hash before the blob is written, so a concurrent deletion of the same
+	// content cannot drop it in between; if storing fails, the retry reports OriginalNotAvailable.
+	// The request may be over (cancelled analysis): keeping the upload must still succeed.
+	if f.ContentStored {
+		s.storeOriginal(context.WithoutCancel(ctx), u)
+	}
+}
+
+// analyzeUpload covers the reuse and remote paths once the file size and hash are known.
+func (s *analyzerService) analyzeUpload(ctx context.Context, start time.Time, u *upload, lang string, userID string, collectionID *int, batchID *string, batchSize *int, opts AnalyzeOptions) models.AnalysisResult {
+	cid := utils.CorrelationIDFromCtx(ctx)
+	fileName, contentHash := u.name, u.hash
+
+	if !opts.Scanned {
+		if rejected := s.scan(ctx, u, lang, userID); rejected != nil {
+			return *rejected
+		}
+	}
+
+	ext := fileExt(fileName)
+	detected := sniff.Detect(u.src, u.size)
+	if !sniff.Matches(ext, detected) {
+		log.Warn().Str("cid", cid).Str("file", fileName).Str("ext", ext).Str("detectedType", detected).Msg("file content does not match extension")
+		return failedResult(fileName, lang, "FileContentMismatch")
 	}
 
 	// Reuse path (only if a valid docID was found and existing analysis exists)
-	if docID, err := s.analysisRepo.FindDocument(userID, collectionID, contentHash); err == nil && docID > 0 {
-		if existing, err2 := s.analysisRepo.GetLatestAnalysisByDocument(userID, docID); err2 == nil && existing != nil {
-			_, _ = s.analysisRepo.InsertAnalysis(userID, docID, existing.Summary, existing.Keywords, existing.Sentiment, existing.SummaryPoints, batchID, batchSize)
-			log.Info().Str("cid", cid).Str("file", fileHeader.Filename).Bool("reused", true).Dur("duration", time.Since(start)).Msg("analysis reused")
-			return models.AnalysisResult{FileName: fileHeader.Filename, Reused: true, Data: &models.AnalysisResponse{Summary: existing.Summary, Keywords: existing.Keywords, Sentiment: existing.Sentiment, FullText: existing.FullText, SummaryPoints: existing.SummaryPoints}}
+	docID, err := s.analysisRepo.FindDocument(userID, collectionID, contentHash)
+	if err != nil {
+		docID = 0
+	}
+	if docID > 0 && !opts.Force {
+		route := httpclient.RouteRequest{Explicit: opts.Backend, FileName: fileName, CollectionID: collectionID, UserID: userID}
+		if existing, err2 := s.analysisRepo.GetLatestAnalysisByDocument(userID, docID); err2 == nil && existing != nil && s.reusable(ctx, existing, route) {
+			s.storeOriginal(ctx, u)
+			_, _ = s.analysisRepo.InsertReusedAnalysis(userID, existing.AnalysisID, batchID, batchSize)
+			log.Info().Str("cid", cid).Str("file", fileName).Bool("reused", true).Dur("duration", time.Since(start)).Msg("analysis reused")
+			return models.AnalysisResult{FileName: fileName, Reused: true, Backend: existing.Backend, Data: &models.AnalysisResponse{Summary: existing.Summary, Keywords: existing.Keywords, Sentiment: existing.Sentiment, FullText: existing.FullText, SummaryPoints: existing.SummaryPoints, Meta: existing.Meta}}
+		}
+	}
+
+	// Plain text and Markdown are extracted here; other formats are parsed by the backend
+	var text string
+	if extract.Supported(ext) {
+		raw, err := u.readAll()
+		if err != nil {
+			log.Error().Str("cid", cid).Str("file", fileName).Err(err).Msg("read text file error")
+			return failedResult(fileName, lang, "InternalError")
+		}
+		t, err := extract.Text(raw, ext, extract.Options{StripMarkdown: config.ExtractStripMarkdown})
+		if err != nil {
+			key := "EmptyDocument"
+			if errors.Is(err, extract.ErrBinary) {
+				key = "BinaryContent"
+			}
+			log.Info().Str("cid", cid).Str("file", fileName).Err(err).Msg("text extraction rejected file")
+			return failedResult(fileName, lang, key)
+		}
+		text = t
+	}
+
+	out, backend, waited, err := s.runBackend(ctx, start, userID, fileName, collectionID, text, u.src, opts)
+	if err != nil {
+		return failedResult(fileName, lang, analysisErrorKey(err))
+	}
+
+	analysisData := models.AnalysisResponse{Summary: out.Summary, Keywords: out.Keywords, Sentiment: out.Sentiment, FullText: out.FullText, SummaryPoints: out.SummaryPoints, Meta: out.Meta}
+	if out.FullText != "" {
+		// A forced or outdated re-analysis keeps the document and stores its next version
+		if docID > 0 {
+			err = s.analysisRepo.UpdateDocumentText(userID, docID, out.FullText)
+		} else {
+			docID, err = s.analysisRepo.InsertDocument(userID, collectionID, fileName, out.FullText, contentHash, ext, detected)
+		}
+		if err == nil {
+			// Stored once the document references the hash (see recordFailure)
+			s.storeOriginal(ctx, u)
+			_, err = s.analysisRepo.InsertAnalysis(userID, docID, out.Summary, out.Keywords, out.Sentiment, out.SummaryPoints, batchID, batchSize, backend, out.Meta)
+			s.embedDocument(ctx, userID, docID, out.FullText)
+			s.chunkDocument(ctx, userID, docID, out.FullText)
+		}
+		if err != nil {
+			log.Error().Str("cid", cid).Str("file", fileName).Err(err).Msg("store analysis error")
+		}
+	}
+	log.Info().Str("cid", cid).Str("file", fileName).Str("ext", ext).Str("detectedType", detected).Str("backend", backend).Bool("reused", false).Bool("force", opts.Force).Dur("queued", waited).Dur("duration", time.Since(start)).Msg("analysis complete")
+	return models.AnalysisResult{FileName: fileName, Backend: backend, Data: &analysisData}
+}
+
+// ReanalyzeDocument analyzes the stored text of a document again and stores the result as its next
+// version; the original upload is not needed.
+func (s *analyzerService) ReanalyzeDocument(ctx context.Context, userID string, documentID int, opts AnalyzeOptions) (*models.AnalysisDetail, error) {
+	start := time.Now()
+	cid := utils.CorrelationIDFromCtx(ctx)
+	latest, err := s.analysisRepo.GetLatestAnalysisByDocument(userID, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(latest.FullText) == "" {
+		return nil, ErrNoStoredText
+	}
+	out, backend, waited, err := s.runBackend(ctx, start, userID, latest.FileName, latest.CollectionID, latest.FullText, nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.analysisRepo.InsertAnalysis(userID, documentID, out.Summary, out.Keywords, out.Sentiment, out.SummaryPoints, nil, nil, backend, out.Meta); err != nil {
+		return nil, err
+	}
+	log.Info().Str("cid", cid).Int("document", documentID).Str("backend", backend).Dur("queued", waited).Dur("duration", time.Since(start)).Msg("document re-analyzed")
+	return s.analysisRepo.GetLatestAnalysisByDocument(userID, documentID)
+}
+
+// RetryBatch analyzes again the failed files of a batch whose upload was kept, with the options
+// of the original request, and attaches the outcome to the same batch. The result is the whole
+// batch: earlier analyses, then the failures that cannot be retried, then the retried files. A
+// transient failure whose upload was not kept is reported as OriginalNotAvailable, since retrying
+// it can never succeed. sql.ErrNoRows when the user has no such batch.
+func (s *analyzerService) RetryBatch(ctx context.Context, userID, batchID, lang string) ([]models.AnalysisResult, error) {
+	if s.batches == nil {
+		return nil, sql.ErrNoRows
+	}
+	cid := utils.CorrelationIDFromCtx(ctx)
+	batch, err := s.batches.GetBatch(userID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	// Claims of a crashed retry expire like the leases of job files
+	claimed, err := s.batches.ClaimRetryableFailures(userID, batchID, config.JobLeaseTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.AnalysisResult, 0, batch.FileCount)
+	for _, a := range batch.Analyses {
+		results = append(results, models.AnalysisResult{FileName: a.FileName, Backend: a.Backend, Data: &models.AnalysisResponse{Summary: a.Summary, Keywords: a.Keywords, Sentiment: a.Sentiment, SummaryPoints: a.SummaryPoints}})
+	}
+	retrying := make(map[int]bool, len(claimed))
+	for _, f := range claimed {
+		retrying[f.ID] = true
+	}
+	for _, f := range batch.Failures {
+		if retrying[f.ID] {
+			continue
+		}
+		key := f.ErrorKey
+		if retryableErrors[key] && !f.Retryable {
+			key = "OriginalNotAvailable"
 		}
+		results = append(results, failedResult(f.FileName, lang, key))
 	}
 
-	// Remote analyze
-	resp, err := s.pyClient.AnalyzeWithCtx(ctx, origBytes, fileHeader.Filename, cid)
+	retried := make([]models.AnalysisResult, len(claimed))
+	var wg sync.WaitGroup
+	for i, f := range claimed {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			retried[i] = s.retryFailure(ctx, f, lang)
+		}()
+	}
+	wg.Wait()
+	log.Info().Str("cid", cid).Str("batch", batchID).Int("retried", len(claimed)).Msg("batch retried")
+
+	s.releaseRetryContent(ctx, claimed)
+	return append(results, retried...), nil
+}
+
+// retryFailure runs a claimed failure through the pipeline again from its kept upload. The failure
+// is resolved only when the file is analyzed; otherwise its claim is released with the new error.
+func (s *analyzerService) retryFailure(ctx context.Context, f models.AnalysisFailure, lang string) models.AnalysisResult {
+	cid := utils.CorrelationIDFromCtx(ctx)
+	batchID, batchSize := f.BatchID, f.BatchSize
+	opts := AnalyzeOptions{Backend: f.Backend, Force: f.Force}
+	if opts.Backend != "" && !s.HasBackend(opts.Backend) {
+		opts.Backend = "" // removed from the configuration since: let the routing rules decide
+	}
+
+	var result models.AnalysisResult
+	src, closeSrc, err := s.openOriginal(ctx, f.ContentHash)
+	var u *upload
+	if err == nil {
+		defer closeSrc()
+		u, err = newUpload(f.FileName, src)
+	}
+	switch {
+	case errors.Is(err, blobstore.ErrNotFound):
+		log.Warn().Str("cid", cid).Str("file", f.FileName).Str("hash", f.ContentHash).Msg("kept upload is gone, failure can no longer be retried")
+		result = failedResult(f.FileName, lang, "OriginalNotAvailable")
+	case err != nil:
+		log.Error().Str("cid", cid).Str("file", f.FileName).Str("hash", f.ContentHash).Err(err).Msg("read kept upload error")
+		result = failedResult(f.FileName, lang, "InternalError")
+	default:
+		result = s.analyzeKept(ctx, time.Now(), u, lang, f.UserID, f.CollectionID, &batchID, &batchSize, opts)
+	}
+
+	if result.ErrorKey == "" {
+		err = s.batches.ResolveFailure(f.ID)
+	} else {
+		err = s.batches.ReleaseFailure(f.ID, result.ErrorKey, retryableErrors[result.ErrorKey])
+	}
 	if err != nil {
+		log.Error().Str("cid", cid).Str("file", f.FileName).Str("batch", batchID).Err(err).Msg("finish batch failure retry error")
+	}
+	return result
+}
+
+// openOriginal opens a kept upload for the pipeline without loading it into memory: blobs that are
+// already seekable files (local store) are used as they are, others are spilled to a temp file.
+func (s *analyzerService) openOriginal(ctx context.Context, hash string) (uploadSource, func(), error) {
+	if s.blobs == nil {
+		return nil, nil, blobstore.ErrNotFound
+	}
+	rc, _, err := s.blobs.Get(ctx, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if src, ok := rc.(uploadSource); ok {
+		return src, func() { rc.Close() }, nil
+	}
+	defer rc.Close()
+	tmp, err := os.CreateTemp("", "retry-upload-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	if _, err := io.Copy(tmp, rc); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return tmp, cleanup, nil
+}
+
+// releaseRetryContent deletes the kept uploads of retried files that are no longer needed: a
+// successful retry is referenced by its document, a transient failure still keeps its own.
+func (s *analyzerService) releaseRetryContent(ctx context.Context, retried []models.AnalysisFailure) {
+	if s.blobs == nil || len(retried) == 0 {
+		return
+	}
+	cid := utils.CorrelationIDFromCtx(ctx)
+	hashes := make([]string, 0, len(retried))
+	for _, f := range retried {
+		hashes = append(hashes, f.ContentHash)
+	}
+	orphaned, err := s.batches.UnreferencedHashes(hashes)
+	if err != nil {
+		log.Error().Str("cid", cid).Err(err).Msg("find unreferenced uploads error")
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	for _, key := range orphaned {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			log.Error().Str("cid", cid).Str("hash", key).Err(err).Msg("blob delete error")
+		}
+	}
+}
+
+// runBackend waits for an outbound slot (a client disconnect cancels the wait), routes the file to
+// a backend and decodes its analysis. Text goes to the text endpoint, otherwise src is uploaded.
+// Failures are logged here and returned as ErrAnalysisCancelled, ErrBackendUnsupported,
+// ErrBackendRejected, ErrBackendUnavailable or ErrMalformedAnalysis.
+func (s *analyzerService) runBackend(ctx context.Context, start time.Time, userID, fileName string, collectionID *int, text string, src io.ReadSeeker, opts AnalyzeOptions) (*models.AnalysisResponse, string, time.Duration, error) {
+	cid := utils.CorrelationIDFromCtx(ctx)
+	queuedAt := time.Now()
+	release, err := s.limiter.Acquire(ctx, userID)
+	if err != nil {
+		log.Info().Str("cid", cid).Str("file", fileName).Err(err).Dur("queued", time.Since(queuedAt)).Msg("analysis cancelled while queued")
+		return nil, "", 0, fmt.Errorf("%w: %v", ErrAnalysisCancelled, err)
+	}
+	defer release()
+	waited := time.Since(queuedAt)
+
+	backend, client := s.backends.Route(httpclient.RouteRequest{Explicit: opts.Backend, FileName: fileName, CollectionID: collectionID, UserID: userID})
+	var resp *http.Response
+	if text != "" {
+		resp, err = client.AnalyzeTextWithCtx(ctx, text, cid)
+	} else {
+		resp, err = client.AnalyzeWithCtx(ctx, src, fileName, cid)
+	}
+	if errors.Is(err, httpclient.ErrUnsupported) {
+		log.Info().Str("cid", cid).Str("file", fileName).Str("backend", backend).Err(err).Msg("backend cannot analyze file")
+		return nil, backend, waited, fmt.Errorf("%w: %v", ErrBackendUnsupported, err)
+	}
+	if err != nil {
+		// Bad statuses come with the response: only its status is used
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+			resp.Body.Close()
+		}
 		errType := "python_unavailable"
-		if errors.Is(err, httpclient.ErrBadStatus) {
+		switch {
+		case errors.Is(err, httpclient.ErrBadStatus):
 			errType = "python_bad_status"
+		case errors.Is(err, httpclient.ErrCircuitOpen):
+			errType = "python_circuit_open"
+		}
+		log.Error().Str("cid", cid).Str("file", fileName).Str("backend", backend).Str("errorType", errType).Int("status", status).Err(err).Dur("duration", time.Since(start)).Msg("python analyze error")
+		if status >= http.StatusBadRequest && status < http.StatusInternalServerError {
+			return nil, backend, waited, fmt.Errorf("%w: status %d", ErrBackendRejected, status)
 		}
-		log.Error().Str("cid", cid).Str("file", fileHeader.Filename).Str("errorType", errType).Err(err).Dur("duration", time.Since(start)).Msg("python analyze error")
-		return models.AnalysisResult{FileName: fileHeader.Filename, Error: i18n.GetMessage(lang, "PythonServiceUnavailable")}
+		return nil, backend, waited, fmt.Errorf("%w: %v", ErrBackendUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	var out models.AnalysisResponse
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		log.Error().Str("cid", cid).Str("file", fileHeader.Filename).Err(err).Dur("duration", time.Since(start)).Msg("decode python response error")
-		return models.AnalysisResult{FileName: fileHeader.Filename, Error: i18n.GetMessage(lang, "InternalError")}
+		log.Error().Str("cid", cid).Str("file", fileName).Err(err).Dur("duration", time.Since(start)).Msg("decode python response error")
+		return nil, backend, waited, fmt.Errorf("%w: %v", ErrMalformedAnalysis, err)
 	}
+	return &out, backend, waited, nil
+}
 
-	analysisData := models.AnalysisResponse{Summary: out.Summary, Keywords: out.Keywords, Sentiment: out.Sentiment, FullText: out.FullText, SummaryPoints: out.SummaryPoints}
-	if out.FullText != "" {
-		if docID, err := s.analysisRepo.InsertDocument(userID, collectionID, fileHeader.Filename, out.FullText, contentHash); err == nil {
-			_, _ = s.analysisRepo.InsertAnalysis(userID, docID, out.Summary, out.Keywords, out.Sentiment, out.SummaryPoints, batchID, batchSize)
+// analysisErrorKey maps a runBackend error to the message shown for the file.
+func analysisErrorKey(err error) string {
+	switch {
+	case errors.Is(err, ErrAnalysisCancelled):
+		return "AnalysisCancelled"
+	case errors.Is(err, ErrBackendUnsupported):
+		return "UnsupportedFileType"
+	case errors.Is(err, ErrBackendRejected):
+		return "AnalysisRejected"
+	case errors.Is(err, ErrBackendUnavailable):
+		return "PythonServiceUnavailable"
+	default:
+		return "InternalError"
+	}
+}
+
+// reusable reports whether a stored analysis was produced by the models the file would be analyzed
+// with now. Backends that do not report a model version keep the earlier behaviour (always reuse);
+// analyses stored without one are treated as outdated.
+func (s *analyzerService) reusable(ctx context.Context, existing *models.AnalysisDetail, route httpclient.RouteRequest) bool {
+	backend, client := s.backends.Route(route)
+	current := s.currentModelVersion(ctx, backend, client)
+	if current == "" {
+		return true
+	}
+	var stored string
+	if existing.Meta != nil {
+		stored = existing.Meta.ModelVersion
+	}
+	if stored == current {
+		return true
+	}
+	log.Info().Str("cid", utils.CorrelationIDFromCtx(ctx)).Str("file", route.FileName).Int("document", existing.DocumentID).Str("backend", backend).Str("storedModelVersion", stored).Str("modelVersion", current).Msg("stored analysis is from other models, analyzing again")
+	return false
+}
+
+// currentModelVersion returns the backend's model version, asking it at most once per
+// config.ModelVersionTTL. Failures are cached as "" (unknown) for the same time, except when
+// the request itself was cancelled.
+func (s *analyzerService) currentModelVersion(ctx context.Context, backend string, client httpclient.PythonClient) string {
+	s.versions.mu.Lock()
+	entry, ok := s.versions.entries[backend]
+	s.versions.mu.Unlock()
+	if ok && time.Since(entry.fetched) < config.ModelVersionTTL {
+		return entry.version
+	}
+
+	cid := utils.CorrelationIDFromCtx(ctx)
+	var current models.BackendModels
+	resp, err := client.ModelsWithCtx(ctx, cid)
+	if err == nil {
+		err = json.NewDecoder(resp.Body).Decode(&current)
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return ""
+		}
+		log.Warn().Str("cid", cid).Str("backend", backend).Err(err).Msg("backend model version unavailable, stored analyses are reused unchecked")
+		current.ModelVersion = ""
+	}
+
+	s.versions.mu.Lock()
+	s.versions.entries[backend] = modelVersionEntry{version: current.ModelVersion, fetched: time.Now()}
+	s.versions.mu.Unlock()
+	return current.ModelVersion
+}
+
+// storeOriginal keeps the upload for later download (or retry). Callers first record the row that
+// references the hash. Failures only cost the download, so they are logged and the analysis goes on.
+func (s *analyzerService) storeOriginal(ctx context.Context, u *upload) {
+	if s.blobs == nil {
+		return
+	}
+	r, err := u.rewind()
+	if err == nil {
+		err = s.blobs.Put(ctx, u.hash, r, u.size)
+	}
+	if err != nil {
+		log.Error().Str("cid", utils.CorrelationIDFromCtx(ctx)).Str("file", u.name).Err(err).Msg("store original upload error")
+	}
+}
+
+// embedDocument refreshes the document's embedding. Vectors always come from the default backend
+// so they stay comparable; failures only cost the "related documents" entry and are logged.
+func (s *analyzerService) embedDocument(ctx context.Context, userID string, docID int, text string) {
+	if s.embeddings == nil {
+		return
+	}
+	cid := utils.CorrelationIDFromCtx(ctx)
+	_, client := s.backends.Default()
+	resp, err := client.EmbedWithCtx(ctx, text, cid)
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
 		}
+		log.Error().Str("cid", cid).Int("document", docID).Err(err).Msg("embed document error")
+		return
+	}
+	defer resp.Body.Close()
+	var out models.EmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || len(out.Embedding) == 0 {
+		log.Error().Str("cid", cid).Int("document", docID).Err(err).Msg("decode embedding error")
+		return
+	}
+	if err := s.embeddings.UpsertEmbedding(userID, docID, out.Model, out.Embedding); err != nil {
+		log.Error().Str("cid", cid).Int("document", docID).Err(err).Msg("store embedding error")
 	}
-	log.Info().Str("cid", cid).Str("file", fileHeader.Filename).Bool("reused", false).Dur("duration", time.Since(start)).Msg("analysis complete")
-	return models.AnalysisResult{FileName: fileHeader.Filename, Data: &analysisData}
 }
 
-// Quiz generation: simple proxy (no persistence / reuse path).
-func (s *analyzerService) GenerateQuiz(text string, lang string) (*models.QuizResponse, error) {
-	return s.GenerateQuizWithContext(context.Background(), text, lang)
+// chunkDocument stores the document's passages; on failure the document is chunked again the
+// first time its collection is asked a question.
+func (s *analyzerService) chunkDocument(ctx context.Context, userID string, docID int, text string) {
+	if s.chunks == nil {
+		return
+	}
+	if err := s.chunks.ReplaceChunks(userID, docID, chunkText(text)); err != nil {
+		log.Error().Str("cid", utils.CorrelationIDFromCtx(ctx)).Int("document", docID).Err(err).Msg("store chunks error")
+	}
+}
+
+// scan runs the malware scanner; a non-nil result means the file must not go any further.
+func (s *analyzerService) scan(ctx context.Context, u *upload, lang string, userID string) *models.AnalysisResult {
+	cid := utils.CorrelationIDFromCtx(ctx)
+	fileName, contentHash := u.name, u.hash
+	reject := func(key string) *models.AnalysisResult {
+		r := failedResult(fileName, lang, key)
+		return &r
+	}
+	var verdict scanner.Verdict
+	r, err := u.rewind()
+	if err == nil {
+		verdict, err = s.scanner.Scan(ctx, r)
+	}
+	switch {
+	case err != nil && ctx.Err() != nil:
+		return reject("AnalysisCancelled")
+	case err != nil && config.ScannerFailOpen:
+		log.Warn().Str("cid", cid).Str("file", fileName).Str("scanner", s.scanner.Name()).Err(err).Msg("scanner unavailable, accepting file (fail open)")
+		return nil
+	case err != nil:
+		log.Error().Str("cid", cid).Str("file", fileName).Str("scanner", s.scanner.Name()).Err(err).Msg("scanner unavailable")
+		return reject("ScanUnavailable")
+	case verdict.Clean:
+		return nil
+	}
+
+	log.Warn().Str("cid", cid).Str("file", fileName).Str("scanner", s.scanner.Name()).Str("signature", verdict.Signature).Str("hash", contentHash).Msg("upload rejected by malware scanner")
+	if s.quarantine != nil {
+		ev := models.QuarantineEvent{UserID: userID, FileName: fileName, ContentHash: contentHash, Scanner: s.scanner.Name(), Verdict: models.QuarantineInfected, Signature: verdict.Signature, CorrelationID: cid}
+		if err := s.quarantine.RecordEvent(ev); err != nil {
+			log.Error().Str("cid", cid).Str("file", fileName).Err(err).Msg("record quarantine event error")
+		}
+	}
+	return reject("FileRejected")
 }
-func (s *analyzerService) GenerateQuizWithContext(ctx context.Context, text string, lang string) (*models.QuizResponse, error) {
-	requestBody, err := json.Marshal(map[string]string{"text": text})
+
+// Quiz generation: simple proxy (no persistence / reuse path). The response is only decoded here;
+// its shape is checked by the quiz service.
+func (s *analyzerService) GenerateQuiz(text string, lang string, opts models.QuizOptions) (*models.QuizResponse, error) {
+	return s.GenerateQuizWithContext(context.Background(), text, lang, opts)
+}
+func (s *analyzerService) GenerateQuizWithContext(ctx context.Context, text string, lang string, opts models.QuizOptions) (*models.QuizResponse, error) {
+	requestBody, err := json.Marshal(struct {
+		Text string `json:"text"`
+		models.QuizOptions
+	}{Text: text, QuizOptions: opts})
 	if err != nil {
 		return nil, err
 	}
 	cid := utils.CorrelationIDFromCtx(ctx)
-	resp, err := s.pyClient.GenerateQuizWithCtx(ctx, requestBody, cid)
+	_, client := s.backends.Default()
+	resp, err := client.GenerateQuizWithCtx(ctx, requestBody, cid)
 	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
 	var quizResp models.QuizResponse
 	if err := json.NewDecoder(resp.Body).Decode(&quizResp); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrMalformedQuiz, err)
 	}
 	return &quizResp, nil
 }
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/httpclient"
+	"github.com/samusafe/genericapi/internal/insights"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// Collection insights (GET /collections/:id/insights):
+// 1. Computed from the latest analysis of each document: top keywords, sentiments, documents per month.
+// 2. Cached per collection with a fingerprint of its documents and their latest analysis, so adding,
+//    moving or re-analyzing a document makes the next request compute them again.
+// 3. The meta-summary is optional: the document summaries are concatenated and summarized by the
+//    default backend; it is cached with the rest once requested.
+
+type InsightsServiceInterface interface {
+	Insights(ctx context.Context, userID string, collectionID int, withSummary bool) (*models.CollectionInsights, error)
+}
+
+type insightsService struct {
+	repo     repositories.InsightsRepository
+	backends *httpclient.Registry
+}
+
+func NewInsightsService(repo repositories.InsightsRepository, backends *httpclient.Registry) InsightsServiceInterface {
+	return &insightsService{repo: repo, backends: backends}
+}
+
+func (s *insightsService) Insights(ctx context.Context, userID string, collectionID int, withSummary bool) (*models.CollectionInsights, error) {
+	cid := utils.CorrelationIDFromCtx(ctx)
+	fp, err := s.repo.Fingerprint(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	cached, err := s.repo.GetCached(userID, collectionID)
+	switch {
+	case err == nil && cached.Fingerprint == fp && (!withSummary || cached.Summary != nil):
+		out := cached.Insights
+		out.Cached = true
+		if withSummary {
+			out.Summary = *cached.Summary
+		}
+		return &out, nil
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	// A change between the fingerprint and this read only stores insights newer than their
+	// fingerprint: the next request computes them again.
+	docs, err := s.repo.LatestAnalyses(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	out := insights.Compute(collectionID, docs, config.InsightsTopKeywords)
+	entry := models.CachedInsights{Fingerprint: fp, Insights: out}
+	if withSummary {
+		summary, err := s.metaSummary(ctx, docs)
+		if err != nil {
+			return nil, err
+		}
+		entry.Summary = &summary
+		out.Summary = summary
+	}
+	if err := s.repo.SaveCached(userID, collectionID, entry); err != nil {
+		log.Error().Str("cid", cid).Int("collection", collectionID).Err(err).Msg("cache collection insights error")
+	}
+	log.Info().Str("cid", cid).Int("collection", collectionID).Int("documents", out.Documents).Bool("summary", withSummary).Msg("collection insights computed")
+	return &out, nil
+}
+
+// metaSummary summarizes the concatenated document summaries; empty when there are none.
+func (s *insightsService) metaSummary(ctx context.Context, docs []models.InsightsDocument) (string, error) {
+	text := insights.SummaryInput(docs, config.InsightsSummaryMaxChars)
+	if text == "" {
+		return "", nil
+	}
+	_, client := s.backends.Default()
+	resp, err := client.SummarizeWithCtx(ctx, text, utils.CorrelationIDFromCtx(ctx))
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Summary string `json:"summary"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	return out.Summary, nil
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"mime/multipart"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// Job service overview:
+// 1. Submit scans every upload before anything is stored: rejected files are stored with their
+//    final result and no content, the others as raw bytes in Postgres. It returns immediately.
+//    The bytes are held in memory for the insert (and by the worker), which is why job uploads
+//    are capped by JOB_MAX_UPLOAD_BYTES instead of only MAX_UPLOAD_BYTES.
+// 2. A fixed pool of workers claims queued files (FOR UPDATE SKIP LOCKED, so replicas can share the
+//    queue) and runs them through the regular AnalyzeContent pipeline.
+// 3. Results are stored per file as JSON in the same AnalysisResult shape /analyze returns.
+// 4. A worker renews the lease of its file while analyzing it. Files left "running" by a crashed
+//    process are requeued once their lease expires; on graceful shutdown in-flight files are
+//    released back to the queue immediately. Each claim is a new attempt and only the current
+//    attempt may complete or release the file.
+
+// JobServiceInterface exported for handler/service boundary & test mocks.
+type JobServiceInterface interface {
+	Submit(ctx context.Context, files []*multipart.FileHeader, lang string, userID string, collectionID *int, opts AnalyzeOptions) (*models.AnalysisJob, error)
+	Get(userID, jobID string) (*models.AnalysisJob, error)
+	HasBackend(name string) bool
+	Start(ctx context.Context) (wait func())
+}
+
+type jobService struct {
+	repo       repositories.JobsRepository
+	analyzer   AnalyzerServiceInterface
+	fileOpener FileOpener
+	workers    int
+	wake       chan struct{}
+	startOnce  sync.Once
+	wg         sync.WaitGroup
+}
+
+func NewJobService(repo repositories.JobsRepository, analyzer AnalyzerServiceInterface) JobServiceInterface {
+	return NewJobServiceFull(repo, analyzer, nil, config.JobWorkers)
+}
+
+func NewJobServiceFull(repo repositories.JobsRepository, analyzer AnalyzerServiceInterface, opener FileOpener, workers int) JobServiceInterface {
+	if opener == nil {
+		opener = defaultFileOpener{}
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return &jobService{repo: repo, analyzer: analyzer, fileOpener: opener, workers: workers, wake: make(chan struct{}, 1)}
+}
+
+func (s *jobService) Submit(ctx context.Context, files []*multipart.FileHeader, lang string, userID string, collectionID *int, opts AnalyzeOptions) (*models.AnalysisJob, error) {
+	jobID := uuid.New().String()
+	var batchID *string
+	var batchSize *int
+	if len(files) > 1 {
+		size := len(files)
+		batchID, batchSize = &jobID, &size
+	}
+
+	uploads := make([]models.JobUpload, 0, len(files))
+	for _, fh := range files {
+		upload, err := s.readUpload(ctx, fh, lang, userID, collectionID, batchID, batchSize, opts)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+
+	cid := utils.CorrelationIDFromCtx(ctx)
+	if err := s.repo.CreateJob(jobID, userID, lang, cid, collectionID, opts.Backend, opts.Force, uploads); err != nil {
+		return nil, err
+	}
+	log.Info().Str("cid", cid).Str("job", jobID).Int("files", len(uploads)).Msg("analysis job queued")
+
+	// Non-blocking nudge; idle workers also poll, so a dropped signal only adds latency.
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return s.repo.GetJob(userID, jobID)
+}
+
+// readUpload scans one file and reads it for storage unless the scanner rejected it.
+func (s *jobService) readUpload(ctx context.Context, fh *multipart.FileHeader, lang string, userID string, collectionID *int, batchID *string, batchSize *int, opts AnalyzeOptions) (models.JobUpload, error) {
+	f, err := s.fileOpener.Open(fh)
+	if err != nil {
+		return models.JobUpload{}, err
+	}
+	defer f.Close()
+	if rejected := s.analyzer.ScanUpload(ctx, fh.Filename, f, lang, userID, collectionID, batchID, batchSize, opts); rejected != nil {
+		return models.JobUpload{FileName: fh.Filename, Rejected: rejected}, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return models.JobUpload{}, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return models.JobUpload{}, err
+	}
+	return models.JobUpload{FileName: fh.Filename, Content: data}, nil
+}
+
+func (s *jobService) Get(userID, jobID string) (*models.AnalysisJob, error) {
+	return s.repo.GetJob(userID, jobID)
+}
+
+func (s *jobService) HasBackend(name string) bool { return s.analyzer.HasBackend(name) }
+
+// Start launches the worker pool; workers stop when ctx is cancelled.
+// The returned func blocks until every worker has released its in-flight file.
+func (s *jobService) Start(ctx context.Context) (wait func()) {
+	s.startOnce.Do(func() {
+		if n, err := s.repo.RequeueStale(config.JobLeaseTimeout); err != nil {
+			log.Error().Err(err).Msg("requeue stale job files error")
+		} else if n > 0 {
+			log.Info().Int64("files", n).Msg("requeued stale job files")
+		}
+		for i := 0; i < s.workers; i++ {
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.worker(ctx)
+			}()
+		}
+		log.Info().Int("workers", s.workers).Msg("analysis job workers started")
+	})
+	return s.wg.Wait
+}
+
+func (s *jobService) worker(ctx context.Context) {
+	ticker := time.NewTicker(config.JobPollInterval)
+	defer ticker.Stop()
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		task, err := s.repo.ClaimNextFile()
+		if err == nil {
+			s.process(ctx, task)
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Msg("claim job file error")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+			if _, err := s.repo.RequeueStale(config.JobLeaseTimeout); err != nil {
+				log.Error().Err(err).Msg("requeue stale job files error")
+			}
+		}
+	}
+}
+
+func (s *jobService) process(ctx context.Context, task *models.JobFileTask) {
+	var batchID *string
+	var batchSize *int
+	if task.FileCount > 1 {
+		id, sz := task.JobID, task.FileCount
+		batchID, batchSize = &id, &sz
+	}
+
+	fileCtx := utils.WithCorrelationID(ctx, task.CorrelationID)
+	stopRenewing := s.renewLease(task)
+	result := s.analyzer.AnalyzeContent(fileCtx, task.FileName, task.Content, task.Lang, task.UserID, task.CollectionID, batchID, batchSize, AnalyzeOptions{Backend: task.Backend, Force: task.Force, Scanned: true})
+	stopRenewing()
+
+	// Shutting down: the failure is ours, not the file's. Hand it back to the queue.
+	if ctx.Err() != nil {
+		if err := s.repo.ReleaseFile(task.FileID, task.Attempt); err != nil {
+			log.Error().Str("cid", task.CorrelationID).Str("job", task.JobID).Err(err).Msg("release job file error")
+		}
+		return
+	}
+
+	status := models.JobStatusDone
+	if result.Error != "" {
+		status = models.JobStatusFailed
+	}
+	if err := s.repo.CompleteFile(task.FileID, task.Attempt, status, result); errors.Is(err, repositories.ErrClaimLost) {
+		log.Warn().Str("cid", task.CorrelationID).Str("job", task.JobID).Str("file", task.FileName).Msg("job file lease lost, result dropped")
+		return
+	} else if err != nil {
+		log.Error().Str("cid", task.CorrelationID).Str("job", task.JobID).Err(err).Msg("complete job file error")
+		return
+	}
+	log.Info().Str("cid", task.CorrelationID).Str("job", task.JobID).Str("file", task.FileName).Str("status", status).Msg("job file processed")
+}
+
+// renewLease keeps the claimed file's lease fresh (every third of JOB_LEASE_SECONDS) until the
+// returned func is called, so a slow analysis is not requeued as if its worker had crashed.
+func (s *jobService) renewLease(task *models.JobFileTask) (stop func()) {
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(max(config.JobLeaseTimeout/3, 10*time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			err := s.repo.RenewFile(task.FileID, task.Attempt)
+			if errors.Is(err, repositories.ErrClaimLost) {
+				log.Warn().Str("cid", task.CorrelationID).Str("job", task.JobID).Str("file", task.FileName).Msg("job file lease lost while analyzing")
+				return
+			}
+			if err != nil {
+				log.Error().Str("cid", task.CorrelationID).Str("job", task.JobID).Err(err).Msg("renew job file lease error")
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+	"github.com/samusafe/genericapi/internal/chunk"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/httpclient"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// Question answering over a collection (retrieval-augmented):
+// 1. Documents stored before chunking existed are chunked once, on the first question.
+// 2. The best passages for the question are retrieved with full-text search (request language).
+// 3. The passages go to the Python extractive QA endpoint (default backend); its spans are mapped
+//    back to document character offsets for citations.
+
+// ErrNoRelevantContent means no passage of the collection matched the question.
+var ErrNoRelevantContent = errors.New("no relevant content")
+
+type QAServiceInterface interface {
+	Ask(ctx context.Context, userID string, collectionID int, question string, lang string) (*models.AskResult, error)
+}
+
+type qaService struct {
+	chunks   repositories.ChunksRepository
+	backends *httpclient.Registry
+}
+
+func NewQAService(chunks repositories.ChunksRepository, backends *httpclient.Registry) QAServiceInterface {
+	return &qaService{chunks: chunks, backends: backends}
+}
+
+// chunkText cuts a document into the passages stored for retrieval.
+func chunkText(text string) []models.DocumentChunk {
+	parts := chunk.Split(text, config.ChunkChars, config.ChunkOverlap)
+	out := make([]models.DocumentChunk, len(parts))
+	for i, p := range parts {
+		out[i] = models.DocumentChunk{Index: p.Index, Start: p.Start, End: p.End, Content: p.Text}
+	}
+	return out
+}
+
+func (s *qaService) Ask(ctx context.Context, userID string, collectionID int, question string, lang string) (*models.AskResult, error) {
+	cid := utils.CorrelationIDFromCtx(ctx)
+
+	pending, err := s.chunks.ListUnchunkedDocuments(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range pending {
+		if err := s.chunks.ReplaceChunks(userID, doc.ID, chunkText(doc.FullText)); err != nil {
+			return nil, err
+		}
+	}
+
+	passages, err := s.chunks.SearchChunks(userID, collectionID, question, lang, config.AskContextChunks)
+	if err != nil {
+		return nil, err
+	}
+	if len(passages) == 0 {
+		return nil, ErrNoRelevantContent
+	}
+
+	req := models.AnswerRequest{Question: question, Contexts: make([]models.AnswerContext, len(passages))}
+	for i, p := range passages {
+		req.Contexts[i] = models.AnswerContext{ID: i, Text: p.Content}
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	_, client := s.backends.Default()
+	resp, err := client.AnswerWithCtx(ctx, body, cid)
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var out models.AnswerResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	if out.Answer == "" {
+		return nil, ErrNoRelevantContent
+	}
+
+	result := &models.AskResult{Answer: out.Answer, Score: out.Score, Citations: []models.Citation{}}
+	for _, c := range out.Citations {
+		if c.ContextID < 0 || c.ContextID >= len(passages) {
+			continue
+		}
+		p := passages[c.ContextID]
+		text := []rune(p.Content)
+		start, end := max(0, min(c.Start, len(text))), max(0, min(c.End, len(text)))
+		if start >= end {
+			continue
+		}
+		result.Citations = append(result.Citations, models.Citation{DocumentID: p.DocumentID, FileName: p.FileName, Start: p.Start + start, End: p.Start + end, Quote: string(text[start:end])})
+	}
+	log.Info().Str("cid", cid).Int("collection", collectionID).Int("passages", len(passages)).Int("citations", len(result.Citations)).Float64("score", out.Score).Msg("collection question answered")
+	return result, nil
+}
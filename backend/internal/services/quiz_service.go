@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/utils"
+)
+
+// Quiz service overview:
+// - Text quizzes are generated and not stored.
+// - Analysis quizzes take the text from the stored document; the latest stored version is returned
+//   when it was generated with the same options, unless a regeneration is requested. New quizzes
+//   are stored as a new version and the earlier ones are kept.
+// - Every generated quiz is checked against the requested type before it is returned or stored.
+
+var (
+	// ErrEmptyQuiz means the model produced no questions; nothing is stored.
+	ErrEmptyQuiz = errors.New("quiz has no questions")
+	// ErrMalformedQuiz means the generator answered with a quiz that does not have the requested shape.
+	ErrMalformedQuiz = errors.New("malformed quiz")
+)
+
+type QuizServiceInterface interface {
+	GenerateFromText(ctx context.Context, text string, lang string, opts models.QuizOptions) (*models.QuizResponse, error)
+	GenerateForAnalysis(ctx context.Context, userID string, analysisID int, lang string, opts models.QuizOptions, regenerate bool) (*models.StoredQuiz, error)
+	GetQuiz(userID string, analysisID int, version int) (*models.StoredQuiz, error)
+}
+
+type quizService struct {
+	repo     repositories.QuizRepository
+	analyzer AnalyzerServiceInterface
+}
+
+func NewQuizService(repo repositories.QuizRepository, analyzer AnalyzerServiceInterface) QuizServiceInterface {
+	return &quizService{repo: repo, analyzer: analyzer}
+}
+
+func (s *quizService) GenerateFromText(ctx context.Context, text string, lang string, opts models.QuizOptions) (*models.QuizResponse, error) {
+	return s.generate(ctx, text, lang, opts)
+}
+
+// GenerateForAnalysis returns sql.ErrNoRows when the analysis is not the user's.
+func (s *quizService) GenerateForAnalysis(ctx context.Context, userID string, analysisID int, lang string, opts models.QuizOptions, regenerate bool) (*models.StoredQuiz, error) {
+	cid := utils.CorrelationIDFromCtx(ctx)
+	if !regenerate {
+		stored, err := s.repo.GetQuiz(userID, analysisID, 0)
+		if err == nil && stored.Options == opts {
+			stored.Reused = true
+			return stored, nil
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	text, err := s.repo.GetAnalysisText(userID, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	generated, err := s.generate(ctx, truncateUTF8(text, config.QuizMaxChars), lang, opts)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.repo.SaveQuiz(userID, analysisID, opts, generated.Quiz)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Str("cid", cid).Int("analysis", analysisID).Int("version", version).Int("questions", len(generated.Quiz)).Msg("quiz stored")
+	return s.repo.GetQuiz(userID, analysisID, version)
+}
+
+func (s *quizService) GetQuiz(userID string, analysisID int, version int) (*models.StoredQuiz, error) {
+	return s.repo.GetQuiz(userID, analysisID, version)
+}
+
+// generate asks the generator for a quiz and checks it has the requested shape.
+func (s *quizService) generate(ctx context.Context, text string, lang string, opts models.QuizOptions) (*models.QuizResponse, error) {
+	generated, err := s.analyzer.GenerateQuizWithContext(ctx, text, lang, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(generated.Quiz) == 0 {
+		return nil, ErrEmptyQuiz
+	}
+	if len(generated.Quiz) > opts.Count {
+		generated.Quiz = generated.Quiz[:opts.Count]
+	}
+	for i := range generated.Quiz {
+		if err := checkQuestion(&generated.Quiz[i], opts.Type); err != nil {
+			log.Warn().Str("cid", utils.CorrelationIDFromCtx(ctx)).Int("question", i).Err(err).Msg("generator returned a malformed quiz")
+			return nil, fmt.Errorf("%w: question %d: %v", ErrMalformedQuiz, i, err)
+		}
+	}
+	return generated, nil
+}
+
+// checkQuestion validates one generated question against the requested type. Questions without a
+// type (older generators) are taken as open ones; a multiple-choice answer is set from its options.
+func checkQuestion(q *models.QuizQuestion, want string) error {
+	if q.Type == "" && want == models.QuizTypeOpen {
+		q.Type = models.QuizTypeOpen
+	}
+	if q.Type != want {
+		return fmt.Errorf("type %q, want %q", q.Type, want)
+	}
+	if strings.TrimSpace(q.Question) == "" {
+		return errors.New("empty question")
+	}
+	switch q.Type {
+	case models.QuizTypeMultipleChoice:
+		if len(q.Options) < 2 {
+			return errors.New("fewer than two options")
+		}
+		seen := make(map[string]bool, len(q.Options))
+		for _, o := range q.Options {
+			key := strings.ToLower(strings.TrimSpace(o))
+			if key == "" || seen[key] {
+				return errors.New("empty or repeated option")
+			}
+			seen[key] = true
+		}
+		if q.CorrectIndex == nil || *q.CorrectIndex < 0 || *q.CorrectIndex >= len(q.Options) {
+			return errors.New("correctIndex out of range")
+		}
+		q.Answer = q.Options[*q.CorrectIndex]
+		return nil
+	case models.QuizTypeTrueFalse:
+		switch strings.ToLower(strings.TrimSpace(q.Answer)) {
+		case "true":
+			q.Answer = "True"
+		case "false":
+			q.Answer = "False"
+		default:
+			return fmt.Errorf("answer %q is not True or False", q.Answer)
+		}
+	case models.QuizTypeCloze:
+		if !strings.Contains(q.Question, "___") {
+			return errors.New("cloze question without a blank")
+		}
+	}
+	if len(q.Options) > 0 || q.CorrectIndex != nil {
+		return errors.New("options on a question that is not multiple choice")
+	}
+	if strings.TrimSpace(q.Answer) == "" {
+		return errors.New("empty answer")
+	}
+	return nil
+}
+
+// truncateUTF8 cuts text to at most n bytes without splitting a UTF-8 sequence.
+func truncateUTF8(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
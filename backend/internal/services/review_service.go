@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/grading"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/srs"
+)
+
+// Review service overview:
+// - An attempt answers some or all questions of one stored quiz version (default: the latest).
+// - Each answer is graded (normalized match, else token overlap against config.QuizPassScore;
+//   multiple-choice and true/false answers must name the right option) and stored, so every
+//   question keeps its correctness history.
+// - The grade becomes an SM-2 recall quality that reschedules the question for the user.
+
+// ErrInvalidAnswer means an answer targets a question that is not in the quiz, or repeats one.
+var ErrInvalidAnswer = errors.New("answer does not match a quiz question")
+
+type ReviewServiceInterface interface {
+	SubmitAttempt(userID string, analysisID, version int, answers []models.SubmittedAnswer) (*models.QuizAttempt, error)
+	DueReviews(userID string, limit, offset int) ([]models.DueReview, int, error)
+}
+
+type reviewService struct {
+	quizzes repositories.QuizRepository
+	reviews repositories.ReviewRepository
+	now     func() time.Time
+}
+
+func NewReviewService(quizzes repositories.QuizRepository, reviews repositories.ReviewRepository) ReviewServiceInterface {
+	return &reviewService{quizzes: quizzes, reviews: reviews, now: time.Now}
+}
+
+// SubmitAttempt grades and stores the answers; sql.ErrNoRows when the quiz (version) is not the user's.
+func (s *reviewService) SubmitAttempt(userID string, analysisID, version int, answers []models.SubmittedAnswer) (*models.QuizAttempt, error) {
+	quiz, err := s.quizzes.GetQuiz(userID, analysisID, version)
+	if err != nil {
+		return nil, err
+	}
+	questions := make(map[int]models.QuizQuestion, len(quiz.Quiz))
+	for _, q := range quiz.Quiz {
+		questions[q.ID] = q
+	}
+	ids := make([]int, 0, len(answers))
+	seen := make(map[int]bool, len(answers))
+	for _, a := range answers {
+		if _, ok := questions[a.QuestionID]; !ok || seen[a.QuestionID] {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidAnswer, a.QuestionID)
+		}
+		seen[a.QuestionID] = true
+		ids = append(ids, a.QuestionID)
+	}
+
+	states, err := s.reviews.GetReviewStates(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	attempt := &models.QuizAttempt{AnalysisID: analysisID, Version: quiz.Version, Total: len(answers), Results: make([]models.AnswerResult, 0, len(answers))}
+	next := make([]models.ReviewState, 0, len(answers))
+	for _, a := range answers {
+		q := questions[a.QuestionID]
+		threshold := config.QuizPassScore
+		if q.Type == models.QuizTypeMultipleChoice || q.Type == models.QuizTypeTrueFalse {
+			threshold = 1 // choosing an option: no partial credit
+		}
+		g := grading.Grade(q.Answer, a.Answer, threshold)
+		quality := srs.Quality(g.Correct, g.Exact, g.Score)
+		prev := states[q.ID]
+		st := srs.Review(srs.State{Repetitions: prev.Repetitions, IntervalDays: prev.IntervalDays, Ease: prev.Ease}, quality, now)
+		next = append(next, models.ReviewState{QuestionID: q.ID, Repetitions: st.Repetitions, IntervalDays: st.IntervalDays, Ease: st.Ease, DueAt: st.DueAt})
+		if g.Correct {
+			attempt.Correct++
+		}
+		attempt.Results = append(attempt.Results, models.AnswerResult{
+			QuestionID: q.ID, Question: q.Question, Answer: a.Answer, Expected: q.Answer,
+			Correct: g.Correct, Score: g.Score, Quality: quality, NextReviewAt: st.DueAt.UTC().Format(time.RFC3339),
+		})
+	}
+	if attempt.Total > 0 {
+		attempt.Score = float64(attempt.Correct) / float64(attempt.Total)
+	}
+	if err := s.reviews.SaveAttempt(userID, attempt, next); err != nil {
+		return nil, err
+	}
+	return attempt, nil
+}
+
+func (s *reviewService) DueReviews(userID string, limit, offset int) ([]models.DueReview, int, error) {
+	return s.reviews.ListDue(userID, s.now(), limit, offset)
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/utils"
+	"github.com/samusafe/genericapi/internal/workspace"
+)
+
+// Workspace service overview:
+// - Export streams the user's collections, documents (extracted text), analyses and quiz
+//   questions as a gzip-compressed workspace archive. Original uploads, embeddings, passages
+//   and review history are not part of it.
+// - Import validates the whole archive before touching the database, then restores it in one
+//   transaction with new IDs (see WorkspaceRepository.ImportWorkspace for the dedupe rules).
+//   A dry run reports the same counts and conflicts without keeping anything.
+
+type WorkspaceServiceInterface interface {
+	Export(ctx context.Context, userID string, w io.Writer) error
+	Import(ctx context.Context, userID string, r io.Reader, dryRun bool) (*models.ImportReport, error)
+}
+
+type workspaceService struct {
+	repo repositories.WorkspaceRepository
+	now  func() time.Time
+}
+
+func NewWorkspaceService(repo repositories.WorkspaceRepository) WorkspaceServiceInterface {
+	return &workspaceService{repo: repo, now: time.Now}
+}
+
+func (s *workspaceService) Export(ctx context.Context, userID string, w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	aw := workspace.NewWriter(zw, s.now())
+	if err := s.repo.ExportWorkspace(ctx, userID, aw); err != nil {
+		return err
+	}
+	if err := aw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Import returns workspace.ErrInvalidArchive, workspace.ErrUnsupportedVersion or
+// workspace.ErrArchiveTooLarge for archives that cannot be restored; nothing is written then.
+func (s *workspaceService) Import(ctx context.Context, userID string, r io.Reader, dryRun bool) (*models.ImportReport, error) {
+	archive, err := workspace.Read(r, config.WorkspaceImportMaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.repo.ImportWorkspace(ctx, userID, archive, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Str("cid", utils.CorrelationIDFromCtx(ctx)).Bool("dry_run", dryRun).
+		Int("documents", report.Documents.Created).Int("existing_documents", report.Documents.Existing).
+		Int("analyses", report.Analyses.Created).Msg("workspace import")
+	return report, nil
+}
@@ -0,0 +1,48 @@
+package similarity
+
+import (
+	"math"
+	"sort"
+
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+// Cosine returns the cosine similarity of a and b, or 0 when the lengths differ or either
+// vector is zero.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		na += x * x
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// TopK ranks candidates by similarity to target and keeps the k best (ties by document id).
+func TopK(target []float32, candidates []models.DocumentEmbedding, k int) []models.RelatedDocument {
+	out := make([]models.RelatedDocument, 0, len(candidates))
+	for _, c := range candidates {
+		if len(c.Vector) != len(target) {
+			continue
+		}
+		out = append(out, models.RelatedDocument{DocumentID: c.DocumentID, FileName: c.FileName, CollectionID: c.CollectionID, Similarity: Cosine(target, c.Vector)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Similarity != out[j].Similarity {
+			return out[i].Similarity > out[j].Similarity
+		}
+		return out[i].DocumentID < out[j].DocumentID
+	})
+	if len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
@@ -0,0 +1,159 @@
+package sniff
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// Content-type detection from magic bytes, so a renamed executable cannot pass as report.pdf.
+// Only the formats we accept are recognized; everything else is reported as TypeUnknown.
+
+const (
+	TypePDF     = "application/pdf"
+	TypeDOCX    = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	TypeZIP     = "application/zip"
+	TypeText    = "text/plain"
+	TypeUnknown = "application/octet-stream"
+)
+
+// pdfHeaderWindow is how far into the file the %PDF- marker may appear behind a binary preamble
+// (readers tolerate junk before it).
+const pdfHeaderWindow = 1024
+
+// textSampleSize is how much of the file is inspected when deciding whether it is text.
+const textSampleSize = 64 * 1024
+
+// maxControlRatio is the share of control characters above which content is treated as binary.
+const maxControlRatio = 0.1
+
+var (
+	pdfMagic      = []byte("%PDF-")
+	utf8BOM       = []byte{0xEF, 0xBB, 0xBF}
+	zipMagic      = []byte("PK\x03\x04")
+	utf16LEBOM    = []byte{0xFF, 0xFE}
+	utf16BEBOM    = []byte{0xFE, 0xFF}
+	docxMainEntry = "word/document.xml"
+)
+
+// Detect returns the content type of the size bytes behind r. Only a prefix is read, plus the
+// ZIP central directory for DOCX, so large uploads are never loaded whole.
+func Detect(r io.ReaderAt, size int64) string {
+	sample := make([]byte, min(size, textSampleSize))
+	n, err := r.ReadAt(sample, 0)
+	if err != nil && err != io.EOF {
+		return TypeUnknown
+	}
+	sample = sample[:n]
+	switch {
+	case hasPDFHeader(sample):
+		return TypePDF
+	case bytes.HasPrefix(sample, zipMagic):
+		if hasZipEntry(r, size, docxMainEntry) {
+			return TypeDOCX
+		}
+		return TypeZIP
+	case isText(sample, int64(n) < size):
+		return TypeText
+	}
+	return TypeUnknown
+}
+
+// Matches reports whether the detected type is what the file extension promises.
+func Matches(ext, detected string) bool {
+	switch ext {
+	case ".pdf":
+		return detected == TypePDF
+	case ".docx":
+		return detected == TypeDOCX
+	case ".txt", ".md":
+		return detected == TypeText
+	}
+	return false
+}
+
+// LooksBinary flags NUL characters or a high share of C0 control characters (tabs, newlines and
+// form feeds excluded) in decoded text.
+func LooksBinary(text string) bool {
+	total, control := 0, 0
+	for _, r := range text {
+		total++
+		switch {
+		case r == 0:
+			return true
+		case r == '\t' || r == '\n' || r == '\r' || r == '\f' || r == '\v':
+		case r < 0x20 || r == 0x7F:
+			control++
+		}
+	}
+	return total > 0 && float64(control)/float64(total) > maxControlRatio
+}
+
+// isText accepts UTF-16 with a BOM, valid UTF-8 and, failing that, Latin-1 without control garbage.
+// truncated means data is a prefix, so a multi-byte rune cut at the end is not an error.
+func isText(data []byte, truncated bool) bool {
+	if bytes.HasPrefix(data, utf16LEBOM) || bytes.HasPrefix(data, utf16BEBOM) {
+		return truncated || len(data)%2 == 0
+	}
+	if truncated {
+		data = trimPartialRune(data)
+	}
+	if utf8.Valid(data) {
+		return !LooksBinary(string(data))
+	}
+	return bytes.IndexByte(data, 0) < 0 && !LooksBinary(string(latin1(data)))
+}
+
+// hasPDFHeader accepts %PDF- at the start of the file, after a BOM or whitespace, or behind a
+// short preamble with control bytes in it. A text file that merely mentions %PDF- is not a PDF.
+func hasPDFHeader(sample []byte) bool {
+	head := bytes.TrimLeft(bytes.TrimPrefix(sample, utf8BOM), " \t\r\n\f\v")
+	if bytes.HasPrefix(head, pdfMagic) {
+		return true
+	}
+	i := bytes.Index(sample[:min(len(sample), pdfHeaderWindow)], pdfMagic)
+	if i <= 0 {
+		return false
+	}
+	for _, b := range sample[:i] {
+		if (b < 0x20 && !bytes.ContainsRune([]byte("\t\n\r\f\v"), rune(b))) || b == 0x7F {
+			return true
+		}
+	}
+	return false
+}
+
+func latin1(data []byte) []rune {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return runes
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence at the end of a sample.
+func trimPartialRune(data []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				return data[:len(data)-i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+func hasZipEntry(r io.ReaderAt, size int64, name string) bool {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return false
+	}
+	for _, f := range zr.File {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
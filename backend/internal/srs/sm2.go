@@ -0,0 +1,65 @@
+// Package srs schedules question reviews with the SM-2 spaced-repetition algorithm.
+package srs
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// DefaultEase is the easiness factor of a question that was never reviewed.
+	DefaultEase = 2.5
+	minEase     = 1.3
+)
+
+// State is the scheduling state of one question.
+type State struct {
+	Repetitions  int
+	IntervalDays int
+	Ease         float64
+	DueAt        time.Time
+}
+
+// Review applies a recall quality (0-5, >= 3 means remembered) at time now and returns the next
+// state. A zero State is treated as a new question.
+func Review(s State, quality int, now time.Time) State {
+	quality = max(0, min(5, quality))
+	if s.Ease == 0 {
+		s.Ease = DefaultEase
+	}
+	if quality < 3 {
+		s.Repetitions = 0
+		s.IntervalDays = 1
+	} else {
+		s.Repetitions++
+		switch s.Repetitions {
+		case 1:
+			s.IntervalDays = 1
+		case 2:
+			s.IntervalDays = 6
+		default:
+			s.IntervalDays = int(math.Round(float64(s.IntervalDays) * s.Ease))
+		}
+	}
+	miss := float64(5 - quality)
+	s.Ease = math.Max(minEase, s.Ease+0.1-miss*(0.08+miss*0.02))
+	s.DueAt = now.AddDate(0, 0, s.IntervalDays)
+	return s
+}
+
+// Quality maps a graded answer to an SM-2 recall quality: 5 for an exact answer, 4 or 3 for an
+// accepted fuzzy one depending on its score, 2 for a partially right miss and 1 otherwise.
+func Quality(correct, exact bool, score float64) int {
+	switch {
+	case exact:
+		return 5
+	case correct && score >= 0.8:
+		return 4
+	case correct:
+		return 3
+	case score > 0:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/samusafe/genericapi/internal/blobstore"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+// mockAccountRepo simulates the deletion transaction: finish runs with fixed counts and the
+// stored report is what a real repository would insert as the audit record. committed is set
+// when the transaction would commit.
+type mockAccountRepo struct {
+	orphaned  []string
+	stored    *models.DeletionReport
+	userID    string
+	committed bool
+}
+
+func (m *mockAccountRepo) DeleteAccount(ctx context.Context, userID string, finish repositories.AccountFinisher) (*models.DeletionReport, []string, error) {
+	m.userID = userID
+	report, err := finish(models.DeletionCounts{Collections: 1, Documents: 3, Analyses: 4, QuizQuestions: 5}, m.orphaned)
+	if err != nil {
+		return nil, nil, err
+	}
+	m.stored = report
+	m.committed = true
+	return report, m.orphaned, nil
+}
+
+// commitCheckingBlobStore records whether the deletion had committed when each blob was deleted.
+type commitCheckingBlobStore struct {
+	blobstore.BlobStore
+	repo          *mockAccountRepo
+	deletedBefore int
+}
+
+func (s *commitCheckingBlobStore) Delete(ctx context.Context, key string) error {
+	if !s.repo.committed {
+		s.deletedBefore++
+	}
+	return s.BlobStore.Delete(ctx, key)
+}
+
+type failingBlobStore struct{ blobstore.BlobStore }
+
+func (failingBlobStore) Delete(ctx context.Context, key string) error {
+	return errors.New("bucket unavailable")
+}
+
+func TestAccountService_DeleteAccount(t *testing.T) {
+	store := blobstore.NewLocal(t.TempDir())
+	data := []byte("original upload")
+	key := hashOf(data)
+	if err := store.Put(context.Background(), key, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	repo := &mockAccountRepo{orphaned: []string{key}}
+	blobs := &commitCheckingBlobStore{BlobStore: store, repo: repo}
+	secret := []byte("test-key")
+	service := services.NewAccountService(repo, blobs, secret)
+
+	report, err := service.DeleteAccount(context.Background(), "user_2abc", models.DeletionRequestedByAdmin)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, _, err := store.Get(context.Background(), key); !errors.Is(err, blobstore.ErrNotFound) {
+		t.Fatalf("expected orphaned blob deleted, got %v", err)
+	}
+	if blobs.deletedBefore != 0 {
+		t.Fatalf("blobs must only be deleted after the deletion committed")
+	}
+	if report.Counts.Documents != 3 || report.Counts.Blobs != 1 || report.RequestedBy != models.DeletionRequestedByAdmin || report.ID == "" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if repo.stored != report {
+		t.Fatalf("expected the signed report to be the audit record")
+	}
+	if strings.Contains(report.Subject, "user_2abc") || report.Subject != services.DeletionSubject(secret, "user_2abc") {
+		t.Fatalf("subject must be the keyed hash of the user ID, got %q", report.Subject)
+	}
+	if !services.VerifyDeletionReport(secret, report) {
+		t.Fatalf("expected signature to verify")
+	}
+	tampered := *report
+	tampered.Counts.Documents = 0
+	if services.VerifyDeletionReport(secret, &tampered) || services.VerifyDeletionReport([]byte("other-key"), report) {
+		t.Fatalf("expected tampered report or wrong key to fail verification")
+	}
+}
+
+func TestAccountService_BlobFailureAfterCommitIsTolerated(t *testing.T) {
+	repo := &mockAccountRepo{orphaned: []string{hashOf([]byte("x"))}}
+	service := services.NewAccountService(repo, failingBlobStore{}, []byte("k"))
+
+	report, err := service.DeleteAccount(context.Background(), "user-1", models.DeletionRequestedByUser)
+	if err != nil {
+		t.Fatalf("a blob left behind must not fail the committed deletion: %v", err)
+	}
+	if repo.stored != report || report.Counts.Blobs != 1 {
+		t.Fatalf("expected the audit record stored, got %+v", report)
+	}
+}
+
+func TestAccountHandler_DeleteAccount(t *testing.T) {
+	repo := &mockAccountRepo{}
+	h := handlers.NewAccountHandler(services.NewAccountService(repo, nil, []byte("k")))
+	c, w := newTestContext()
+	c.Request = httptest.NewRequest(http.MethodDelete, "/me", nil)
+	h.DeleteAccount(c)
+
+	if w.Code != http.StatusOK || repo.userID != "user-1" {
+		t.Fatalf("expected 200 for the authenticated user, got %d user=%q", w.Code, repo.userID)
+	}
+	var env envelope
+	decodeEnvelope(t, w, &env)
+	if env.Data["requestedBy"] != models.DeletionRequestedByUser || env.Data["signature"] == "" {
+		t.Fatalf("unexpected report %+v", env.Data)
+	}
+}
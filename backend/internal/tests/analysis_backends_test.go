@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/httpclient"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+func newTestRegistry(t *testing.T) *httpclient.Registry {
+	t.Helper()
+	reg := httpclient.NewRegistry("python", &mockPythonClient{respBody: `{"summary":"default","fullText":"x"}`})
+	reg.Register("large", &mockPythonClient{respBody: `{"summary":"large","fullText":"x"}`})
+	reg.Register("llm", &mockPythonClient{respBody: `{"summary":"llm","fullText":"x"}`})
+	rules := []httpclient.RoutingRule{
+		{Backend: "large", Extensions: []string{".PDF"}},
+		{Backend: "llm", CollectionIDs: []int{7}, UserIDs: []string{"beta"}},
+	}
+	for _, r := range rules {
+		if err := reg.AddRule(r); err != nil {
+			t.Fatalf("add rule: %v", err)
+		}
+	}
+	return reg
+}
+
+func TestRegistry_Route(t *testing.T) {
+	reg := newTestRegistry(t)
+	col := 7
+	cases := []struct {
+		name string
+		req  httpclient.RouteRequest
+		want string
+	}{
+		{"default", httpclient.RouteRequest{FileName: "a.txt", UserID: "u"}, "python"},
+		{"extension rule", httpclient.RouteRequest{FileName: "a.pdf", UserID: "u"}, "large"},
+		{"all criteria must match", httpclient.RouteRequest{FileName: "a.txt", CollectionID: &col, UserID: "u"}, "python"},
+		{"collection and user rule", httpclient.RouteRequest{FileName: "a.txt", CollectionID: &col, UserID: "beta"}, "llm"},
+		{"explicit wins", httpclient.RouteRequest{Explicit: "llm", FileName: "a.pdf"}, "llm"},
+	}
+	for _, tc := range cases {
+		if got, _ := reg.Route(tc.req); got != tc.want {
+			t.Errorf("%s: expected %s got %s", tc.name, tc.want, got)
+		}
+	}
+	if err := reg.AddRule(httpclient.RoutingRule{Backend: "missing"}); err == nil {
+		t.Fatalf("expected error for rule with unknown backend")
+	}
+}
+
+func TestAnalyzerService_RecordsRoutedBackend(t *testing.T) {
+	repo := &mockRepo{}
+	service := services.NewAnalyzerServiceWithBackends(repo, newTestRegistry(t))
+	res := service.AnalyzeContent(context.Background(), "doc.pdf", []byte("%PDF-1.7\n..."), "en", "user", nil, nil, nil, services.AnalyzeOptions{})
+	if res.Error != "" || res.Data.Summary != "large" || res.Backend != "large" {
+		t.Fatalf("expected result from large backend, got %+v", res)
+	}
+	if repo.lastBackend != "large" {
+		t.Fatalf("expected backend recorded on analysis, got %q", repo.lastBackend)
+	}
+}
+
+func TestAnalyzeHandler_UnknownBackend(t *testing.T) {
+	h := handlers.NewAnalyzeHandler(services.NewAnalyzerServiceWithBackends(&mockRepo{}, newTestRegistry(t)))
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, _ := w.CreateFormFile("documents", "a.txt")
+	part.Write([]byte("alpha"))
+	w.WriteField("backend", "nope")
+	w.Close()
+
+	c, rec := newTestContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/analyze", &body)
+	c.Request.Header.Set("Content-Type", w.FormDataContentType())
+	h.Analyze(c)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestOpenAIClient_TranslatesChatCompletion(t *testing.T) {
+	var gotModel string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		gotModel = req.Model
+		reply := "```json\n{\"summary\":\"short\",\"summary_points\":[\"a\"],\"keywords\":[\"k\"],\"sentiment\":\"neutral\"}\n```"
+		json.NewEncoder(w).Encode(map[string]any{"choices": []any{map[string]any{"message": map[string]string{"role": "assistant", "content": reply}}}})
+	}))
+	defer srv.Close()
+	client := httpclient.NewOpenAIClientWithPolicy(srv.URL+"/v1/", "llama3", 0, srv.Client(), httpclient.RetryPolicy{MaxAttempts: 1}, httpclient.NewCircuitBreaker("llm-test", 5, time.Minute))
+
+	resp, err := client.AnalyzeTextWithCtx(context.Background(), "the document", "")
+	if err != nil {
+		t.Fatalf("analyze text: %v", err)
+	}
+	defer resp.Body.Close()
+	var out models.AnalysisResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if gotModel != "llama3" || out.Summary != "short" || out.FullText != "the document" || len(out.Keywords) != 1 || out.Meta == nil || out.Meta.ModelVersion != "llama3" {
+		t.Fatalf("unexpected analysis %+v (model %q)", out, gotModel)
+	}
+
+	if _, err := client.AnalyzeWithCtx(context.Background(), bytes.NewReader([]byte("%PDF")), "a.pdf", ""); !errors.Is(err, httpclient.ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported for file upload, got %v", err)
+	}
+}
+
+func TestAnalyzerService_UnsupportedByBackend(t *testing.T) {
+	reg := httpclient.NewRegistry("python", &mockPythonClient{respBody: `{"summary":"default","fullText":"x"}`})
+	reg.Register("llm", httpclient.NewOpenAIClient("llm", "http://127.0.0.1:1/v1", "llama3", 0, time.Second))
+	service := services.NewAnalyzerServiceWithBackends(&mockRepo{}, reg)
+	res := service.AnalyzeContent(context.Background(), "doc.pdf", []byte("%PDF-1.7\n..."), "en", "user", nil, nil, nil, services.AnalyzeOptions{Backend: "llm"})
+	if res.ErrorKey != "UnsupportedFileType" {
+		t.Fatalf("expected UnsupportedFileType, got %+v", res)
+	}
+}
+
+func TestLoadRegistry_BackendsFile(t *testing.T) {
+	oldFile, oldDefault, oldList := config.AnalysisBackendsFile, config.AnalysisDefaultBackend, config.AnalysisBackends
+	t.Cleanup(func() {
+		config.AnalysisBackendsFile, config.AnalysisDefaultBackend, config.AnalysisBackends = oldFile, oldDefault, oldList
+	})
+	config.AnalysisBackends, config.AnalysisDefaultBackend = "", ""
+
+	load := func(content string) (*httpclient.Registry, error) {
+		path := filepath.Join(t.TempDir(), "backends.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write backends file: %v", err)
+		}
+		config.AnalysisBackendsFile = path
+		return httpclient.LoadRegistry()
+	}
+
+	reg, err := load(`{"backends":[{"name":"large","url":"http://large:5000","timeoutSeconds":300},{"name":"llm","type":"openai","url":"http://llm:8000/v1","model":"llama3","timeoutSeconds":120}],"rules":[{"backend":"llm","extensions":[".md"]}]}`)
+	if err != nil {
+		t.Fatalf("load registry: %v", err)
+	}
+	if got, _ := reg.Route(httpclient.RouteRequest{FileName: "notes.md"}); got != "llm" || !reg.Has("large") {
+		t.Fatalf("expected llm route and large backend, got %s", got)
+	}
+
+	for name, content := range map[string]string{
+		"openai default":  `{"default":"llm","backends":[{"name":"llm","type":"openai","url":"http://llm:8000/v1","model":"llama3"}]}`,
+		"openai no model": `{"backends":[{"name":"llm","type":"openai","url":"http://llm:8000/v1"}]}`,
+		"unknown type":    `{"backends":[{"name":"x","type":"grpc","url":"http://x"}]}`,
+	} {
+		if _, err := load(content); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
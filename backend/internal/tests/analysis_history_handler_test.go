@@ -3,7 +3,6 @@ package tests
 import (
 	"database/sql"
 	"encoding/json"
-	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -13,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/samusafe/genericapi/internal/handlers"
 	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
 	"github.com/samusafe/genericapi/internal/utils"
 )
 
@@ -20,12 +20,13 @@ type mockAnalysisRepo2 struct {
 	latestFn       func(userID string, documentID int) (*models.AnalysisDetail, error)
 	listAllFn      func(userID string, limit, offset int) ([]models.DocumentItem, int, error)
 	updateDocColFn func(userID string, docID int, colID int) error
+	versionsFn     func(userID string, docID int) ([]models.AnalysisVersion, error)
 }
 
-func (m *mockAnalysisRepo2) InsertDocument(string, *int, string, string, string) (int, error) {
+func (m *mockAnalysisRepo2) InsertDocument(string, *int, string, string, string, string, string) (int, error) {
 	return 0, nil
 }
-func (m *mockAnalysisRepo2) InsertAnalysis(string, int, string, []string, string, []string, *string, *int) (int, error) {
+func (m *mockAnalysisRepo2) InsertAnalysis(string, int, string, []string, string, []string, *string, *int, string, *models.AnalysisMeta) (int, error) {
 	return 0, nil
 }
 func (m *mockAnalysisRepo2) FindDocument(string, *int, string) (int, error) { return 0, nil }
@@ -41,6 +42,13 @@ func (m *mockAnalysisRepo2) ListAllDocuments(userID string, limit, offset int) (
 func (m *mockAnalysisRepo2) UpdateDocumentCollection(userID string, docID int, colID int) error {
 	return m.updateDocColFn(userID, docID, colID)
 }
+func (m *mockAnalysisRepo2) InsertReusedAnalysis(string, int, *string, *int) (int, error) {
+	return 0, nil
+}
+func (m *mockAnalysisRepo2) UpdateDocumentText(string, int, string) error { return nil }
+func (m *mockAnalysisRepo2) ListAnalysisVersions(userID string, docID int) ([]models.AnalysisVersion, error) {
+	return m.versionsFn(userID, docID)
+}
 
 type mockCollectionsRepo2 struct {
 	existsFn func(userID string, id int) (bool, error)
@@ -148,7 +156,7 @@ func TestAnalysisHistory_SaveDocumentToCollection_CollectionNotFound(t *testing.
 }
 
 func TestAnalysisHistory_SaveDocumentToCollection_AlreadyAssigned(t *testing.T) {
-	aRepo := &mockAnalysisRepo2{updateDocColFn: func(string, int, int) error { return errors.New("already_assigned") }}
+	aRepo := &mockAnalysisRepo2{updateDocColFn: func(string, int, int) error { return repositories.ErrDocumentAlreadyAssigned }}
 	cRepo := &mockCollectionsRepo2{existsFn: func(string, int) (bool, error) { return true, nil }}
 	h := handlers.NewAnalysisHistoryHandler(aRepo, cRepo)
 	c, w := newHistoryContext()
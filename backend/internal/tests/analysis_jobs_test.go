@@ -0,0 +1,253 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/scanner"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+// mockJobsRepo hands out queued tasks once and records completions.
+type mockJobsRepo struct {
+	mu        sync.Mutex
+	tasks     []*models.JobFileTask
+	created   []models.JobUpload
+	force     bool
+	completed map[int]models.AnalysisResult
+	statuses  map[int]string
+	done      chan struct{}
+	renewals  int
+	attempts  map[int]int
+}
+
+func (m *mockJobsRepo) CreateJob(jobID, userID, lang, cid string, collectionID *int, backend string, force bool, uploads []models.JobUpload) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.force = force
+	m.created = append(m.created, uploads...)
+	return nil
+}
+func (m *mockJobsRepo) GetJob(userID, jobID string) (*models.AnalysisJob, error) {
+	if jobID == "00000000-0000-0000-0000-000000000000" {
+		return nil, sql.ErrNoRows
+	}
+	return &models.AnalysisJob{ID: jobID, Status: models.JobStatusQueued, Total: len(m.created)}, nil
+}
+func (m *mockJobsRepo) ClaimNextFile() (*models.JobFileTask, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.tasks) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	t := m.tasks[0]
+	m.tasks = m.tasks[1:]
+	return t, nil
+}
+func (m *mockJobsRepo) RenewFile(fileID, attempt int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.renewals++
+	return nil
+}
+func (m *mockJobsRepo) CompleteFile(fileID, attempt int, status string, result models.AnalysisResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.attempts == nil {
+		m.attempts = map[int]int{}
+	}
+	m.attempts[fileID] = attempt
+	m.completed[fileID] = result
+	m.statuses[fileID] = status
+	if len(m.tasks) == 0 && m.done != nil {
+		close(m.done)
+		m.done = nil
+	}
+	return nil
+}
+func (m *mockJobsRepo) ReleaseFile(int, int) error                { return nil }
+func (m *mockJobsRepo) RequeueStale(time.Duration) (int64, error) { return 0, nil }
+
+func TestJobService_WorkerCompletesQueuedFiles(t *testing.T) {
+	repo := &mockJobsRepo{
+		tasks: []*models.JobFileTask{
+			{FileID: 1, JobID: "job-1", UserID: "user", Lang: "en", FileCount: 2, FileName: "doc.txt", Content: []byte("content")},
+			{FileID: 2, JobID: "job-1", UserID: "user", Lang: "en", FileCount: 2, FileName: "doc.exe", Content: []byte("content")},
+		},
+		completed: map[int]models.AnalysisResult{},
+		statuses:  map[int]string{},
+		done:      make(chan struct{}),
+	}
+	done := repo.done
+	py := &mockPythonClient{respBody: `{"summary":"ok","keywords":["a"],"sentiment":"neutral","fullText":"full content"}`}
+	analyzer := services.NewAnalyzerServiceFull(&mockRepo{}, py, nil)
+	jobs := services.NewJobServiceFull(repo, analyzer, nil, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := jobs.Start(ctx)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for job files to complete")
+	}
+	cancel()
+	wait()
+
+	if repo.statuses[1] != models.JobStatusDone || repo.completed[1].Data == nil {
+		t.Fatalf("expected doc.txt done with data, got %s %+v", repo.statuses[1], repo.completed[1])
+	}
+	if repo.statuses[2] != models.JobStatusFailed || repo.completed[2].Error == "" {
+		t.Fatalf("expected doc.exe failed with error, got %s %+v", repo.statuses[2], repo.completed[2])
+	}
+}
+
+// slowPythonClient answers like mockPythonClient after a delay.
+type slowPythonClient struct {
+	*mockPythonClient
+	delay time.Duration
+}
+
+func (s slowPythonClient) AnalyzeTextWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error) {
+	time.Sleep(s.delay)
+	return s.mockPythonClient.AnalyzeTextWithCtx(ctx, text, correlationID)
+}
+
+func TestJobService_RenewsLeaseWhileAnalyzing(t *testing.T) {
+	old := config.JobLeaseTimeout
+	config.JobLeaseTimeout = 30 * time.Millisecond
+	t.Cleanup(func() { config.JobLeaseTimeout = old })
+	repo := &mockJobsRepo{
+		tasks:     []*models.JobFileTask{{FileID: 1, Attempt: 3, JobID: "job-1", UserID: "user", Lang: "en", FileCount: 1, FileName: "doc.txt", Content: []byte("content")}},
+		completed: map[int]models.AnalysisResult{},
+		statuses:  map[int]string{},
+		done:      make(chan struct{}),
+	}
+	done := repo.done
+	py := slowPythonClient{&mockPythonClient{respBody: `{"summary":"ok","fullText":"content"}`}, 100 * time.Millisecond}
+	jobs := services.NewJobServiceFull(repo, services.NewAnalyzerServiceFull(&mockRepo{}, py, nil), nil, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := jobs.Start(ctx)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for the job file to complete")
+	}
+	cancel()
+	wait()
+
+	if repo.renewals < 2 || repo.attempts[1] != 3 || repo.statuses[1] != models.JobStatusDone {
+		t.Fatalf("expected lease renewed and attempt 3 completed, got renewals=%d attempt=%d status=%s", repo.renewals, repo.attempts[1], repo.statuses[1])
+	}
+}
+
+func TestJobService_SubmitStoresUploads(t *testing.T) {
+	repo := &mockJobsRepo{}
+	opener := mockFileOpener{contents: map[string]string{"a.txt": "alpha", "b.md": "beta"}}
+	analyzer := services.NewAnalyzerServiceFull(&mockRepo{}, &mockPythonClient{}, nil)
+	jobs := services.NewJobServiceFull(repo, analyzer, opener, 1)
+	files := []*multipart.FileHeader{buildMemFileHeader("a.txt", "alpha"), buildMemFileHeader("b.md", "beta")}
+	job, err := jobs.Submit(context.Background(), files, "en", "user", nil, services.AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if job == nil || job.Total != 2 {
+		t.Fatalf("expected job with 2 files, got %+v", job)
+	}
+	if string(repo.created[0].Content) != "alpha" || repo.created[1].FileName != "b.md" {
+		t.Fatalf("unexpected stored uploads: %+v", repo.created)
+	}
+}
+
+// signatureScanner flags uploads containing the marker.
+type signatureScanner struct{ marker string }
+
+func (s signatureScanner) Name() string { return "signature" }
+func (s signatureScanner) Scan(_ context.Context, r io.Reader) (scanner.Verdict, error) {
+	data, err := io.ReadAll(r)
+	if err != nil || !bytes.Contains(data, []byte(s.marker)) {
+		return scanner.Verdict{Clean: true}, err
+	}
+	return scanner.Verdict{Signature: "Test.Marker"}, nil
+}
+
+func TestJobService_SubmitScansBeforeStoring(t *testing.T) {
+	repo := &mockJobsRepo{}
+	batches := &mockBatchesRepo{}
+	quarantine := &mockQuarantineRepo{}
+	opener := mockFileOpener{contents: map[string]string{"a.txt": "alpha", "evil.txt": "MARKER payload"}}
+	analyzer := services.NewAnalyzerServiceFull(&mockRepo{}, &mockPythonClient{}, nil, services.WithScanner(signatureScanner{marker: "MARKER"}, quarantine), services.WithBatches(batches))
+	jobs := services.NewJobServiceFull(repo, analyzer, opener, 1)
+
+	files := []*multipart.FileHeader{buildMemFileHeader("a.txt", "alpha"), buildMemFileHeader("evil.txt", "MARKER payload")}
+	if _, err := jobs.Submit(context.Background(), files, "en", "user", nil, services.AnalyzeOptions{}); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if string(repo.created[0].Content) != "alpha" || repo.created[0].Rejected != nil {
+		t.Fatalf("expected clean file stored, got %+v", repo.created[0])
+	}
+	evil := repo.created[1]
+	if evil.Content != nil || evil.Rejected == nil || evil.Rejected.ErrorKey != "FileRejected" {
+		t.Fatalf("expected rejected file stored without content, got %+v", evil)
+	}
+	if len(quarantine.events) != 1 || len(batches.failures) != 1 || batches.failures[0].FileName != "evil.txt" {
+		t.Fatalf("expected quarantine event and batch failure, got %+v %+v", quarantine.events, batches.failures)
+	}
+}
+
+func TestAnalysisJobsHandler_Get_InvalidID(t *testing.T) {
+	h := handlers.NewAnalysisJobsHandler(services.NewJobServiceFull(&mockJobsRepo{}, nil, nil, 1))
+	c, w := newTestContext()
+	c.Params = gin.Params{{Key: "id", Value: "not-a-uuid"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/analyze/jobs/not-a-uuid", nil)
+	h.Get(c)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 got %d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestAnalysisJobsHandler_Get_NotFound(t *testing.T) {
+	h := handlers.NewAnalysisJobsHandler(services.NewJobServiceFull(&mockJobsRepo{}, nil, nil, 1))
+	c, w := newTestContext()
+	id := "00000000-0000-0000-0000-000000000000"
+	c.Params = gin.Params{{Key: "id", Value: id}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/analyze/jobs/"+id, nil)
+	h.Get(c)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 got %d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestAnalysisJobsHandler_Submit_JobSizeCap(t *testing.T) {
+	old := config.JobMaxUploadBytes
+	config.JobMaxUploadBytes = 4
+	t.Cleanup(func() { config.JobMaxUploadBytes = old })
+	repo := &mockJobsRepo{}
+	h := handlers.NewAnalysisJobsHandler(services.NewJobServiceFull(repo, nil, nil, 1))
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, _ := w.CreateFormFile("documents", "a.txt")
+	part.Write([]byte("alpha"))
+	w.Close()
+	c, rec := newTestContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/analyze/jobs", &body)
+	c.Request.Header.Set("Content-Type", w.FormDataContentType())
+	h.Submit(c)
+
+	if rec.Code != http.StatusBadRequest || len(repo.created) != 0 {
+		t.Fatalf("expected 400 without a stored job, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
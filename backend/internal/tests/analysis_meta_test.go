@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"mime/multipart"
+	"testing"
+
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+func TestAnalyze_StoresMeta(t *testing.T) {
+	repo := &mockRepo{}
+	py := &mockPythonClient{respBody: `{"summary":"ok","fullText":"full content","meta":{"modelVersion":"v2","models":{"summarizer":{"name":"bart","version":"abc"}},"language":"en","tokens":{"input":120,"summary":30,"tokenizer":"bart"},"timingsMs":{"summary":812.5,"total":900}}}`}
+	opener := mockFileOpener{contents: map[string]string{"doc.txt": "content"}}
+	service := services.NewAnalyzerServiceFull(repo, py, opener)
+
+	res := service.AnalyzeFilesWithContext(context.Background(), []*multipart.FileHeader{buildMemFileHeader("doc.txt", "content")}, "en", "user", nil)
+	if len(res) != 1 || res[0].Data == nil || res[0].Data.Meta == nil {
+		t.Fatalf("expected meta in the result, got %+v", res)
+	}
+	meta := repo.lastMeta
+	if meta == nil || meta.ModelVersion != "v2" || meta.Language != "en" || meta.Tokens == nil || meta.Tokens.Input != 120 {
+		t.Fatalf("expected meta stored with the analysis, got %+v", meta)
+	}
+	if meta.Models["summarizer"].Version != "abc" || meta.TimingsMs["summary"] != 812.5 {
+		t.Fatalf("unexpected models or timings %+v", meta)
+	}
+}
+
+func TestAnalyze_ReuseChecksModelVersion(t *testing.T) {
+	opener := mockFileOpener{contents: map[string]string{"doc.txt": "content"}}
+	files := []*multipart.FileHeader{buildMemFileHeader("doc.txt", "content")}
+	fresh := `{"summary":"fresh","fullText":"content","meta":{"modelVersion":"v2"}}`
+
+	cases := []struct {
+		name       string
+		stored     *models.AnalysisMeta
+		modelsBody string
+		reused     bool
+	}{
+		{"same version", &models.AnalysisMeta{ModelVersion: "v2"}, `{"modelVersion":"v2"}`, true},
+		{"older version", &models.AnalysisMeta{ModelVersion: "v1"}, `{"modelVersion":"v2"}`, false},
+		{"stored without meta", nil, `{"modelVersion":"v2"}`, false},
+		{"backend reports no version", &models.AnalysisMeta{ModelVersion: "v1"}, "", true},
+	}
+	for _, tc := range cases {
+		repo := &mockRepo{findDocID: 4, latest: &models.AnalysisDetail{AnalysisID: 9, DocumentID: 4, Summary: "cached", FullText: "content", Meta: tc.stored}}
+		py := &mockPythonClient{respBody: fresh, modelsBody: tc.modelsBody}
+		service := services.NewAnalyzerServiceFull(repo, py, opener)
+		res := service.AnalyzeFilesWithContext(context.Background(), files, "en", "user", nil)
+		if len(res) != 1 || res[0].Reused != tc.reused {
+			t.Fatalf("%s: expected reused=%v, got %+v", tc.name, tc.reused, res)
+		}
+		if !tc.reused && (repo.updateTextCalls != 1 || repo.insertDocCalls != 0 || repo.lastMeta == nil || repo.lastMeta.ModelVersion != "v2") {
+			t.Fatalf("%s: expected a new version of the existing document, got text=%d doc=%d meta=%+v", tc.name, repo.updateTextCalls, repo.insertDocCalls, repo.lastMeta)
+		}
+	}
+}
+
+func TestAnalyze_ModelVersionCached(t *testing.T) {
+	repo := &mockRepo{findDocID: 4, latest: &models.AnalysisDetail{AnalysisID: 9, Summary: "cached", Meta: &models.AnalysisMeta{ModelVersion: "v2"}}}
+	py := &mockPythonClient{modelsBody: `{"modelVersion":"v2"}`}
+	opener := mockFileOpener{contents: map[string]string{"a.txt": "alpha", "b.txt": "beta"}}
+	service := services.NewAnalyzerServiceFull(repo, py, opener)
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		res := service.AnalyzeFilesWithContext(context.Background(), []*multipart.FileHeader{buildMemFileHeader(name, "x")}, "en", "user", nil)
+		if len(res) != 1 || !res[0].Reused {
+			t.Fatalf("expected %s reused, got %+v", name, res)
+		}
+	}
+	if py.modelCalls != 1 {
+		t.Fatalf("expected the model version fetched once, got %d", py.modelCalls)
+	}
+}
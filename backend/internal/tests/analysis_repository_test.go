@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+)
+
+// recordingDriver answers every query with a single row holding 1 and keeps the arguments of the
+// statements matching its prefix, as they reach the driver (after database/sql conversion).
+type recordingDriver struct {
+	mu     sync.Mutex
+	prefix string
+	args   [][]driver.Value
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return &recordingConn{d: d}, nil }
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{d: c.d, query: query}, nil
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *recordingConn) Commit() error             { return nil }
+func (c *recordingConn) Rollback() error           { return nil }
+
+type recordingStmt struct {
+	d     *recordingDriver
+	query string
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+func (s *recordingStmt) record(args []driver.Value) {
+	if strings.HasPrefix(strings.TrimSpace(s.query), s.d.prefix) {
+		s.d.mu.Lock()
+		s.d.args = append(s.d.args, args)
+		s.d.mu.Unlock()
+	}
+}
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.record(args)
+	return driver.RowsAffected(1), nil
+}
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.record(args)
+	return &oneRow{}, nil
+}
+
+type oneRow struct{ done bool }
+
+func (r *oneRow) Columns() []string { return []string{"v"} }
+func (r *oneRow) Close() error      { return nil }
+func (r *oneRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+var (
+	analysesDriver     = &recordingDriver{prefix: "INSERT INTO analyses"}
+	registerDriverOnce sync.Once
+)
+
+func TestInsertAnalysis_NilMetaIsNull(t *testing.T) {
+	registerDriverOnce.Do(func() { sql.Register("recording-analyses", analysesDriver) })
+	db, err := sql.Open("recording-analyses", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := repositories.NewAnalysisRepositoryWithDB(db)
+
+	for _, meta := range []*models.AnalysisMeta{nil, {ModelVersion: "v2"}} {
+		analysesDriver.args = nil
+		if _, err := repo.InsertAnalysis("user", 1, "s", []string{"k"}, "neutral", nil, nil, nil, "python", meta); err != nil {
+			t.Fatalf("insert failed: %v", err)
+		}
+		if len(analysesDriver.args) != 1 || len(analysesDriver.args[0]) != 11 {
+			t.Fatalf("expected one analyses insert with 11 args, got %v", analysesDriver.args)
+		}
+		got := analysesDriver.args[0][10]
+		if meta == nil {
+			// lib/pq only sends NULL for an untyped nil; a nil []byte would be an empty (invalid) JSONB value
+			if got != nil {
+				t.Fatalf("expected NULL meta, got %#v", got)
+			}
+			continue
+		}
+		raw, ok := got.([]byte)
+		if !ok || !json.Valid(raw) {
+			t.Fatalf("expected JSON meta, got %#v", got)
+		}
+	}
+}
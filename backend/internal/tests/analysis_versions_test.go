@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/analysisdiff"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/httpclient"
+	"github.com/samusafe/genericapi/internal/i18n"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+func TestAnalyze_ForceSkipsReuse(t *testing.T) {
+	repo := &mockRepo{findDocID: 7, latest: &models.AnalysisDetail{AnalysisID: 3, Summary: "cached sum", FullText: "old"}}
+	py := &mockPythonClient{respBody: `{"summary":"fresh","keywords":["a"],"sentiment":"neutral","fullText":"new text"}`}
+	opener := mockFileOpener{contents: map[string]string{"doc.txt": "content"}}
+	service := services.NewAnalyzerServiceFull(repo, py, opener)
+	files := []*multipart.FileHeader{buildMemFileHeader("doc.txt", "content")}
+
+	res := service.AnalyzeFilesWithOptions(context.Background(), files, "en", "user", nil, services.AnalyzeOptions{Force: true})
+	if len(res) != 1 || res[0].Reused || res[0].Data == nil || res[0].Data.Summary != "fresh" {
+		t.Fatalf("expected a fresh analysis, got %+v", res)
+	}
+	if py.textCalls != 1 {
+		t.Fatalf("expected the backend called once, got %d", py.textCalls)
+	}
+	if repo.insertDocCalls != 0 || repo.updateTextCalls != 1 || repo.reusedAnalysisCalls != 0 || repo.insertAnalysisCalls != 1 {
+		t.Fatalf("expected the existing document updated with a new version, got doc=%d text=%d reused=%d analysis=%d", repo.insertDocCalls, repo.updateTextCalls, repo.reusedAnalysisCalls, repo.insertAnalysisCalls)
+	}
+
+	// Without force the same upload is a reuse and keeps the stored version
+	repo = &mockRepo{findDocID: 7, latest: &models.AnalysisDetail{AnalysisID: 3, Summary: "cached sum", FullText: "old"}}
+	service = services.NewAnalyzerServiceFull(repo, py, opener)
+	res = service.AnalyzeFilesWithOptions(context.Background(), files, "en", "user", nil, services.AnalyzeOptions{})
+	if len(res) != 1 || !res[0].Reused || repo.reusedAnalysisCalls != 1 {
+		t.Fatalf("expected reuse without force, got %+v reused=%d", res, repo.reusedAnalysisCalls)
+	}
+}
+
+func TestReanalyzeDocument_UsesStoredText(t *testing.T) {
+	repo := &mockRepo{latest: &models.AnalysisDetail{DocumentID: 7, FileName: "doc.pdf", FullText: "stored text", AnalysisVersion: 1}}
+	py := &mockPythonClient{respBody: `{"summary":"again","keywords":["a"],"sentiment":"neutral","fullText":"stored text"}`}
+	service := services.NewAnalyzerServiceFull(repo, py, nil)
+
+	if _, err := service.ReanalyzeDocument(context.Background(), "user", 7, services.AnalyzeOptions{Force: true}); err != nil {
+		t.Fatalf("reanalyze failed: %v", err)
+	}
+	if py.textCalls != 1 || py.lastText != "stored text" {
+		t.Fatalf("expected the stored text sent to the text endpoint, got calls=%d text=%q", py.textCalls, py.lastText)
+	}
+	if repo.insertAnalysisCalls != 1 || repo.insertDocCalls != 0 {
+		t.Fatalf("expected one new analysis and no new document, got analysis=%d doc=%d", repo.insertAnalysisCalls, repo.insertDocCalls)
+	}
+
+	repo.latest = &models.AnalysisDetail{DocumentID: 7}
+	if _, err := service.ReanalyzeDocument(context.Background(), "user", 7, services.AnalyzeOptions{}); err != services.ErrNoStoredText {
+		t.Fatalf("expected ErrNoStoredText, got %v", err)
+	}
+}
+
+func TestAnalyzeHandler_Reanalyze(t *testing.T) {
+	i18n.Init()
+	cases := []struct {
+		name   string
+		repo   *mockRepo
+		py     *mockPythonClient
+		body   string
+		status int
+	}{
+		{"ok", &mockRepo{latest: &models.AnalysisDetail{FullText: "text"}}, &mockPythonClient{respBody: `{"summary":"s"}`}, "", http.StatusOK},
+		{"unknown backend", &mockRepo{}, &mockPythonClient{}, `{"backend":"nope"}`, http.StatusBadRequest},
+		{"backend down", &mockRepo{latest: &models.AnalysisDetail{FullText: "text"}}, &mockPythonClient{respErr: httpclient.ErrPythonUnavailable}, "", http.StatusServiceUnavailable},
+		{"malformed", &mockRepo{latest: &models.AnalysisDetail{FullText: "text"}}, &mockPythonClient{respBody: "not json"}, "", http.StatusBadGateway},
+	}
+	for _, tc := range cases {
+		h := handlers.NewAnalyzeHandler(services.NewAnalyzerServiceFull(tc.repo, tc.py, nil))
+		c, w := newTestContext()
+		c.Params = gin.Params{{Key: "documentId", Value: "7"}}
+		c.Request = httptest.NewRequest(http.MethodPost, "/documents/7/reanalyze", strings.NewReader(tc.body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.Reanalyze(c)
+		if w.Code != tc.status {
+			t.Fatalf("%s: expected %d got %d body=%s", tc.name, tc.status, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestJobService_SubmitRecordsForce(t *testing.T) {
+	repo := &mockJobsRepo{}
+	opener := mockFileOpener{contents: map[string]string{"a.txt": "alpha"}}
+	jobs := services.NewJobServiceFull(repo, services.NewAnalyzerServiceFull(&mockRepo{}, &mockPythonClient{}, nil), opener, 1)
+	if _, err := jobs.Submit(context.Background(), []*multipart.FileHeader{buildMemFileHeader("a.txt", "alpha")}, "en", "user", nil, services.AnalyzeOptions{Force: true}); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if !repo.force {
+		t.Fatalf("expected force stored on the job")
+	}
+}
+
+func TestAnalysisDiff_Compare(t *testing.T) {
+	from := models.AnalysisVersion{Version: 1, Summary: "Old summary.", Sentiment: "neutral", Keywords: []string{"Go", "databases", "go"}, SummaryPoints: []string{"First point.", "Second point."}}
+	to := models.AnalysisVersion{Version: 2, Summary: "old summary", Sentiment: "positive", Keywords: []string{"go", "Concurrency"}, SummaryPoints: []string{"Second point", "Third point."}}
+
+	diff := analysisdiff.Compare(from, to)
+	if diff.From != 1 || diff.To != 2 {
+		t.Fatalf("unexpected versions %d -> %d", diff.From, diff.To)
+	}
+	if !slices.Equal(diff.Keywords.Added, []string{"Concurrency"}) || !slices.Equal(diff.Keywords.Removed, []string{"databases"}) || !slices.Equal(diff.Keywords.Unchanged, []string{"go"}) {
+		t.Fatalf("unexpected keyword diff %+v", diff.Keywords)
+	}
+	if !slices.Equal(diff.SummaryPoints.Added, []string{"Third point."}) || !slices.Equal(diff.SummaryPoints.Removed, []string{"First point."}) || !slices.Equal(diff.SummaryPoints.Unchanged, []string{"Second point"}) {
+		t.Fatalf("unexpected summary point diff %+v", diff.SummaryPoints)
+	}
+	if !diff.Sentiment.Changed || diff.Sentiment.From != "neutral" || diff.Sentiment.To != "positive" {
+		t.Fatalf("unexpected sentiment diff %+v", diff.Sentiment)
+	}
+	if diff.SummaryChanged {
+		t.Fatalf("expected summaries differing only in case and punctuation to compare equal")
+	}
+}
+
+func TestAnalysisHistory_ListVersions(t *testing.T) {
+	versions := []models.AnalysisVersion{{Version: 1, Uses: 3}, {Version: 2, Uses: 1}}
+	aRepo := &mockAnalysisRepo2{versionsFn: func(string, int) ([]models.AnalysisVersion, error) { return versions, nil }}
+	h := handlers.NewAnalysisHistoryHandler(aRepo, &mockCollectionsRepo2{})
+	c, w := newHistoryContext()
+	c.Params = gin.Params{{Key: "documentId", Value: "10"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/documents/10/analyses", nil)
+	h.ListVersions(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+	}
+	var env envWrapper
+	decodeEnv(t, w, &env)
+	if list, ok := env.Data["versions"].([]any); !ok || len(list) != 2 {
+		t.Fatalf("expected 2 versions, got %v", env.Data["versions"])
+	}
+
+	aRepo.versionsFn = func(string, int) ([]models.AnalysisVersion, error) { return nil, sql.ErrNoRows }
+	c, w = newHistoryContext()
+	c.Params = gin.Params{{Key: "documentId", Value: "10"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/documents/10/analyses", nil)
+	h.ListVersions(c)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 got %d", w.Code)
+	}
+}
+
+func TestAnalysisHistory_DiffVersions(t *testing.T) {
+	i18n.Init()
+	versions := []models.AnalysisVersion{
+		{Version: 1, Sentiment: "neutral", Keywords: []string{"a"}},
+		{Version: 2, Sentiment: "neutral", Keywords: []string{"a", "b"}},
+		{Version: 3, Sentiment: "positive", Keywords: []string{"c"}},
+	}
+	aRepo := &mockAnalysisRepo2{versionsFn: func(string, int) ([]models.AnalysisVersion, error) { return versions, nil }}
+	h := handlers.NewAnalysisHistoryHandler(aRepo, &mockCollectionsRepo2{})
+
+	cases := []struct {
+		query    string
+		status   int
+		from, to float64
+	}{
+		{"", http.StatusOK, 2, 3},
+		{"?from=1&to=2", http.StatusOK, 1, 2},
+		{"?to=1", http.StatusOK, 1, 1},
+		{"?from=9", http.StatusNotFound, 0, 0},
+		{"?to=abc", http.StatusBadRequest, 0, 0},
+	}
+	for _, tc := range cases {
+		c, w := newHistoryContext()
+		c.Params = gin.Params{{Key: "documentId", Value: "10"}}
+		c.Request = httptest.NewRequest(http.MethodGet, "/documents/10/analyses/diff"+tc.query, nil)
+		h.DiffVersions(c)
+		if w.Code != tc.status {
+			t.Fatalf("%q: expected %d got %d body=%s", tc.query, tc.status, w.Code, w.Body.String())
+		}
+		if tc.status != http.StatusOK {
+			continue
+		}
+		var env envWrapper
+		decodeEnv(t, w, &env)
+		diff, _ := env.Data["diff"].(map[string]any)
+		if diff["from"] != tc.from || diff["to"] != tc.to {
+			t.Fatalf("%q: expected %v -> %v, got %v", tc.query, tc.from, tc.to, diff)
+		}
+	}
+}
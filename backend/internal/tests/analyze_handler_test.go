@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+// newUploadRequest builds a multipart /analyze request with the given files.
+func newUploadRequest(t *testing.T, target string, files map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, content := range files {
+		part, err := w.CreateFormFile("documents", name)
+		if err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		part.Write([]byte(content))
+	}
+	w.Close()
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestAnalyzeHandler_StreamSSE(t *testing.T) {
+	repo := &mockRepo{findDocID: 0}
+	py := &mockPythonClient{respBody: `{"summary":"ok","keywords":["a"],"sentiment":"neutral","fullText":"full content"}`}
+	service := services.NewAnalyzerServiceFull(repo, py, nil)
+	h := handlers.NewAnalyzeHandler(service)
+
+	c, w := newTestContext()
+	c.Request = newUploadRequest(t, "/analyze?stream=sse", map[string]string{"a.txt": "alpha", "b.exe": "beta"})
+	h.Analyze(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("expected event-stream content type, got %q", ct)
+	}
+	out := w.Body.String()
+	if n := strings.Count(out, "event:result"); n != 2 {
+		t.Fatalf("expected 2 result events, got %d: %s", n, out)
+	}
+	summaryAt := strings.Index(out, "event:summary")
+	if summaryAt < strings.LastIndex(out, "event:result") {
+		t.Fatalf("expected summary as the last event: %s", out)
+	}
+	summary := out[summaryAt:]
+	if !strings.Contains(summary, `"succeeded":1`) || !strings.Contains(summary, `"failed":1`) || !strings.Contains(summary, `"batchId"`) {
+		t.Fatalf("unexpected summary event: %s", summary)
+	}
+	if !strings.Contains(summary, `"correlationId":"cid-test"`) {
+		t.Fatalf("expected correlation id in summary: %s", summary)
+	}
+}
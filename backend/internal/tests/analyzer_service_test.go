@@ -3,12 +3,15 @@ package tests
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/samusafe/genericapi/internal/blobstore"
 	"github.com/samusafe/genericapi/internal/httpclient"
 	"github.com/samusafe/genericapi/internal/models"
 	"github.com/samusafe/genericapi/internal/services"
@@ -21,15 +24,25 @@ type mockRepo struct {
 	latest              *models.AnalysisDetail
 	insertDocCalls      int
 	insertAnalysisCalls int
+	reusedAnalysisCalls int
+	updateTextCalls     int
+	lastBackend         string
+	lastMeta            *models.AnalysisMeta
+	lastBatchID         *string
+	lastDetectedType    string
 	listAllFn           func(userID string, limit, offset int) ([]models.DocumentItem, int, error)
 }
 
-func (m *mockRepo) InsertDocument(userID string, collectionID *int, fileName, fullText string, contentHash string) (int, error) {
+func (m *mockRepo) InsertDocument(userID string, collectionID *int, fileName, fullText string, contentHash string, fileExt, detectedType string) (int, error) {
 	m.insertDocCalls++
+	m.lastDetectedType = detectedType
 	return 101, nil
 }
-func (m *mockRepo) InsertAnalysis(userID string, documentID int, summary string, keywords []string, sentiment string, summaryPoints []string, batchID *string, batchSize *int) (int, error) {
+func (m *mockRepo) InsertAnalysis(userID string, documentID int, summary string, keywords []string, sentiment string, summaryPoints []string, batchID *string, batchSize *int, backend string, meta *models.AnalysisMeta) (int, error) {
 	m.insertAnalysisCalls++
+	m.lastBackend = backend
+	m.lastMeta = meta
+	m.lastBatchID = batchID
 	return 201, nil
 }
 func (m *mockRepo) FindDocument(userID string, collectionID *int, contentHash string) (int, error) {
@@ -50,15 +63,57 @@ func (m *mockRepo) ListAllDocuments(userID string, limit, offset int) ([]models.
 func (m *mockRepo) UpdateDocumentCollection(userID string, documentID int, collectionID int) error {
 	return nil
 }
+func (m *mockRepo) InsertReusedAnalysis(userID string, sourceAnalysisID int, batchID *string, batchSize *int) (int, error) {
+	m.insertAnalysisCalls++
+	m.reusedAnalysisCalls++
+	return 202, nil
+}
+func (m *mockRepo) UpdateDocumentText(userID string, documentID int, fullText string) error {
+	m.updateTextCalls++
+	return nil
+}
+func (m *mockRepo) ListAnalysisVersions(userID string, documentID int) ([]models.AnalysisVersion, error) {
+	return nil, nil
+}
 
 // mockPythonClient simulates python client responses.
 type mockPythonClient struct {
-	respBody string
-	respErr  error
-	status   int
+	respBody   string
+	respErr    error
+	status     int
+	textCalls  int
+	lastText   string
+	lastFile   []byte
+	embedBody  string
+	embeds     int
+	answerBody string
+	lastAnswer models.AnswerRequest
+	quizBody   string
+	lastQuiz   string
+	modelsBody string
+	modelCalls int
+	sumBody    string
+	lastSum    string
+	closed     int
+}
+
+// trackedBody counts Close calls, like a connection returned to the pool.
+type trackedBody struct {
+	io.Reader
+	closed *int
 }
 
-func (m *mockPythonClient) AnalyzeWithCtx(ctx context.Context, file []byte, filename string, correlationID string) (*http.Response, error) {
+func (b trackedBody) Close() error {
+	*b.closed++
+	return nil
+}
+
+func (m *mockPythonClient) AnalyzeWithCtx(ctx context.Context, file io.ReadSeeker, filename string, correlationID string) (*http.Response, error) {
+	m.lastFile, _ = io.ReadAll(file)
+	if m.respErr != nil && m.status != 0 {
+		// Like the real client: bad statuses come with the response
+		return &http.Response{StatusCode: m.status, Body: trackedBody{strings.NewReader(m.respBody), &m.closed}}, m.respErr
+	}
 	if m.respErr != nil {
 		return nil, m.respErr
 	}
@@ -67,8 +122,50 @@ func (m *mockPythonClient) AnalyzeWithCtx(ctx context.Context, file []byte, file
 	}
 	return &http.Response{StatusCode: m.status, Body: io.NopCloser(bytes.NewBufferString(m.respBody))}, nil
 }
+func (m *mockPythonClient) AnalyzeTextWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error) {
+	m.textCalls++
+	m.lastText = text
+	return m.AnalyzeWithCtx(ctx, strings.NewReader(text), "", correlationID)
+}
+func (m *mockPythonClient) EmbedWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error) {
+	m.embeds++
+	body := m.embedBody
+	if body == "" {
+		body = `{"embedding":[1,0],"model":"test-model","dimensions":2}`
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+}
+func (m *mockPythonClient) AnswerWithCtx(ctx context.Context, body []byte, correlationID string) (*http.Response, error) {
+	if m.respErr != nil {
+		return nil, m.respErr
+	}
+	json.Unmarshal(body, &m.lastAnswer)
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(m.answerBody))}, nil
+}
 func (m *mockPythonClient) GenerateQuizWithCtx(ctx context.Context, body []byte, correlationID string) (*http.Response, error) {
-	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`{"questions":[]}`))}, nil
+	m.lastQuiz = string(body)
+	resp := m.quizBody
+	if resp == "" {
+		resp = `{"questions":[]}`
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(resp))}, nil
+}
+
+func (m *mockPythonClient) SummarizeWithCtx(ctx context.Context, text string, correlationID string) (*http.Response, error) {
+	if m.respErr != nil {
+		return nil, m.respErr
+	}
+	m.lastSum = text
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(m.sumBody))}, nil
+}
+
+func (m *mockPythonClient) ModelsWithCtx(ctx context.Context, correlationID string) (*http.Response, error) {
+	m.modelCalls++
+	if m.modelsBody == "" {
+		// like a backend without GET /models
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, httpclient.ErrBadStatus
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(m.modelsBody))}, nil
 }
 
 // memFile implements multipart.File (Read, ReadAt, Seek, Close)
@@ -199,6 +296,24 @@ func TestAnalyze_NewDocumentSuccess(t *testing.T) {
 	}
 }
 
+func TestAnalyze_BackendRejectsFile(t *testing.T) {
+	batches := &mockBatchesRepo{}
+	py := &mockPythonClient{respErr: httpclient.ErrBadStatus, status: http.StatusUnprocessableEntity, respBody: `{"detail":"unreadable"}`}
+	opener := mockFileOpener{contents: map[string]string{"a.txt": "alpha", "b.txt": "beta"}}
+	service := services.NewAnalyzerServiceFull(&mockRepo{}, py, opener, services.WithBatches(batches), services.WithBlobStore(blobstore.NewLocal(t.TempDir())))
+	files := []*multipart.FileHeader{buildMemFileHeader("a.txt", "alpha"), buildMemFileHeader("b.txt", "beta")}
+	res := service.AnalyzeFilesWithOptions(context.Background(), files, "en", "user", nil, services.AnalyzeOptions{})
+	if len(res) != 2 || res[0].ErrorKey != "AnalysisRejected" {
+		t.Fatalf("expected a non-retryable rejection, got %+v", res)
+	}
+	if py.closed != 2 {
+		t.Fatalf("expected every rejected response body closed, got %d", py.closed)
+	}
+	if len(batches.failures) != 2 || batches.failures[0].ContentStored {
+		t.Fatalf("a rejected file must not be kept for retry, got %+v", batches.failures)
+	}
+}
+
 // ensure mockPythonClient errors propagate classification
 func TestAnalyze_PythonBadStatus(t *testing.T) {
 	badStatusErr := httpclient.ErrBadStatus
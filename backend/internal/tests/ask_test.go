@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/chunk"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/httpclient"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+type mockChunksRepo struct {
+	stored     map[int][]models.DocumentChunk
+	unchunked  []models.DocumentText
+	results    []models.DocumentChunk
+	searchLang string
+}
+
+func (m *mockChunksRepo) ReplaceChunks(userID string, documentID int, chunks []models.DocumentChunk) error {
+	if m.stored == nil {
+		m.stored = map[int][]models.DocumentChunk{}
+	}
+	m.stored[documentID] = chunks
+	return nil
+}
+func (m *mockChunksRepo) ListUnchunkedDocuments(userID string, collectionID int) ([]models.DocumentText, error) {
+	return m.unchunked, nil
+}
+func (m *mockChunksRepo) SearchChunks(userID string, collectionID int, question, lang string, limit int) ([]models.DocumentChunk, error) {
+	m.searchLang = lang
+	return m.results, nil
+}
+
+func TestChunk_SplitOffsetsAndBoundaries(t *testing.T) {
+	text := strings.Repeat("Olá mundo, isto é uma frase. ", 30) + "\n\n" + strings.Repeat("Second paragraph words here. ", 30)
+	runes := []rune(text)
+	chunks := chunk.Split(text, 200, 40)
+	if len(chunks) < 5 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if c.Index != i || string(runes[c.Start:c.End]) != c.Text || utf8.RuneCountInString(c.Text) > 200 {
+			t.Fatalf("chunk %d does not match its offsets: %+v", i, c)
+		}
+		if i > 0 {
+			prev := chunks[i-1]
+			if c.Start >= prev.End || c.Start <= prev.Start {
+				t.Fatalf("expected chunk %d to overlap the previous one: prev=[%d,%d) cur=[%d,%d)", i, prev.Start, prev.End, c.Start, c.End)
+			}
+			if runes[c.Start-1] != ' ' && runes[c.Start-1] != '\n' {
+				t.Fatalf("expected chunk %d to start at a word boundary", i)
+			}
+		}
+		if c.End < len(runes) && !strings.HasSuffix(c.Text, ". ") && !strings.HasSuffix(c.Text, "\n") {
+			t.Fatalf("expected chunk %d to end at a sentence boundary, got %q", i, c.Text[len(c.Text)-10:])
+		}
+	}
+	if last := chunks[len(chunks)-1]; last.End != len(runes) {
+		t.Fatalf("expected the text to be covered to the end")
+	}
+	if chunk.Split("   ", 100, 10) != nil {
+		t.Fatalf("expected no chunks for blank text")
+	}
+}
+
+func TestQAService_MapsCitationsToDocumentOffsets(t *testing.T) {
+	repo := &mockChunksRepo{
+		unchunked: []models.DocumentText{{ID: 4, FullText: "Old document text."}},
+		results: []models.DocumentChunk{
+			{DocumentID: 7, FileName: "budget.pdf", Start: 1000, End: 1040, Content: "O orçamento de 2024 é de 3 milhões."},
+		},
+	}
+	py := &mockPythonClient{answerBody: `{"answer":"3 milhões","score":0.9,"citations":[{"contextId":0,"start":25,"end":34},{"contextId":5,"start":0,"end":1}]}`}
+	qa := services.NewQAService(repo, httpclient.NewRegistry("python", py))
+
+	res, err := qa.Ask(context.Background(), "user", 1, "Qual é o orçamento?", "pt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.stored[4]) != 1 {
+		t.Fatalf("expected unchunked document chunked before retrieval, got %+v", repo.stored)
+	}
+	if repo.searchLang != "pt" || py.lastAnswer.Question != "Qual é o orçamento?" || len(py.lastAnswer.Contexts) != 1 {
+		t.Fatalf("unexpected retrieval/answer request: lang=%s req=%+v", repo.searchLang, py.lastAnswer)
+	}
+	if res.Answer != "3 milhões" || len(res.Citations) != 1 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	c := res.Citations[0]
+	if c.DocumentID != 7 || c.Start != 1025 || c.End != 1034 || c.Quote != "3 milhões" {
+		t.Fatalf("unexpected citation %+v", c)
+	}
+
+	repo.results = nil
+	if _, err := qa.Ask(context.Background(), "user", 1, "anything", "en"); err != services.ErrNoRelevantContent {
+		t.Fatalf("expected ErrNoRelevantContent, got %v", err)
+	}
+}
+
+func TestAskHandler_Responses(t *testing.T) {
+	repo := &mockChunksRepo{}
+	qa := services.NewQAService(repo, httpclient.NewRegistry("python", &mockPythonClient{}))
+	cols := &mockCollectionsRepo{existsForUserFn: func(userID string, id int) (bool, error) { return id == 1, nil }}
+	h := handlers.NewAskHandler(cols, qa)
+
+	cases := []struct {
+		id, body string
+		want     int
+	}{
+		{"1", `{"question":"  "}`, http.StatusBadRequest},
+		{"1", `{"question":"` + strings.Repeat("a", 600) + `"}`, http.StatusBadRequest},
+		{"2", `{"question":"why?"}`, http.StatusNotFound},
+		{"1", `{"question":"why?"}`, http.StatusNotFound}, // nothing retrieved
+	}
+	for _, tc := range cases {
+		c, w := newTestContext()
+		c.Params = gin.Params{{Key: "id", Value: tc.id}}
+		c.Request = httptest.NewRequest(http.MethodPost, "/collections/"+tc.id+"/ask", strings.NewReader(tc.body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.Ask(c)
+		if w.Code != tc.want {
+			t.Errorf("collection %s body %.20s: expected %d got %d", tc.id, tc.body, tc.want, w.Code)
+		}
+	}
+}
+
+func TestAnalyzerService_StoresChunksForNewDocuments(t *testing.T) {
+	chunks := &mockChunksRepo{}
+	py := &mockPythonClient{respBody: `{"summary":"ok","fullText":"Some extracted text."}`}
+	service := services.NewAnalyzerServiceFull(&mockRepo{}, py, nil, services.WithChunks(chunks))
+	if res := service.AnalyzeContent(context.Background(), "a.txt", []byte("x"), "en", "user", nil, nil, nil, services.AnalyzeOptions{}); res.Error != "" {
+		t.Fatalf("unexpected error %s", res.Error)
+	}
+	if got := chunks.stored[101]; len(got) != 1 || got[0].Content != "Some extracted text." {
+		t.Fatalf("expected chunks stored for the new document, got %+v", chunks.stored)
+	}
+}
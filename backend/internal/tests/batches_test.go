@@ -0,0 +1,341 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/blobstore"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/httpclient"
+	"github.com/samusafe/genericapi/internal/i18n"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+type mockBatchesRepo struct {
+	mu       sync.Mutex
+	failures []models.AnalysisFailure
+	items    []models.BatchItem
+	batch    *models.BatchDetail
+	released []string
+	claimed  map[int]bool
+}
+
+func (m *mockBatchesRepo) RecordFailure(f models.AnalysisFailure) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f.ID = len(m.failures) + 1
+	m.failures = append(m.failures, f)
+	return nil
+}
+func (m *mockBatchesRepo) ListBatches(userID string, limit, offset int) ([]models.BatchItem, int, error) {
+	return m.items, len(m.items), nil
+}
+func (m *mockBatchesRepo) GetBatch(userID, batchID string) (*models.BatchDetail, error) {
+	if m.batch == nil || m.batch.BatchID != batchID {
+		return nil, sql.ErrNoRows
+	}
+	return m.batch, nil
+}
+
+// ClaimRetryableFailures hands out the unclaimed failures whose upload was kept.
+func (m *mockBatchesRepo) ClaimRetryableFailures(userID, batchID string, lease time.Duration) ([]models.AnalysisFailure, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var claimed []models.AnalysisFailure
+	for i, f := range m.failures {
+		if f.ContentStored && !m.claimed[f.ID] {
+			if m.claimed == nil {
+				m.claimed = map[int]bool{}
+			}
+			m.claimed[f.ID] = true
+			claimed = append(claimed, m.failures[i])
+		}
+	}
+	return claimed, nil
+}
+func (m *mockBatchesRepo) ResolveFailure(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures = slices.DeleteFunc(m.failures, func(f models.AnalysisFailure) bool { return f.ID == id })
+	delete(m.claimed, id)
+	return nil
+}
+func (m *mockBatchesRepo) ReleaseFailure(id int, errorKey string, contentStored bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.failures {
+		if m.failures[i].ID == id {
+			m.failures[i].ErrorKey, m.failures[i].ContentStored = errorKey, contentStored
+		}
+	}
+	delete(m.claimed, id)
+	return nil
+}
+
+// UnreferencedHashes keeps the hashes of failures still waiting for a retry (no documents here).
+func (m *mockBatchesRepo) UnreferencedHashes(hashes []string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.released = append(m.released, hashes...)
+	return slices.DeleteFunc(slices.Clone(hashes), func(h string) bool {
+		return slices.ContainsFunc(m.failures, func(f models.AnalysisFailure) bool { return f.ContentStored && f.ContentHash == h })
+	}), nil
+}
+
+// batchOf exposes the recorded failures as the batch detail GetBatch returns.
+func (m *mockBatchesRepo) batchOf(batchID string) {
+	m.batch = &models.BatchDetail{BatchItem: models.BatchItem{BatchID: batchID, FileCount: len(m.failures)}}
+	for _, f := range m.failures {
+		m.batch.Failures = append(m.batch.Failures, models.BatchFailure{ID: f.ID, FileName: f.FileName, ErrorKey: f.ErrorKey, Retryable: f.ContentStored})
+	}
+}
+
+func TestAnalyze_RecordsBatchFailures(t *testing.T) {
+	batches := &mockBatchesRepo{}
+	py := &mockPythonClient{respBody: `{"summary":"ok","fullText":"content"}`}
+	opener := mockFileOpener{contents: map[string]string{"a.txt": "alpha"}}
+	service := services.NewAnalyzerServiceFull(&mockRepo{}, py, opener, services.WithBatches(batches))
+
+	files := []*multipart.FileHeader{buildMemFileHeader("a.txt", "alpha"), buildMemFileHeader("b.exe", "beta")}
+	res := service.AnalyzeFilesWithOptions(context.Background(), files, "en", "user", nil, services.AnalyzeOptions{Force: true})
+	if len(res) != 2 {
+		t.Fatalf("expected 2 results, got %+v", res)
+	}
+	if len(batches.failures) != 1 {
+		t.Fatalf("expected one failure recorded, got %+v", batches.failures)
+	}
+	f := batches.failures[0]
+	if f.FileName != "b.exe" || f.ErrorKey != "UnsupportedFileType" || f.BatchID == "" || f.BatchSize != 2 || !f.Force || f.ContentHash != "" {
+		t.Fatalf("unexpected failure %+v", f)
+	}
+	for _, r := range res {
+		if r.FileName == "b.exe" && r.ErrorKey != "UnsupportedFileType" {
+			t.Fatalf("expected the error key on the result, got %+v", r)
+		}
+	}
+
+	// A single-file upload is not a batch
+	batches.failures = nil
+	service.AnalyzeFilesWithContext(context.Background(), []*multipart.FileHeader{buildMemFileHeader("c.exe", "x")}, "en", "user", nil)
+	if len(batches.failures) != 0 {
+		t.Fatalf("expected no failure recorded for a single upload, got %+v", batches.failures)
+	}
+}
+
+func TestBatchesHandler_Get(t *testing.T) {
+	i18n.Init()
+	const id = "0b7e5c1e-0d7e-4f0c-9a57-8a4e1b2f9c10"
+	repo := &mockBatchesRepo{batch: &models.BatchDetail{
+		BatchItem: models.BatchItem{BatchID: id, FileCount: 2, Succeeded: 1, Failed: 1},
+		Failures:  []models.BatchFailure{{ID: 1, FileName: "b.exe", ErrorKey: "UnsupportedFileType"}},
+	}}
+	h := handlers.NewBatchesHandler(repo, nil)
+
+	cases := []struct {
+		id     string
+		status int
+	}{
+		{id, http.StatusOK},
+		{"4d1c2a8e-9f5b-4e63-b1a0-3c2d7e6f5a41", http.StatusNotFound},
+		{"not-a-uuid", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		c, w := newTestContext()
+		c.Params = gin.Params{{Key: "id", Value: tc.id}}
+		c.Request = httptest.NewRequest(http.MethodGet, "/batches/"+tc.id, nil)
+		h.Get(c)
+		if w.Code != tc.status {
+			t.Fatalf("%s: expected %d got %d body=%s", tc.id, tc.status, w.Code, w.Body.String())
+		}
+	}
+	if got := repo.batch.Failures[0].Error; got != i18n.GetMessage("en", "UnsupportedFileType") {
+		t.Fatalf("expected the failure translated, got %q", got)
+	}
+}
+
+func TestBatchesHandler_List(t *testing.T) {
+	repo := &mockBatchesRepo{items: []models.BatchItem{{BatchID: "b1", FileCount: 3, Succeeded: 2, Failed: 1}}}
+	h := handlers.NewBatchesHandler(repo, nil)
+	c, w := newTestContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/batches?page=1", nil)
+	h.List(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", w.Code)
+	}
+	var env envWrapper
+	decodeEnv(t, w, &env)
+	if items, ok := env.Data["items"].([]any); !ok || len(items) != 1 || env.Data["total"] != float64(1) {
+		t.Fatalf("unexpected list %v", env.Data)
+	}
+}
+
+func TestRetryBatch_RetriesKeptUploads(t *testing.T) {
+	i18n.Init()
+	batches := &mockBatchesRepo{}
+	store := blobstore.NewLocal(t.TempDir())
+	repo := &mockRepo{}
+	py := &mockPythonClient{respErr: httpclient.ErrPythonUnavailable}
+	opener := mockFileOpener{contents: map[string]string{"a.txt": "alpha", "b.exe": "beta"}}
+	service := services.NewAnalyzerServiceFull(repo, py, opener, services.WithBatches(batches), services.WithBlobStore(store))
+
+	files := []*multipart.FileHeader{buildMemFileHeader("a.txt", "alpha"), buildMemFileHeader("b.exe", "beta")}
+	service.AnalyzeFilesWithOptions(context.Background(), files, "en", "user", nil, services.AnalyzeOptions{Force: true})
+	if len(batches.failures) != 2 {
+		t.Fatalf("expected both files recorded, got %+v", batches.failures)
+	}
+	var batchID string
+	for _, f := range batches.failures {
+		batchID = f.BatchID
+		if kept := f.FileName == "a.txt"; f.ContentStored != kept {
+			t.Fatalf("expected only the transient failure kept, got %+v", f)
+		}
+	}
+	if got := readBlob(t, store, hashOf([]byte("alpha"))); got != "alpha" {
+		t.Fatalf("expected the upload kept for retry, got %q", got)
+	}
+
+	// The backend is back: only a.txt is analyzed again, into the same batch
+	batches.batchOf(batchID)
+	py.respErr = nil
+	py.respBody = `{"summary":"ok","fullText":"alpha"}`
+	res, err := service.RetryBatch(context.Background(), "user", batchID, "en")
+	if err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	if len(res) != 2 || res[0].ErrorKey != "UnsupportedFileType" || res[1].FileName != "a.txt" || res[1].Error != "" {
+		t.Fatalf("unexpected merged results %+v", res)
+	}
+	if py.textCalls != 2 || repo.insertAnalysisCalls != 1 || repo.lastBatchID == nil || *repo.lastBatchID != batchID {
+		t.Fatalf("expected one new analysis in batch %s, got calls=%d analyses=%d batch=%v", batchID, py.textCalls, repo.insertAnalysisCalls, repo.lastBatchID)
+	}
+	if len(batches.failures) != 1 || len(batches.released) != 1 {
+		t.Fatalf("expected the retried failure claimed and its upload released, got %+v released=%v", batches.failures, batches.released)
+	}
+
+	// Nothing is left to retry
+	py.textCalls = 0
+	batches.batchOf(batchID)
+	if _, err := service.RetryBatch(context.Background(), "user", batchID, "en"); err != nil || py.textCalls != 0 {
+		t.Fatalf("expected no second retry, got err=%v calls=%d", err, py.textCalls)
+	}
+}
+
+func TestBatchesHandler_Retry(t *testing.T) {
+	i18n.Init()
+	const id = "0b7e5c1e-0d7e-4f0c-9a57-8a4e1b2f9c10"
+	newBatches := func() *mockBatchesRepo {
+		b := &mockBatchesRepo{failures: []models.AnalysisFailure{{ID: 1, UserID: "user-1", BatchID: id, BatchSize: 2, FileName: "a.txt", ContentHash: hashOf([]byte("alpha")), ErrorKey: "PythonServiceUnavailable", ContentStored: true}}}
+		b.batchOf(id)
+		return b
+	}
+
+	cases := []struct {
+		name   string
+		id     string
+		py     *mockPythonClient
+		status int
+	}{
+		{"recovered", id, &mockPythonClient{respBody: `{"summary":"ok","fullText":"alpha"}`}, http.StatusOK},
+		{"still down", id, &mockPythonClient{respErr: httpclient.ErrPythonUnavailable}, http.StatusServiceUnavailable},
+		{"unknown batch", "4d1c2a8e-9f5b-4e63-b1a0-3c2d7e6f5a41", &mockPythonClient{}, http.StatusNotFound},
+		{"invalid id", "nope", &mockPythonClient{}, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		batches := newBatches()
+		store := blobstore.NewLocal(t.TempDir())
+		if err := store.Put(context.Background(), hashOf([]byte("alpha")), strings.NewReader("alpha"), 5); err != nil {
+			t.Fatal(err)
+		}
+		service := services.NewAnalyzerServiceFull(&mockRepo{}, tc.py, nil, services.WithBatches(batches), services.WithBlobStore(store))
+		h := handlers.NewBatchesHandler(batches, service)
+		c, w := newTestContext()
+		c.Params = gin.Params{{Key: "id", Value: tc.id}}
+		c.Request = httptest.NewRequest(http.MethodPost, "/batches/"+tc.id+"/retry", nil)
+		h.Retry(c)
+		if w.Code != tc.status {
+			t.Fatalf("%s: expected %d got %d body=%s", tc.name, tc.status, w.Code, w.Body.String())
+		}
+	}
+}
+
+// streamOnlyStore hides the seekable files of the local store, like a remote (S3) store.
+type streamOnlyStore struct{ blobstore.BlobStore }
+
+func (s streamOnlyStore) Get(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	rc, size, err := s.BlobStore.Get(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{rc, rc}, size, nil
+}
+
+func TestRetryBatch_StreamsUnseekableBlobs(t *testing.T) {
+	i18n.Init()
+	const id = "0b7e5c1e-0d7e-4f0c-9a57-8a4e1b2f9c10"
+	batches := &mockBatchesRepo{failures: []models.AnalysisFailure{{ID: 1, UserID: "user", BatchID: id, BatchSize: 2, FileName: "a.txt", ContentHash: hashOf([]byte("alpha")), ErrorKey: "PythonServiceUnavailable", ContentStored: true}}}
+	batches.batchOf(id)
+	local := blobstore.NewLocal(t.TempDir())
+	if err := local.Put(context.Background(), hashOf([]byte("alpha")), strings.NewReader("alpha"), 5); err != nil {
+		t.Fatal(err)
+	}
+	py := &mockPythonClient{respBody: `{"summary":"ok","fullText":"alpha"}`}
+	service := services.NewAnalyzerServiceFull(&mockRepo{}, py, nil, services.WithBatches(batches), services.WithBlobStore(streamOnlyStore{local}))
+
+	res, err := service.RetryBatch(context.Background(), "user", id, "en")
+	if err != nil || len(res) != 1 || res[0].Error != "" || py.textCalls != 1 {
+		t.Fatalf("expected the spilled upload analyzed, got err=%v res=%+v calls=%d", err, res, py.textCalls)
+	}
+}
+
+func TestRetryBatch_FailedRetryKeepsItsFailure(t *testing.T) {
+	i18n.Init()
+	const id = "0b7e5c1e-0d7e-4f0c-9a57-8a4e1b2f9c10"
+	batches := &mockBatchesRepo{failures: []models.AnalysisFailure{
+		{ID: 1, UserID: "user", BatchID: id, BatchSize: 3, FileName: "a.txt", ContentHash: hashOf([]byte("alpha")), ErrorKey: "PythonServiceUnavailable", ContentStored: true},
+		{ID: 2, UserID: "user", BatchID: id, BatchSize: 3, FileName: "gone.txt", ContentHash: hashOf([]byte("gone")), ErrorKey: "PythonServiceUnavailable", ContentStored: true},
+		{ID: 3, UserID: "user", BatchID: id, BatchSize: 3, FileName: "unkept.txt", ErrorKey: "PythonServiceUnavailable"},
+	}}
+	batches.batchOf(id)
+	store := blobstore.NewLocal(t.TempDir())
+	if err := store.Put(context.Background(), hashOf([]byte("alpha")), strings.NewReader("alpha"), 5); err != nil {
+		t.Fatal(err)
+	}
+	py := &mockPythonClient{respErr: httpclient.ErrPythonUnavailable}
+	service := services.NewAnalyzerServiceFull(&mockRepo{}, py, nil, services.WithBatches(batches), services.WithBlobStore(store))
+
+	res, err := service.RetryBatch(context.Background(), "user", id, "en")
+	if err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	keys := map[string]string{}
+	for _, r := range res {
+		keys[r.FileName] = r.ErrorKey
+	}
+	if keys["a.txt"] != "PythonServiceUnavailable" || keys["gone.txt"] != "OriginalNotAvailable" || keys["unkept.txt"] != "OriginalNotAvailable" {
+		t.Fatalf("unexpected error keys %v", keys)
+	}
+	// No failure is lost or duplicated: a.txt stays retryable, gone.txt no longer is
+	if len(batches.failures) != 3 || len(batches.claimed) != 0 {
+		t.Fatalf("expected the claims released on the same failures, got %+v claimed=%v", batches.failures, batches.claimed)
+	}
+	if !batches.failures[0].ContentStored || batches.failures[1].ContentStored || batches.failures[1].ErrorKey != "OriginalNotAvailable" {
+		t.Fatalf("unexpected released failures %+v", batches.failures)
+	}
+	if got := readBlob(t, store, hashOf([]byte("alpha"))); got != "alpha" {
+		t.Fatalf("expected the upload kept for the next retry, got %q", got)
+	}
+}
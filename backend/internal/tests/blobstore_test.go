@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/blobstore"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func readBlob(t *testing.T, store blobstore.BlobStore, key string) string {
+	t.Helper()
+	rc, _, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("get blob: %v", err)
+	}
+	defer rc.Close()
+	b, _ := io.ReadAll(rc)
+	return string(b)
+}
+
+func TestLocalBlobStore_PutGetDedupe(t *testing.T) {
+	store := blobstore.NewLocal(t.TempDir())
+	data := []byte("%PDF-1.7 original")
+	key := hashOf(data)
+
+	for i := 0; i < 2; i++ {
+		if err := store.Put(context.Background(), key, bytes.NewReader(data), int64(len(data))); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+	}
+	if got := readBlob(t, store, key); got != string(data) {
+		t.Fatalf("unexpected blob %q", got)
+	}
+	if _, _, err := store.Get(context.Background(), hashOf([]byte("missing"))); !errors.Is(err, blobstore.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.Put(context.Background(), "../../etc/passwd", bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Fatalf("expected invalid key rejected")
+	}
+	for i := 0; i < 2; i++ {
+		if err := store.Delete(context.Background(), key); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+	}
+	if _, _, err := store.Get(context.Background(), key); !errors.Is(err, blobstore.ErrNotFound) {
+		t.Fatalf("expected deleted blob, got %v", err)
+	}
+}
+
+// fakeS3 is an in-memory path-style bucket that checks the SigV4 headers are present and consistent.
+// Uploads are streamed, so PUT bodies are sent with an unsigned payload.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	puts    int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AK/") || !strings.Contains(auth, "/us-east-1/s3/aws4_request") ||
+		!validPayloadHash(r, body) || r.Header.Get("x-amz-date") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[r.URL.Path]
+	switch r.Method {
+	case http.MethodPut:
+		f.puts++
+		f.objects[r.URL.Path] = body
+	case http.MethodHead, http.MethodGet:
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Write(obj)
+		}
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func validPayloadHash(r *http.Request, body []byte) bool {
+	got := r.Header.Get("x-amz-content-sha256")
+	if r.Method == http.MethodPut {
+		return got == "UNSIGNED-PAYLOAD" && r.ContentLength == int64(len(body))
+	}
+	return got == hashOf(body)
+}
+
+func TestS3BlobStore_PutGetDedupe(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	store := blobstore.NewS3(srv.URL, "us-east-1", "originals", "AK", "SK", srv.Client())
+	data := []byte("docx bytes")
+	key := hashOf(data)
+	for i := 0; i < 2; i++ {
+		if err := store.Put(context.Background(), key, bytes.NewReader(data), int64(len(data))); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+	}
+	if fake.puts != 1 {
+		t.Fatalf("expected existing blob not re-uploaded, got %d puts", fake.puts)
+	}
+	if _, ok := fake.objects["/originals/"+key]; !ok {
+		t.Fatalf("expected path-style object key, got %v", fake.objects)
+	}
+	if got := readBlob(t, store, key); got != string(data) {
+		t.Fatalf("unexpected blob %q", got)
+	}
+	if _, _, err := store.Get(context.Background(), hashOf([]byte("missing"))); !errors.Is(err, blobstore.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := store.Delete(context.Background(), key); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+	}
+	if _, _, err := store.Get(context.Background(), key); !errors.Is(err, blobstore.ErrNotFound) {
+		t.Fatalf("expected deleted blob, got %v", err)
+	}
+}
+
+type mockDocumentsRepo struct {
+	doc      *models.DocumentFile
+	updateFn func(userID string, documentID int, patch models.DocumentPatch) (*models.DocumentItem, error)
+	moveFn   func(userID string, documentIDs []int, collectionID *int) error
+	deleteFn func(userID string, documentIDs []int) ([]string, error)
+}
+
+func (m *mockDocumentsRepo) GetDocumentFile(userID string, documentID int) (*models.DocumentFile, error) {
+	if m.doc == nil || m.doc.ID != documentID {
+		return nil, sql.ErrNoRows
+	}
+	return m.doc, nil
+}
+
+func (m *mockDocumentsRepo) UpdateDocument(userID string, documentID int, patch models.DocumentPatch) (*models.DocumentItem, error) {
+	return m.updateFn(userID, documentID, patch)
+}
+
+func (m *mockDocumentsRepo) MoveDocuments(userID string, documentIDs []int, collectionID *int) error {
+	return m.moveFn(userID, documentIDs, collectionID)
+}
+
+func (m *mockDocumentsRepo) DeleteDocuments(userID string, documentIDs []int) ([]string, error) {
+	return m.deleteFn(userID, documentIDs)
+}
+
+func TestAnalyzerService_StoresOriginalUpload(t *testing.T) {
+	store := blobstore.NewLocal(t.TempDir())
+	py := &mockPythonClient{respBody: `{"summary":"ok","fullText":"x"}`}
+	service := services.NewAnalyzerServiceFull(&mockRepo{}, py, nil, services.WithBlobStore(store))
+
+	data := []byte("%PDF-1.4 report")
+	if res := service.AnalyzeContent(context.Background(), "report.pdf", data, "en", "user", nil, nil, nil, services.AnalyzeOptions{}); res.Error != "" {
+		t.Fatalf("unexpected error: %s", res.Error)
+	}
+	if got := readBlob(t, store, hashOf(data)); got != string(data) {
+		t.Fatalf("expected original stored by content hash, got %q", got)
+	}
+}
+
+func TestAnalyzerService_RewindsUploadForEveryStage(t *testing.T) {
+	store := blobstore.NewLocal(t.TempDir())
+	py := &mockPythonClient{respBody: `{"summary":"ok","fullText":"x"}`}
+	content := "%PDF-1.4 " + strings.Repeat("page ", 20_000)
+	opener := mockFileOpener{contents: map[string]string{"big.pdf": content}}
+	service := services.NewAnalyzerServiceFull(&mockRepo{}, py, opener, services.WithBlobStore(store))
+
+	res := service.AnalyzeFilesWithContext(context.Background(), []*multipart.FileHeader{buildMemFileHeader("big.pdf", content)}, "en", "user", nil)
+	if len(res) != 1 || res[0].Error != "" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if string(py.lastFile) != content {
+		t.Fatalf("expected the whole upload forwarded, got %d of %d bytes", len(py.lastFile), len(content))
+	}
+	if got := readBlob(t, store, hashOf([]byte(content))); got != content {
+		t.Fatalf("expected the whole upload stored, got %d of %d bytes", len(got), len(content))
+	}
+}
+
+func TestDocumentsHandler_DownloadOriginal(t *testing.T) {
+	store := blobstore.NewLocal(t.TempDir())
+	data := []byte("%PDF-1.4 report")
+	store.Put(context.Background(), hashOf(data), bytes.NewReader(data), int64(len(data)))
+	repo := &mockDocumentsRepo{doc: &models.DocumentFile{ID: 3, FileName: "relatório.pdf", ContentHash: hashOf(data), DetectedType: "application/pdf"}}
+	h := handlers.NewDocumentsHandler(repo, store, &mockEmbeddingsRepo{})
+
+	c, w := newTestContext()
+	c.Params = gin.Params{{Key: "documentId", Value: "3"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/documents/3/original", nil)
+	h.DownloadOriginal(c)
+	if w.Code != http.StatusOK || w.Body.String() != string(data) {
+		t.Fatalf("expected original bytes, got %d %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/pdf" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment;") || !strings.Contains(cd, "relat%C3%B3rio.pdf") {
+		t.Fatalf("unexpected content disposition %q", cd)
+	}
+
+	c, w = newTestContext()
+	c.Params = gin.Params{{Key: "documentId", Value: "4"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/documents/4/original", nil)
+	h.DownloadOriginal(c)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another user's document, got %d", w.Code)
+	}
+}
@@ -36,16 +36,20 @@ func (m *mockCollectionsRepo) ExistsForUser(userID string, id int) (bool, error)
 
 type mockAnalysisRepo struct {
 	listDocsByColFn func(userID string, collectionID *int) ([]models.DocumentItem, error)
+	latestFn        func(userID string, documentID int) (*models.AnalysisDetail, error)
 }
 
-func (m *mockAnalysisRepo) InsertDocument(string, *int, string, string, string) (int, error) {
+func (m *mockAnalysisRepo) InsertDocument(string, *int, string, string, string, string, string) (int, error) {
 	return 0, nil
 }
-func (m *mockAnalysisRepo) InsertAnalysis(string, int, string, []string, string, []string, *string, *int) (int, error) {
+func (m *mockAnalysisRepo) InsertAnalysis(string, int, string, []string, string, []string, *string, *int, string, *models.AnalysisMeta) (int, error) {
 	return 0, nil
 }
 func (m *mockAnalysisRepo) FindDocument(string, *int, string) (int, error) { return 0, nil }
-func (m *mockAnalysisRepo) GetLatestAnalysisByDocument(string, int) (*models.AnalysisDetail, error) {
+func (m *mockAnalysisRepo) GetLatestAnalysisByDocument(userID string, documentID int) (*models.AnalysisDetail, error) {
+	if m.latestFn != nil {
+		return m.latestFn(userID, documentID)
+	}
 	return nil, errors.New("not implemented")
 }
 func (m *mockAnalysisRepo) ListDocumentsByCollection(userID string, collectionID *int) ([]models.DocumentItem, error) {
@@ -55,6 +59,13 @@ func (m *mockAnalysisRepo) ListAllDocuments(userID string, limit, offset int) ([
 	return nil, 0, nil
 }
 func (m *mockAnalysisRepo) UpdateDocumentCollection(string, int, int) error { return nil }
+func (m *mockAnalysisRepo) InsertReusedAnalysis(string, int, *string, *int) (int, error) {
+	return 0, nil
+}
+func (m *mockAnalysisRepo) UpdateDocumentText(string, int, string) error { return nil }
+func (m *mockAnalysisRepo) ListAnalysisVersions(string, int) ([]models.AnalysisVersion, error) {
+	return nil, nil
+}
 
 // helper to create gin context
 func newTestContext() (*gin.Context, *httptest.ResponseRecorder) {
@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/blobstore"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/i18n"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+)
+
+func documentRequest(h gin.HandlerFunc, method, id, body string) *httptest.ResponseRecorder {
+	c, w := newTestContext()
+	if id != "" {
+		c.Params = gin.Params{{Key: "documentId", Value: id}}
+	}
+	c.Request = httptest.NewRequest(method, "/documents", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h(c)
+	return w
+}
+
+func TestDocumentsHandler_UpdateDocument(t *testing.T) {
+	i18n.Init()
+	var got models.DocumentPatch
+	repo := &mockDocumentsRepo{updateFn: func(userID string, id int, patch models.DocumentPatch) (*models.DocumentItem, error) {
+		if id != 4 {
+			return nil, sql.ErrNoRows
+		}
+		got = patch
+		return &models.DocumentItem{ID: id, FileName: "renamed.pdf", CollectionID: patch.CollectionID}, nil
+	}}
+	h := handlers.NewDocumentsHandler(repo, nil, nil)
+
+	w := documentRequest(h.UpdateDocument, http.MethodPatch, "4", `{"fileName":"  renamed.pdf ","collectionId":null}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	if got.FileName == nil || *got.FileName != "renamed.pdf" || !got.Move || got.CollectionID != nil {
+		t.Fatalf("expected rename and move to uncategorized, got %+v", got)
+	}
+
+	w = documentRequest(h.UpdateDocument, http.MethodPatch, "4", `{"collectionId":7}`)
+	if w.Code != http.StatusOK || got.FileName != nil || !got.Move || *got.CollectionID != 7 {
+		t.Fatalf("expected move only, got %d %+v", w.Code, got)
+	}
+
+	cases := []struct {
+		id, body string
+		status   int
+		message  string
+	}{
+		{"4", `{}`, http.StatusBadRequest, "InvalidRequest"},
+		{"4", `{"fileName":"  "}`, http.StatusBadRequest, "InvalidDocumentName"},
+		{"4", `{"fileName":"a/b.pdf"}`, http.StatusBadRequest, "InvalidDocumentName"},
+		{"4", `{"collectionId":"x"}`, http.StatusBadRequest, "InvalidRequest"},
+		{"5", `{"fileName":"x.pdf"}`, http.StatusNotFound, "NotFound"},
+	}
+	for _, tc := range cases {
+		w := documentRequest(h.UpdateDocument, http.MethodPatch, tc.id, tc.body)
+		var env envelope
+		decodeEnvelope(t, w, &env)
+		if w.Code != tc.status || env.Message != i18n.GetMessage("en", tc.message) {
+			t.Fatalf("%s: expected %d %s, got %d %q", tc.body, tc.status, tc.message, w.Code, env.Message)
+		}
+	}
+}
+
+func TestDocumentsHandler_DuplicateIsConflict(t *testing.T) {
+	i18n.Init()
+	repo := &mockDocumentsRepo{
+		updateFn: func(string, int, models.DocumentPatch) (*models.DocumentItem, error) {
+			return nil, repositories.ErrDocumentDuplicate
+		},
+		moveFn: func(string, []int, *int) error { return repositories.ErrDocumentDuplicate },
+	}
+	h := handlers.NewDocumentsHandler(repo, nil, nil)
+
+	for _, w := range []*httptest.ResponseRecorder{
+		documentRequest(h.UpdateDocument, http.MethodPatch, "4", `{"collectionId":7}`),
+		documentRequest(h.BulkMove, http.MethodPost, "", `{"documentIds":[4,5],"collectionId":7}`),
+	} {
+		var env envelope
+		decodeEnvelope(t, w, &env)
+		if w.Code != http.StatusConflict || env.Message != i18n.GetMessage("en", "DocumentDuplicate") {
+			t.Fatalf("expected 409 DocumentDuplicate, got %d %q", w.Code, env.Message)
+		}
+	}
+}
+
+func TestDocumentsHandler_BulkMove(t *testing.T) {
+	var gotIDs []int
+	var gotCol *int
+	repo := &mockDocumentsRepo{moveFn: func(userID string, ids []int, colID *int) error {
+		gotIDs, gotCol = ids, colID
+		if colID != nil && *colID == 99 {
+			return repositories.ErrCollectionNotFound
+		}
+		return nil
+	}}
+	h := handlers.NewDocumentsHandler(repo, nil, nil)
+
+	w := documentRequest(h.BulkMove, http.MethodPost, "", `{"documentIds":[3,3,8],"collectionId":null}`)
+	if w.Code != http.StatusOK || len(gotIDs) != 2 || gotCol != nil {
+		t.Fatalf("expected deduped move to uncategorized, got %d ids=%v col=%v", w.Code, gotIDs, gotCol)
+	}
+	if w := documentRequest(h.BulkMove, http.MethodPost, "", `{"documentIds":[3]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected missing collectionId rejected, got %d", w.Code)
+	}
+	if w := documentRequest(h.BulkMove, http.MethodPost, "", `{"documentIds":[],"collectionId":2}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected empty selection rejected, got %d", w.Code)
+	}
+	if w := documentRequest(h.BulkMove, http.MethodPost, "", `{"documentIds":[3],"collectionId":99}`); w.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown collection 404, got %d", w.Code)
+	}
+}
+
+func TestDocumentsHandler_DeleteRemovesOrphanedBlobs(t *testing.T) {
+	i18n.Init()
+	store := blobstore.NewLocal(t.TempDir())
+	data := []byte("only copy")
+	key := hashOf(data)
+	if err := store.Put(context.Background(), key, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	var deleted [][]int
+	repo := &mockDocumentsRepo{deleteFn: func(userID string, ids []int) ([]string, error) {
+		deleted = append(deleted, ids)
+		if ids[0] == 404 {
+			return nil, sql.ErrNoRows
+		}
+		return []string{key}, nil
+	}}
+	h := handlers.NewDocumentsHandler(repo, store, nil)
+
+	w := documentRequest(h.DeleteDocument, http.MethodDelete, "6", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	if _, _, err := store.Get(context.Background(), key); !errors.Is(err, blobstore.ErrNotFound) {
+		t.Fatalf("expected orphaned original deleted, got %v", err)
+	}
+	if w := documentRequest(h.DeleteDocument, http.MethodDelete, "404", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+
+	w = documentRequest(h.BulkDelete, http.MethodPost, "", `{"documentIds":[1,2,2]}`)
+	var env envelope
+	decodeEnvelope(t, w, &env)
+	if w.Code != http.StatusOK || env.Data["deleted"] != float64(2) || len(deleted[len(deleted)-1]) != 2 {
+		t.Fatalf("unexpected bulk delete %d %+v", w.Code, env.Data)
+	}
+}
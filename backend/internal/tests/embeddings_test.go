@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+	"github.com/samusafe/genericapi/internal/similarity"
+)
+
+type mockEmbeddingsRepo struct {
+	stored map[int]models.DocumentEmbedding
+}
+
+func (m *mockEmbeddingsRepo) UpsertEmbedding(userID string, documentID int, model string, vector []float32) error {
+	if m.stored == nil {
+		m.stored = map[int]models.DocumentEmbedding{}
+	}
+	m.stored[documentID] = models.DocumentEmbedding{DocumentID: documentID, FileName: "doc", Model: model, Vector: vector}
+	return nil
+}
+func (m *mockEmbeddingsRepo) GetEmbedding(userID string, documentID int) (*models.DocumentEmbedding, error) {
+	e, ok := m.stored[documentID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &e, nil
+}
+func (m *mockEmbeddingsRepo) ListEmbeddings(userID string, model string, excludeDocumentID int) ([]models.DocumentEmbedding, error) {
+	var out []models.DocumentEmbedding
+	for id, e := range m.stored {
+		if id != excludeDocumentID && e.Model == model {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func TestSimilarity_TopK(t *testing.T) {
+	candidates := []models.DocumentEmbedding{
+		{DocumentID: 1, Vector: []float32{0, 1}},
+		{DocumentID: 2, Vector: []float32{1, 0.1}},
+		{DocumentID: 3, Vector: []float32{1, 1}},
+		{DocumentID: 4, Vector: []float32{1, 0, 0}}, // other dimensions: skipped
+	}
+	got := similarity.TopK([]float32{1, 0}, candidates, 2)
+	if len(got) != 2 || got[0].DocumentID != 2 || got[1].DocumentID != 3 {
+		t.Fatalf("unexpected ranking %+v", got)
+	}
+	if s := similarity.Cosine([]float32{2, 0}, []float32{5, 0}); s < 0.999 {
+		t.Fatalf("expected parallel vectors to have similarity 1, got %f", s)
+	}
+}
+
+func TestAnalyzerService_EmbedsNewDocumentsOnly(t *testing.T) {
+	emb := &mockEmbeddingsRepo{}
+	py := &mockPythonClient{respBody: `{"summary":"ok","fullText":"hello"}`}
+	service := services.NewAnalyzerServiceFull(&mockRepo{}, py, nil, services.WithEmbeddings(emb))
+	if res := service.AnalyzeContent(context.Background(), "notes.txt", []byte("hello"), "en", "user", nil, nil, nil, services.AnalyzeOptions{}); res.Error != "" {
+		t.Fatalf("unexpected error: %s", res.Error)
+	}
+	if e, ok := emb.stored[101]; !ok || e.Model != "test-model" || len(e.Vector) != 2 {
+		t.Fatalf("expected embedding stored for the new document, got %+v", emb.stored)
+	}
+
+	reused := &mockRepo{findDocID: 5, latest: &models.AnalysisDetail{Summary: "cached"}}
+	py = &mockPythonClient{}
+	service = services.NewAnalyzerServiceFull(reused, py, nil, services.WithEmbeddings(emb))
+	if res := service.AnalyzeContent(context.Background(), "notes.txt", []byte("hello"), "en", "user", nil, nil, nil, services.AnalyzeOptions{}); !res.Reused {
+		t.Fatalf("expected reuse, got %+v", res)
+	}
+	if py.embeds != 0 {
+		t.Fatalf("expected reused document not re-embedded, got %d calls", py.embeds)
+	}
+}
+
+func TestDocumentsHandler_Related(t *testing.T) {
+	emb := &mockEmbeddingsRepo{}
+	emb.UpsertEmbedding("user-1", 1, "m", []float32{1, 0})
+	emb.UpsertEmbedding("user-1", 2, "m", []float32{0.9, 0.1})
+	emb.UpsertEmbedding("user-1", 3, "m", []float32{0, 1})
+	emb.UpsertEmbedding("user-1", 4, "other", []float32{1, 0})
+	docs := &mockDocumentsRepo{doc: &models.DocumentFile{ID: 9}}
+	h := handlers.NewDocumentsHandler(docs, nil, emb)
+
+	get := func(id string) (*httptest.ResponseRecorder, envelope) {
+		c, w := newTestContext()
+		c.Params = gin.Params{{Key: "documentId", Value: id}}
+		c.Request = httptest.NewRequest(http.MethodGet, "/documents/"+id+"/related?limit=1", nil)
+		h.Related(c)
+		var env envelope
+		decodeEnvelope(t, w, &env)
+		return w, env
+	}
+
+	w, env := get("1")
+	items, _ := env.Data["items"].([]any)
+	if w.Code != http.StatusOK || len(items) != 1 || items[0].(map[string]any)["documentId"].(float64) != 2 {
+		t.Fatalf("expected document 2 as most related, got %d %+v", w.Code, env.Data)
+	}
+	if w, env := get("9"); w.Code != http.StatusNotFound || env.Message == "" {
+		t.Fatalf("expected 404 for a document without embedding, got %d", w.Code)
+	}
+	if w, _ := get("10"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown document, got %d", w.Code)
+	}
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/export"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/i18n"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+func newExportHandler(t *testing.T, renderer *export.Renderer) *handlers.ExportHandler {
+	t.Helper()
+	i18n.Init()
+	colID := 3
+	analyses := map[int]*models.AnalysisDetail{
+		1: {AnalysisID: 11, DocumentID: 1, FileName: "report.pdf", Summary: "Q3 <script>alert(1)</script> results", SummaryPoints: []string{"Revenue up"}, Keywords: []string{"revenue", "q3"}, Sentiment: "positive", CollectionID: &colID, Backend: "python", FullText: "secret full text"},
+		2: {AnalysisID: 12, DocumentID: 2, FileName: "../notes.md", Summary: "=SUM(A1)", Sentiment: "neutral", CollectionID: &colID},
+	}
+	repo := &mockAnalysisRepo{
+		latestFn: func(userID string, id int) (*models.AnalysisDetail, error) {
+			if a, ok := analyses[id]; ok {
+				return a, nil
+			}
+			return nil, sql.ErrNoRows
+		},
+		listDocsByColFn: func(userID string, collectionID *int) ([]models.DocumentItem, error) {
+			return []models.DocumentItem{{ID: 1, FileName: "report.pdf"}, {ID: 2, FileName: "../notes.md"}, {ID: 4, FileName: "pending.txt"}}, nil
+		},
+	}
+	cols := &mockCollectionsRepo{listFn: func(userID string) ([]models.Collection, error) {
+		return []models.Collection{{ID: colID, Name: "Finance"}}, nil
+	}}
+	if renderer == nil {
+		var err error
+		if renderer, err = export.New(""); err != nil {
+			t.Fatalf("templates: %v", err)
+		}
+	}
+	return handlers.NewExportHandler(repo, cols, renderer)
+}
+
+func exportRequest(h gin.HandlerFunc, param, id, query string) *httptest.ResponseRecorder {
+	c, w := newTestContext()
+	c.Params = gin.Params{{Key: param, Value: id}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/export"+query, nil)
+	h(c)
+	return w
+}
+
+func TestExportHandler_DocumentFormats(t *testing.T) {
+	h := newExportHandler(t, nil)
+
+	w := exportRequest(h.ExportDocument, "documentId", "1", "")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/markdown") {
+		t.Fatalf("expected markdown export, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{"# report.pdf", "## Summary", "- Revenue up", "revenue, q3", "Collection: Finance"} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Fatalf("expected %q in markdown:\n%s", want, w.Body.String())
+		}
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), `filename=1-report.md`) {
+		t.Fatalf("unexpected disposition %s", w.Header().Get("Content-Disposition"))
+	}
+
+	w = exportRequest(h.ExportDocument, "documentId", "1", "?format=html")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "<script>") || !strings.Contains(w.Body.String(), "&lt;script&gt;") {
+		t.Fatalf("expected escaped html export, got %d:\n%s", w.Code, w.Body.String())
+	}
+
+	w = exportRequest(h.ExportDocument, "documentId", "1", "?format=json")
+	var doc map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil || doc["summary"] == nil || doc["collectionName"] != "Finance" {
+		t.Fatalf("unexpected json export %s err=%v", w.Body.String(), err)
+	}
+	if _, ok := doc["fullText"]; ok {
+		t.Fatalf("expected the extracted text left out of the json export")
+	}
+
+	if w := exportRequest(h.ExportDocument, "documentId", "1", "?format=pdf"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown format, got %d", w.Code)
+	}
+	if w := exportRequest(h.ExportDocument, "documentId", "9", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing document, got %d", w.Code)
+	}
+}
+
+func TestExportHandler_CollectionZipAndCSV(t *testing.T) {
+	h := newExportHandler(t, nil)
+
+	w := exportRequest(h.ExportCollection, "id", "3", "?documentFormat=html")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("expected zip export, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("invalid archive: %v", err)
+	}
+	var names []string
+	var manifest struct {
+		Documents []struct{ File string }
+		Skipped   []struct{ DocumentID int }
+	}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if f.Name == "manifest.json" {
+			rc, _ := f.Open()
+			json.NewDecoder(rc).Decode(&manifest)
+			rc.Close()
+		}
+	}
+	if strings.Join(names, ",") != "1-report.html,2-notes.html,manifest.json" {
+		t.Fatalf("unexpected entries %v", names)
+	}
+	if len(manifest.Documents) != 2 || len(manifest.Skipped) != 1 || manifest.Skipped[0].DocumentID != 4 {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+
+	w = exportRequest(h.ExportCollection, "id", "3", "?format=csv")
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("expected header and two rows, got %v err=%v", rows, err)
+	}
+	if rows[2][6] != "'=SUM(A1)" {
+		t.Fatalf("expected formula cells defused, got %q", rows[2][6])
+	}
+
+	if w := exportRequest(h.ExportCollection, "id", "7", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another user's collection, got %d", w.Code)
+	}
+	if w := exportRequest(h.ExportCollection, "id", "3", "?format=tar"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown format, got %d", w.Code)
+	}
+}
+
+func TestExport_TemplateOverride(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "document.md.tmpl"), []byte(`{{ .Analysis.FileName }}|{{ join .Analysis.Keywords "+" }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	renderer, err := export.New(dir)
+	if err != nil {
+		t.Fatalf("templates: %v", err)
+	}
+	h := newExportHandler(t, renderer)
+	w := exportRequest(h.ExportDocument, "documentId", "1", "")
+	if body, _ := io.ReadAll(w.Body); string(body) != "report.pdf|revenue+q3" {
+		t.Fatalf("expected the override template used, got %q", body)
+	}
+	// HTML still comes from the built-in template.
+	if w := exportRequest(h.ExportDocument, "documentId", "1", "?format=html"); !strings.Contains(w.Body.String(), "<h1>report.pdf</h1>") {
+		t.Fatalf("expected the built-in html template, got %s", w.Body.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "document.html.tmpl"), []byte(`{{ .Broken `), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := export.New(dir); err == nil {
+		t.Fatalf("expected an invalid override to be rejected at startup")
+	}
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/samusafe/genericapi/internal/extract"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+func TestExtract_DecodesCharsetsAndLineEndings(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  []byte
+		want string
+	}{
+		{"utf8 bom", []byte("\xEF\xBB\xBFolá\r\nmundo"), "olá\nmundo"},
+		{"utf16le bom", []byte{0xFF, 0xFE, 'h', 0, 'i', 0, '\r', 0, '\n', 0, 0xE9, 0}, "hi\né"},
+		{"utf16be bom", []byte{0xFE, 0xFF, 0, 'o', 0, 'k'}, "ok"},
+		{"latin1 fallback", []byte("caf\xe9\rcr\xe8me"), "café\ncrème"},
+	}
+	for _, tc := range cases {
+		got, err := extract.Text(tc.raw, ".txt", extract.Options{})
+		if err != nil || got != tc.want {
+			t.Errorf("%s: expected %q got %q (err=%v)", tc.name, tc.want, got, err)
+		}
+	}
+}
+
+func TestExtract_RejectsBinaryAndEmpty(t *testing.T) {
+	if _, err := extract.Text([]byte("PK\x03\x04\x00\x00binary"), ".txt", extract.Options{}); !errors.Is(err, extract.ErrBinary) {
+		t.Fatalf("expected ErrBinary, got %v", err)
+	}
+	if _, err := extract.Text([]byte(" \r\n\t"), ".txt", extract.Options{}); !errors.Is(err, extract.ErrEmpty) {
+		t.Fatalf("expected ErrEmpty, got %v", err)
+	}
+}
+
+func TestExtract_StripsMarkdown(t *testing.T) {
+	md := "# Title #\n\nSome **bold** and _em_ text with [a link](http://x) and `code`.\n\n- item one\n1. item two\n> quoted\n\n```go\nfmt.Println()\n```\n---\nsnake_case_name"
+	got, err := extract.Text([]byte(md), ".md", extract.Options{StripMarkdown: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "Title\n\nSome bold and em text with a link and code.\n\nitem one\nitem two\nquoted\n\nfmt.Println()\nsnake_case_name"
+	if got != want {
+		t.Fatalf("unexpected markdown strip:\n%q\nwant\n%q", got, want)
+	}
+	if kept, _ := extract.Text([]byte(md), ".md", extract.Options{}); !strings.HasPrefix(kept, "# Title") {
+		t.Fatalf("expected markdown kept when stripping disabled, got %q", kept)
+	}
+}
+
+func TestAnalyzerService_TextFilesUseTextEndpoint(t *testing.T) {
+	py := &mockPythonClient{respBody: `{"summary":"ok","fullText":"hello"}`}
+	service := services.NewAnalyzerServiceFull(&mockRepo{}, py, nil)
+
+	res := service.AnalyzeContent(context.Background(), "notes.txt", []byte("hello\r\n"), "en", "user", nil, nil, nil, services.AnalyzeOptions{})
+	if res.Error != "" || py.textCalls != 1 || py.lastText != "hello\n" {
+		t.Fatalf("expected normalized text sent to text endpoint, got res=%+v calls=%d text=%q", res, py.textCalls, py.lastText)
+	}
+
+	res = service.AnalyzeContent(context.Background(), "fake.txt", []byte("\x00\x01\x02"), "en", "user", nil, nil, nil, services.AnalyzeOptions{})
+	if res.Error == "" || py.textCalls != 1 {
+		t.Fatalf("expected binary rejected before calling the backend, got res=%+v calls=%d", res, py.textCalls)
+	}
+}
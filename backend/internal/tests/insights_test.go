@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/httpclient"
+	"github.com/samusafe/genericapi/internal/insights"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+type mockInsightsRepo struct {
+	fingerprint string
+	docs        []models.InsightsDocument
+	cached      *models.CachedInsights
+	loads       int
+	saves       int
+}
+
+func (m *mockInsightsRepo) Fingerprint(userID string, collectionID int) (string, error) {
+	return m.fingerprint, nil
+}
+func (m *mockInsightsRepo) LatestAnalyses(userID string, collectionID int) ([]models.InsightsDocument, error) {
+	m.loads++
+	return m.docs, nil
+}
+func (m *mockInsightsRepo) GetCached(userID string, collectionID int) (*models.CachedInsights, error) {
+	if m.cached == nil {
+		return nil, sql.ErrNoRows
+	}
+	c := *m.cached
+	return &c, nil
+}
+func (m *mockInsightsRepo) SaveCached(userID string, collectionID int, cached models.CachedInsights) error {
+	m.saves++
+	m.cached = &cached
+	return nil
+}
+
+func insightsDocs() []models.InsightsDocument {
+	jan := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	return []models.InsightsDocument{
+		{DocumentID: 1, Summary: "First summary.", Keywords: []string{"Go", "databases", "go"}, Sentiment: "Positive", CreatedAt: jan},
+		{DocumentID: 2, Summary: "Second summary.", Keywords: []string{"go", "Concurrency"}, Sentiment: "negative", CreatedAt: jan},
+		{DocumentID: 3, Keywords: []string{"Databases"}, Sentiment: "positive", CreatedAt: mar},
+		{DocumentID: 4, CreatedAt: mar},
+	}
+}
+
+func TestInsights_Compute(t *testing.T) {
+	out := insights.Compute(5, insightsDocs(), 2)
+	if out.CollectionID != 5 || out.Documents != 4 {
+		t.Fatalf("unexpected totals %+v", out)
+	}
+	want := []models.KeywordStat{{Keyword: "Go", Count: 3, Documents: 2}, {Keyword: "databases", Count: 2, Documents: 2}}
+	if len(out.Keywords) != 2 || out.Keywords[0] != want[0] || out.Keywords[1] != want[1] {
+		t.Fatalf("unexpected keywords %+v", out.Keywords)
+	}
+	wantSent := []models.SentimentStat{{Sentiment: "positive", Documents: 2}, {Sentiment: "negative", Documents: 1}, {Sentiment: "unknown", Documents: 1}}
+	if len(out.Sentiments) != 3 || out.Sentiments[0] != wantSent[0] || out.Sentiments[1] != wantSent[1] || out.Sentiments[2] != wantSent[2] {
+		t.Fatalf("unexpected sentiments %+v", out.Sentiments)
+	}
+	if len(out.Timeline) != 2 || out.Timeline[0] != (models.PeriodStat{Period: "2026-01", Documents: 2}) || out.Timeline[1] != (models.PeriodStat{Period: "2026-03", Documents: 2}) {
+		t.Fatalf("unexpected timeline %+v", out.Timeline)
+	}
+
+	if got := insights.SummaryInput(insightsDocs(), 20); got != "First summary." {
+		t.Fatalf("expected only the summaries that fit, got %q", got)
+	}
+}
+
+func TestInsightsService_CachesByFingerprint(t *testing.T) {
+	repo := &mockInsightsRepo{fingerprint: "fp1", docs: insightsDocs()}
+	py := &mockPythonClient{sumBody: `{"summary":"meta"}`}
+	service := services.NewInsightsService(repo, httpclient.NewRegistry("python", py))
+	ctx := context.Background()
+
+	out, err := service.Insights(ctx, "user", 5, false)
+	if err != nil || out.Cached || out.Summary != "" || repo.saves != 1 {
+		t.Fatalf("expected computed insights, got %+v err=%v saves=%d", out, err, repo.saves)
+	}
+	out, _ = service.Insights(ctx, "user", 5, false)
+	if !out.Cached || repo.loads != 1 {
+		t.Fatalf("expected the cached insights, got %+v loads=%d", out, repo.loads)
+	}
+
+	// The meta-summary is computed once, then cached with the rest
+	out, err = service.Insights(ctx, "user", 5, true)
+	if err != nil || out.Summary != "meta" || py.lastSum != "First summary.\n\nSecond summary." {
+		t.Fatalf("expected the meta-summary, got %+v err=%v sent=%q", out, err, py.lastSum)
+	}
+	py.lastSum = ""
+	out, _ = service.Insights(ctx, "user", 5, true)
+	if !out.Cached || out.Summary != "meta" || py.lastSum != "" {
+		t.Fatalf("expected the cached meta-summary, got %+v", out)
+	}
+
+	// A document added, moved or re-analyzed changes the fingerprint
+	repo.fingerprint = "fp2"
+	repo.docs = repo.docs[:1]
+	out, _ = service.Insights(ctx, "user", 5, false)
+	if out.Cached || out.Documents != 1 || repo.cached.Summary != nil {
+		t.Fatalf("expected insights computed again, got %+v", out)
+	}
+}
+
+func TestInsightsHandler_Responses(t *testing.T) {
+	cols := &mockCollectionsRepo{existsForUserFn: func(userID string, id int) (bool, error) { return id == 1, nil }}
+	cases := []struct {
+		id, query string
+		py        *mockPythonClient
+		want      int
+	}{
+		{"1", "", &mockPythonClient{}, http.StatusOK},
+		{"2", "", &mockPythonClient{}, http.StatusNotFound},
+		{"1", "?summary=maybe", &mockPythonClient{}, http.StatusBadRequest},
+		{"1", "?summary=true", &mockPythonClient{respErr: httpclient.ErrPythonUnavailable}, http.StatusServiceUnavailable},
+	}
+	for _, tc := range cases {
+		repo := &mockInsightsRepo{fingerprint: "fp", docs: insightsDocs()}
+		h := handlers.NewInsightsHandler(cols, services.NewInsightsService(repo, httpclient.NewRegistry("python", tc.py)))
+		c, w := newTestContext()
+		c.Params = gin.Params{{Key: "id", Value: tc.id}}
+		c.Request = httptest.NewRequest(http.MethodGet, "/collections/"+tc.id+"/insights"+tc.query, nil)
+		h.Get(c)
+		if w.Code != tc.want {
+			t.Errorf("collection %s%s: expected %d got %d", tc.id, tc.query, tc.want, w.Code)
+		}
+		if tc.want == http.StatusOK && !strings.Contains(w.Body.String(), `"keywords"`) {
+			t.Errorf("expected insights in the body, got %s", w.Body.String())
+		}
+	}
+}
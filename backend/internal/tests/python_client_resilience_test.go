@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/samusafe/genericapi/internal/httpclient"
+)
+
+var fastRetry = httpclient.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestPythonClient_RetriesUnavailableThenSucceeds(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every attempt must carry the whole file, i.e. the stream is rewound between retries.
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("attempt %d: read multipart file: %v", calls.Load()+1, err)
+		} else {
+			b, _ := io.ReadAll(f)
+			bodies = append(bodies, string(b))
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"summary":"ok"}`))
+	}))
+	defer srv.Close()
+
+	breaker := httpclient.NewCircuitBreaker("test", 5, time.Minute)
+	py := httpclient.NewPythonClientWithPolicy(srv.URL, srv.Client(), fastRetry, breaker)
+	resp, err := py.AnalyzeWithCtx(context.Background(), strings.NewReader("content"), "doc.txt", "cid-1")
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls.Load())
+	}
+	for i, b := range bodies {
+		if b != "content" {
+			t.Fatalf("attempt %d: expected full file, got %q", i+1, b)
+		}
+	}
+	if breaker.State() != httpclient.BreakerClosed {
+		t.Fatalf("expected closed breaker, got %s", breaker.State())
+	}
+}
+
+func TestPythonClient_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	py := httpclient.NewPythonClientWithPolicy(srv.URL, srv.Client(), fastRetry, httpclient.NewCircuitBreaker("test", 1, time.Minute))
+	resp, err := py.GenerateQuizWithCtx(context.Background(), []byte(`{"text":"x"}`), "cid-2")
+	if !errors.Is(err, httpclient.ErrBadStatus) {
+		t.Fatalf("expected ErrBadStatus, got %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Fatalf("expected a single attempt, got %d", calls.Load())
+	}
+}
+
+func TestPythonClient_BreakerOpensAndFailsFast(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	breaker := httpclient.NewCircuitBreaker("test", 2, 50*time.Millisecond)
+	py := httpclient.NewPythonClientWithPolicy(srv.URL, srv.Client(), httpclient.RetryPolicy{MaxAttempts: 1}, breaker)
+	for i := 0; i < 2; i++ {
+		if resp, err := py.AnalyzeWithCtx(context.Background(), strings.NewReader("x"), "a.txt", "cid"); err == nil {
+			t.Fatalf("expected failure")
+		} else if resp != nil {
+			resp.Body.Close()
+		}
+	}
+	if breaker.State() != httpclient.BreakerOpen {
+		t.Fatalf("expected open breaker, got %s", breaker.State())
+	}
+	if _, err := py.AnalyzeWithCtx(context.Background(), strings.NewReader("x"), "a.txt", "cid"); !errors.Is(err, httpclient.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected no call while open, got %d calls", calls.Load())
+	}
+
+	// After the cooldown a single probe is allowed; a failure re-opens the breaker.
+	time.Sleep(60 * time.Millisecond)
+	if breaker.State() != httpclient.BreakerHalfOpen {
+		t.Fatalf("expected half-open after cooldown, got %s", breaker.State())
+	}
+	if resp, _ := py.AnalyzeWithCtx(context.Background(), strings.NewReader("x"), "a.txt", "cid"); resp != nil {
+		resp.Body.Close()
+	}
+	if calls.Load() != 3 || breaker.State() != httpclient.BreakerOpen {
+		t.Fatalf("expected probe to re-open breaker, calls=%d state=%s", calls.Load(), breaker.State())
+	}
+}
+
+func TestPythonClient_RetriesConnectionRefused(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close() // nothing listens on url anymore
+
+	breaker := httpclient.NewCircuitBreaker("test", 1, time.Minute)
+	py := httpclient.NewPythonClientWithPolicy(url, http.DefaultClient, fastRetry, breaker)
+	if _, err := py.AnalyzeWithCtx(context.Background(), strings.NewReader("x"), "a.txt", "cid"); !errors.Is(err, httpclient.ErrPythonUnavailable) {
+		t.Fatalf("expected ErrPythonUnavailable, got %v", err)
+	}
+	if breaker.State() != httpclient.BreakerOpen {
+		t.Fatalf("expected breaker open after exhausted retries, got %s", breaker.State())
+	}
+}
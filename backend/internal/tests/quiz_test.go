@@ -0,0 +1,239 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+// mockQuizRepo keeps quiz versions per analysis in memory; analysis 1 belongs to every user.
+type mockQuizRepo struct {
+	versions map[int][][]models.QuizQuestion
+	options  map[int][]models.QuizOptions
+}
+
+func (m *mockQuizRepo) GetAnalysisText(userID string, analysisID int) (string, error) {
+	if analysisID != 1 {
+		return "", sql.ErrNoRows
+	}
+	return "Stored document text.", nil
+}
+func (m *mockQuizRepo) SaveQuiz(userID string, analysisID int, opts models.QuizOptions, questions []models.QuizQuestion) (int, error) {
+	if m.versions == nil {
+		m.versions = map[int][][]models.QuizQuestion{}
+		m.options = map[int][]models.QuizOptions{}
+	}
+	for i := range questions {
+		questions[i].ID = analysisID*1000 + len(m.versions[analysisID])*100 + i
+	}
+	m.versions[analysisID] = append(m.versions[analysisID], questions)
+	m.options[analysisID] = append(m.options[analysisID], opts)
+	return len(m.versions[analysisID]), nil
+}
+func (m *mockQuizRepo) GetQuiz(userID string, analysisID int, version int) (*models.StoredQuiz, error) {
+	stored := m.versions[analysisID]
+	if version == 0 {
+		version = len(stored)
+	}
+	if version == 0 || version > len(stored) {
+		return nil, sql.ErrNoRows
+	}
+	q := &models.StoredQuiz{AnalysisID: analysisID, Version: version, Quiz: stored[version-1]}
+	if opts := m.options[analysisID]; len(opts) >= version {
+		q.Options = opts[version-1]
+	}
+	for v := range stored {
+		q.Versions = append(q.Versions, v+1)
+	}
+	return q, nil
+}
+
+var openQuiz = models.QuizOptions{Type: models.QuizTypeOpen, Count: 5, Difficulty: models.QuizDifficultyMedium}
+
+func TestQuizService_ReusesStoredQuizUntilRegenerated(t *testing.T) {
+	repo := &mockQuizRepo{}
+	py := &mockPythonClient{quizBody: `{"quiz":[{"question":"Q?","answer":"A"}]}`}
+	svc := services.NewQuizService(repo, services.NewAnalyzerServiceFull(&mockRepo{}, py, nil))
+
+	first, err := svc.GenerateForAnalysis(context.Background(), "user", 1, "en", openQuiz, false)
+	if err != nil || first.Version != 1 || first.Reused || len(first.Quiz) != 1 {
+		t.Fatalf("expected a new first version, got %+v err=%v", first, err)
+	}
+	if !strings.Contains(py.lastQuiz, "Stored document text.") {
+		t.Fatalf("expected the stored document text sent for generation, got %s", py.lastQuiz)
+	}
+
+	py.lastQuiz = ""
+	again, err := svc.GenerateForAnalysis(context.Background(), "user", 1, "en", openQuiz, false)
+	if err != nil || !again.Reused || again.Version != 1 || py.lastQuiz != "" {
+		t.Fatalf("expected the stored quiz reused without generation, got %+v err=%v", again, err)
+	}
+
+	regen, err := svc.GenerateForAnalysis(context.Background(), "user", 1, "en", openQuiz, true)
+	if err != nil || regen.Reused || regen.Version != 2 || len(regen.Versions) != 2 {
+		t.Fatalf("expected a second version keeping the first, got %+v err=%v", regen, err)
+	}
+
+	if _, err := svc.GenerateForAnalysis(context.Background(), "user", 2, "en", openQuiz, false); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows for a foreign analysis, got %v", err)
+	}
+
+	py.quizBody = `{"quiz":[]}`
+	if _, err := svc.GenerateForAnalysis(context.Background(), "user", 1, "en", openQuiz, true); err != services.ErrEmptyQuiz {
+		t.Fatalf("expected ErrEmptyQuiz, got %v", err)
+	}
+	if len(repo.versions[1]) != 2 {
+		t.Fatalf("expected an empty quiz not to be stored, got %d versions", len(repo.versions[1]))
+	}
+}
+
+func TestQuizHandler_Responses(t *testing.T) {
+	repo := &mockQuizRepo{}
+	py := &mockPythonClient{quizBody: `{"quiz":[{"question":"Q?","answer":"A"}]}`}
+	h := handlers.NewQuizHandler(services.NewQuizService(repo, services.NewAnalyzerServiceFull(&mockRepo{}, py, nil)))
+
+	post := func(body string) int {
+		c, w := newTestContext()
+		c.Request = httptest.NewRequest(http.MethodPost, "/generate-quiz", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.GenerateQuiz(c)
+		return w.Code
+	}
+	get := func(id, query string) (int, envelope) {
+		c, w := newTestContext()
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		c.Request = httptest.NewRequest(http.MethodGet, "/analyses/"+id+"/quiz"+query, nil)
+		h.GetAnalysisQuiz(c)
+		var env envelope
+		if w.Code == http.StatusOK {
+			decodeEnvelope(t, w, &env)
+		}
+		return w.Code, env
+	}
+
+	if code, _ := get("1", ""); code != http.StatusNotFound {
+		t.Fatalf("expected 404 before any quiz is stored, got %d", code)
+	}
+	if code := post(`{}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without text or analysisId, got %d", code)
+	}
+	if code := post(`{"analysisId":2}`); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a foreign analysis, got %d", code)
+	}
+	if code := post(`{"analysisId":1}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := post(`{"analysisId":1,"regenerate":true}`); code != http.StatusOK {
+		t.Fatalf("expected 200 on regenerate, got %d", code)
+	}
+
+	if code, env := get("1", ""); code != http.StatusOK || env.Data["version"] != float64(2) {
+		t.Fatalf("expected latest version 2, got %d %+v", code, env.Data)
+	}
+	if code, env := get("1", "?version=1"); code != http.StatusOK || env.Data["version"] != float64(1) {
+		t.Fatalf("expected version 1, got %d %+v", code, env.Data)
+	}
+	if code, _ := get("1", "?version=0"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid version, got %d", code)
+	}
+
+	py.quizBody = `{"quiz":[]}`
+	if code := post(`{"analysisId":1,"regenerate":true}`); code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for an empty quiz, got %d", code)
+	}
+}
+
+func TestQuizService_ChecksRequestedFormat(t *testing.T) {
+	py := &mockPythonClient{}
+	svc := services.NewQuizService(&mockQuizRepo{}, services.NewAnalyzerServiceFull(&mockRepo{}, py, nil))
+	mc := models.QuizOptions{Type: models.QuizTypeMultipleChoice, Count: 2, Difficulty: models.QuizDifficultyHard}
+
+	py.quizBody = `{"quiz":[
+		{"type":"multiple_choice","question":"Capital?","options":["Porto","Lisboa","Faro"],"correctIndex":1},
+		{"type":"multiple_choice","question":"River?","options":["Tejo","Douro"],"correctIndex":0},
+		{"type":"multiple_choice","question":"Extra?","options":["a","b"],"correctIndex":0}]}`
+	quiz, err := svc.GenerateFromText(context.Background(), "text", "en", mc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(quiz.Quiz) != 2 || quiz.Quiz[0].Answer != "Lisboa" {
+		t.Fatalf("expected the count enforced and the answer taken from the options, got %+v", quiz.Quiz)
+	}
+	for _, want := range []string{`"type":"multiple_choice"`, `"count":2`, `"difficulty":"hard"`} {
+		if !strings.Contains(py.lastQuiz, want) {
+			t.Fatalf("expected %s passed to the generator, got %s", want, py.lastQuiz)
+		}
+	}
+
+	malformed := []struct {
+		opts models.QuizOptions
+		body string
+	}{
+		{mc, `{"quiz":[{"type":"multiple_choice","question":"Q?","options":["a","b"],"correctIndex":2}]}`},
+		{mc, `{"quiz":[{"type":"multiple_choice","question":"Q?","options":["a","A"],"correctIndex":0}]}`},
+		{mc, `{"quiz":[{"type":"open","question":"Q?","answer":"a"}]}`},
+		{models.QuizOptions{Type: models.QuizTypeTrueFalse, Count: 1}, `{"quiz":[{"type":"true_false","question":"Sky is green","answer":"maybe"}]}`},
+		{models.QuizOptions{Type: models.QuizTypeCloze, Count: 1}, `{"quiz":[{"type":"cloze","question":"No blank here","answer":"x"}]}`},
+		{openQuiz, `{"quiz":"not a list"}`},
+	}
+	for _, tc := range malformed {
+		py.quizBody = tc.body
+		if _, err := svc.GenerateFromText(context.Background(), "text", "en", tc.opts); !errors.Is(err, services.ErrMalformedQuiz) {
+			t.Errorf("expected ErrMalformedQuiz for %s, got %v", tc.body, err)
+		}
+	}
+}
+
+func TestQuizService_ReusesOnlyForSameOptions(t *testing.T) {
+	repo := &mockQuizRepo{}
+	py := &mockPythonClient{quizBody: `{"quiz":[{"type":"open","question":"Q?","answer":"A"}]}`}
+	svc := services.NewQuizService(repo, services.NewAnalyzerServiceFull(&mockRepo{}, py, nil))
+	if _, err := svc.GenerateForAnalysis(context.Background(), "user", 1, "en", openQuiz, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	py.quizBody = `{"quiz":[{"type":"true_false","question":"Q","answer":"true"}]}`
+	tf := models.QuizOptions{Type: models.QuizTypeTrueFalse, Count: 5, Difficulty: models.QuizDifficultyMedium}
+	got, err := svc.GenerateForAnalysis(context.Background(), "user", 1, "en", tf, false)
+	if err != nil || got.Reused || got.Version != 2 || got.Quiz[0].Answer != "True" {
+		t.Fatalf("expected a new version for other options, got %+v err=%v", got, err)
+	}
+	again, err := svc.GenerateForAnalysis(context.Background(), "user", 1, "en", tf, false)
+	if err != nil || !again.Reused || again.Version != 2 {
+		t.Fatalf("expected the true/false quiz reused, got %+v err=%v", again, err)
+	}
+}
+
+func TestQuizHandler_ValidatesOptions(t *testing.T) {
+	py := &mockPythonClient{quizBody: `{"quiz":[{"type":"cloze","question":"Q","answer":"A"}]}`}
+	h := handlers.NewQuizHandler(services.NewQuizService(&mockQuizRepo{}, services.NewAnalyzerServiceFull(&mockRepo{}, py, nil)))
+
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"text":"t","type":"essay"}`, http.StatusBadRequest},
+		{`{"text":"t","difficulty":"insane"}`, http.StatusBadRequest},
+		{`{"text":"t","count":-1}`, http.StatusBadRequest},
+		{`{"text":"t","count":500}`, http.StatusBadRequest},
+		{`{"text":"t","type":"cloze"}`, http.StatusBadGateway}, // no blank in the generated question
+	}
+	for _, tc := range cases {
+		c, w := newTestContext()
+		c.Request = httptest.NewRequest(http.MethodPost, "/generate-quiz", strings.NewReader(tc.body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.GenerateQuiz(c)
+		if w.Code != tc.want {
+			t.Errorf("body %s: expected %d got %d", tc.body, tc.want, w.Code)
+		}
+	}
+}
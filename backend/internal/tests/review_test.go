@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samusafe/genericapi/internal/grading"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/services"
+	"github.com/samusafe/genericapi/internal/srs"
+)
+
+type mockReviewRepo struct {
+	states   map[int]models.ReviewState
+	attempts []models.QuizAttempt
+	due      []models.DueReview
+}
+
+func (m *mockReviewRepo) GetReviewStates(userID string, questionIDs []int) (map[int]models.ReviewState, error) {
+	out := map[int]models.ReviewState{}
+	for _, id := range questionIDs {
+		if s, ok := m.states[id]; ok {
+			out[id] = s
+		}
+	}
+	return out, nil
+}
+func (m *mockReviewRepo) SaveAttempt(userID string, attempt *models.QuizAttempt, states []models.ReviewState) error {
+	if m.states == nil {
+		m.states = map[int]models.ReviewState{}
+	}
+	for _, s := range states {
+		m.states[s.QuestionID] = s
+	}
+	attempt.ID = len(m.attempts) + 1
+	m.attempts = append(m.attempts, *attempt)
+	return nil
+}
+func (m *mockReviewRepo) ListDue(userID string, now time.Time, limit, offset int) ([]models.DueReview, int, error) {
+	return m.due, len(m.due), nil
+}
+
+func TestGrading_NormalizedAndFuzzyMatch(t *testing.T) {
+	cases := []struct {
+		expected, given string
+		correct, exact  bool
+	}{
+		{"São Paulo", "  sao paulo. ", true, true},
+		{"The mitochondria is the powerhouse of the cell", "powerhouse of the cell", true, false},
+		{"Photosynthesis converts light into chemical energy", "it is about plants", false, false},
+		{"Lisbon", "", false, false},
+	}
+	for _, tc := range cases {
+		g := grading.Grade(tc.expected, tc.given, 0.6)
+		if g.Correct != tc.correct || g.Exact != tc.exact {
+			t.Errorf("Grade(%q, %q) = %+v", tc.expected, tc.given, g)
+		}
+	}
+}
+
+func TestSM2_Scheduling(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := srs.Review(srs.State{}, 5, now)
+	if s.Repetitions != 1 || s.IntervalDays != 1 || !s.DueAt.Equal(now.AddDate(0, 0, 1)) {
+		t.Fatalf("unexpected first review %+v", s)
+	}
+	s = srs.Review(s, 5, now)
+	if s.IntervalDays != 6 {
+		t.Fatalf("expected 6 days after the second success, got %+v", s)
+	}
+	s = srs.Review(s, 4, now)
+	if s.Repetitions != 3 || s.IntervalDays != 16 {
+		t.Fatalf("expected interval scaled by ease, got %+v", s)
+	}
+	lapsed := srs.Review(s, 1, now)
+	if lapsed.Repetitions != 0 || lapsed.IntervalDays != 1 || lapsed.Ease >= s.Ease {
+		t.Fatalf("expected a lapse to reset the schedule and lower the ease, got %+v", lapsed)
+	}
+	for i := 0; i < 10; i++ {
+		lapsed = srs.Review(lapsed, 0, now)
+	}
+	if lapsed.Ease < 1.3 {
+		t.Fatalf("expected ease floored at 1.3, got %v", lapsed.Ease)
+	}
+}
+
+func storedQuizRepo() *mockQuizRepo {
+	return &mockQuizRepo{versions: map[int][][]models.QuizQuestion{1: {{
+		{ID: 10, Question: "Capital of Brazil?", Answer: "Brasília"},
+		{ID: 11, Question: "Largest city?", Answer: "São Paulo"},
+	}}}}
+}
+
+func TestReviewService_GradesAndSchedules(t *testing.T) {
+	reviews := &mockReviewRepo{}
+	svc := services.NewReviewService(storedQuizRepo(), reviews)
+
+	attempt, err := svc.SubmitAttempt("user", 1, 0, []models.SubmittedAnswer{{QuestionID: 10, Answer: "brasilia"}, {QuestionID: 11, Answer: "Rio"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempt.Version != 1 || attempt.Correct != 1 || attempt.Total != 2 || attempt.Score != 0.5 {
+		t.Fatalf("unexpected attempt %+v", attempt)
+	}
+	if !attempt.Results[0].Correct || attempt.Results[0].Quality != 5 || attempt.Results[1].Correct || attempt.Results[1].Expected != "São Paulo" {
+		t.Fatalf("unexpected results %+v", attempt.Results)
+	}
+	if reviews.states[10].Repetitions != 1 || reviews.states[11].Repetitions != 0 || reviews.states[10].DueAt.IsZero() {
+		t.Fatalf("expected both questions scheduled, got %+v", reviews.states)
+	}
+
+	if _, err := svc.SubmitAttempt("user", 1, 0, []models.SubmittedAnswer{{QuestionID: 10, Answer: "Brasília"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reviews.states[10].Repetitions != 2 || reviews.states[10].IntervalDays != 6 {
+		t.Fatalf("expected the schedule to build on the previous review, got %+v", reviews.states[10])
+	}
+}
+
+func TestReviewHandler_Responses(t *testing.T) {
+	reviews := &mockReviewRepo{due: []models.DueReview{{QuestionID: 10, AnalysisID: 1, Question: "Capital of Brazil?"}}}
+	h := handlers.NewReviewHandler(services.NewReviewService(storedQuizRepo(), reviews))
+
+	cases := []struct {
+		id, body string
+		want     int
+	}{
+		{"1", `{"answers":[]}`, http.StatusBadRequest},
+		{"1", `{"answers":[{"questionId":99,"answer":"x"}]}`, http.StatusBadRequest},
+		{"1", `{"answers":[{"questionId":10,"answer":"x"},{"questionId":10,"answer":"y"}]}`, http.StatusBadRequest},
+		{"1", `{"version":2,"answers":[{"questionId":10,"answer":"x"}]}`, http.StatusNotFound},
+		{"2", `{"answers":[{"questionId":10,"answer":"x"}]}`, http.StatusNotFound},
+		{"1", `{"answers":[{"questionId":10,"answer":"Brasília"}]}`, http.StatusCreated},
+	}
+	for _, tc := range cases {
+		c, w := newTestContext()
+		c.Params = gin.Params{{Key: "id", Value: tc.id}}
+		c.Request = httptest.NewRequest(http.MethodPost, "/quizzes/"+tc.id+"/attempts", strings.NewReader(tc.body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.SubmitAttempt(c)
+		if w.Code != tc.want {
+			t.Errorf("quiz %s body %s: expected %d got %d", tc.id, tc.body, tc.want, w.Code)
+		}
+	}
+	if len(reviews.attempts) != 1 {
+		t.Fatalf("expected only the valid attempt stored, got %d", len(reviews.attempts))
+	}
+
+	c, w := newTestContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/reviews/due", nil)
+	h.DueReviews(c)
+	var env envelope
+	decodeEnvelope(t, w, &env)
+	if w.Code != http.StatusOK || env.Data["total"] != float64(1) {
+		t.Fatalf("unexpected due reviews response %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samusafe/genericapi/internal/i18n"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/scanner"
+	"github.com/samusafe/genericapi/internal/services"
+)
+
+// fakeClamd accepts one INSTREAM session per connection and flags payloads containing "EICAR".
+func fakeClamd(t *testing.T, network, address string) net.Listener {
+	t.Helper()
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if cmd, _ := r.ReadString(0); cmd != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+				var payload bytes.Buffer
+				for {
+					var size uint32
+					if binary.Read(r, binary.BigEndian, &size) != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					io.CopyN(&payload, r, int64(size))
+				}
+				if bytes.Contains(payload.Bytes(), []byte("EICAR")) {
+					conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return ln
+}
+
+func TestClamd_InstreamOverTCPAndUnix(t *testing.T) {
+	tcp := fakeClamd(t, "tcp", "127.0.0.1:0")
+	unix := fakeClamd(t, "unix", filepath.Join(t.TempDir(), "clamd.sock"))
+
+	for _, sc := range []*scanner.Clamd{
+		scanner.NewClamd("tcp", tcp.Addr().String(), time.Second),
+		scanner.NewClamd("unix", unix.Addr().String(), time.Second),
+	} {
+		clean, err := sc.Scan(context.Background(), bytes.NewReader(bytes.Repeat([]byte("a"), 200_000)))
+		if err != nil || !clean.Clean {
+			t.Fatalf("expected clean verdict, got %+v err=%v", clean, err)
+		}
+		infected, err := sc.Scan(context.Background(), strings.NewReader("X5O!P%@AP EICAR test"))
+		if err != nil || infected.Clean || infected.Signature != "Eicar-Test-Signature" {
+			t.Fatalf("expected infected verdict, got %+v err=%v", infected, err)
+		}
+	}
+}
+
+type fakeScanner struct {
+	verdict scanner.Verdict
+	err     error
+}
+
+func (f fakeScanner) Name() string { return "fake" }
+func (f fakeScanner) Scan(context.Context, io.Reader) (scanner.Verdict, error) {
+	return f.verdict, f.err
+}
+
+type mockQuarantineRepo struct{ events []models.QuarantineEvent }
+
+func (m *mockQuarantineRepo) RecordEvent(ev models.QuarantineEvent) error {
+	m.events = append(m.events, ev)
+	return nil
+}
+
+func TestAnalyzerService_InfectedFileQuarantined(t *testing.T) {
+	repo := &mockRepo{findDocID: 5, latest: &models.AnalysisDetail{Summary: "old"}}
+	py := &mockPythonClient{respBody: `{"summary":"ok","fullText":"x"}`}
+	quarantine := &mockQuarantineRepo{}
+	sc := fakeScanner{verdict: scanner.Verdict{Signature: "Win.Trojan.Test"}}
+	service := services.NewAnalyzerServiceFull(repo, py, nil, services.WithScanner(sc, quarantine))
+
+	res := service.AnalyzeContent(context.Background(), "doc.txt", []byte("payload"), "en", "user", nil, nil, nil, services.AnalyzeOptions{})
+	if res.Error != i18n.GetMessage("en", "FileRejected") {
+		t.Fatalf("expected FileRejected, got %+v", res)
+	}
+	if repo.insertDocCalls != 0 || repo.insertAnalysisCalls != 0 || py.textCalls != 0 {
+		t.Fatalf("expected no reuse, persistence or backend call for infected file")
+	}
+	if len(quarantine.events) != 1 || quarantine.events[0].Signature != "Win.Trojan.Test" || quarantine.events[0].Verdict != models.QuarantineInfected {
+		t.Fatalf("expected quarantine event recorded, got %+v", quarantine.events)
+	}
+}
+
+func TestAnalyzerService_ScannerUnavailableFailsClosed(t *testing.T) {
+	py := &mockPythonClient{respBody: `{"summary":"ok","fullText":"x"}`}
+	service := services.NewAnalyzerServiceFull(&mockRepo{}, py, nil, services.WithScanner(fakeScanner{err: errors.New("connection refused")}, nil))
+
+	res := service.AnalyzeContent(context.Background(), "doc.txt", []byte("payload"), "en", "user", nil, nil, nil, services.AnalyzeOptions{})
+	if res.Error != i18n.GetMessage("en", "ScanUnavailable") || py.textCalls != 0 {
+		t.Fatalf("expected ScanUnavailable without backend call, got %+v", res)
+	}
+}
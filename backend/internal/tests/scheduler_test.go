@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/samusafe/genericapi/internal/scheduler"
+)
+
+func acquireAsync(s *scheduler.Scheduler, ctx context.Context, user string) chan func() {
+	ch := make(chan func(), 1)
+	go func() {
+		release, err := s.Acquire(ctx, user)
+		if err == nil {
+			ch <- release
+		}
+	}()
+	return ch
+}
+
+func expectBlocked(t *testing.T, ch chan func(), msg string) {
+	t.Helper()
+	select {
+	case <-ch:
+		t.Fatalf("%s: expected acquire to block", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func expectGranted(t *testing.T, ch chan func(), msg string) func() {
+	t.Helper()
+	select {
+	case release := <-ch:
+		return release
+	case <-time.After(time.Second):
+		t.Fatalf("%s: expected acquire to be granted", msg)
+		return nil
+	}
+}
+
+func TestScheduler_PerUserLimitLetsOthersThrough(t *testing.T) {
+	s := scheduler.New(3, 2)
+	ctx := context.Background()
+	r1, _ := s.Acquire(ctx, "heavy")
+	r2, _ := s.Acquire(ctx, "heavy")
+
+	heavy := acquireAsync(s, ctx, "heavy")
+	expectBlocked(t, heavy, "heavy user over its share")
+
+	light := acquireAsync(s, ctx, "light")
+	rl := expectGranted(t, light, "light user with free global slot")
+
+	r1()
+	rh := expectGranted(t, heavy, "heavy user after releasing one of its own slots")
+	r2()
+	rh()
+	rl()
+	if inFlight, queued := s.Stats(); inFlight != 0 || queued != 0 {
+		t.Fatalf("expected empty scheduler, got inFlight=%d queued=%d", inFlight, queued)
+	}
+}
+
+func TestScheduler_RoundRobinAcrossWaitingUsers(t *testing.T) {
+	s := scheduler.New(1, 1)
+	ctx := context.Background()
+	first, _ := s.Acquire(ctx, "a")
+
+	a2 := acquireAsync(s, ctx, "a")
+	time.Sleep(20 * time.Millisecond)
+	b1 := acquireAsync(s, ctx, "b")
+	expectBlocked(t, b1, "global limit reached")
+
+	first()
+	ra := expectGranted(t, a2, "a queued first")
+	expectBlocked(t, b1, "slot still held by a")
+	ra()
+	expectGranted(t, b1, "b next in rotation")()
+}
+
+func TestScheduler_CancelledWaiterLeavesQueue(t *testing.T) {
+	s := scheduler.New(1, 1)
+	release, _ := s.Acquire(context.Background(), "u")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(ctx, "u"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if _, queued := s.Stats(); queued != 0 {
+		t.Fatalf("expected cancelled waiter removed, queued=%d", queued)
+	}
+	release()
+	next, err := s.Acquire(context.Background(), "u")
+	if err != nil {
+		t.Fatalf("expected slot after release: %v", err)
+	}
+	next()
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+type mockSearchRepo struct {
+	last  *models.SearchQuery
+	calls int
+}
+
+func (m *mockSearchRepo) Search(userID string, q models.SearchQuery) ([]models.SearchHit, int, error) {
+	m.calls++
+	m.last = &q
+	return []models.SearchHit{{DocumentID: 1, FileName: "report.pdf", Snippet: "the <mark>budget</mark> plan", Rank: 0.5}}, 1, nil
+}
+
+func TestSearchHandler_PassesLanguageCollectionAndPage(t *testing.T) {
+	repo := &mockSearchRepo{}
+	cols := &mockCollectionsRepo{existsForUserFn: func(userID string, id int) (bool, error) { return id == 7, nil }}
+	h := handlers.NewSearchHandler(repo, cols)
+
+	c, w := newTestContext()
+	c.Set("lang", "pt")
+	c.Request = httptest.NewRequest(http.MethodGet, "/search?q=+orçamento+&collectionId=7&limit=25&page=3", nil)
+	h.Search(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+	}
+	q := repo.last
+	if q.Text != "orçamento" || q.Lang != "pt" || q.CollectionID == nil || *q.CollectionID != 7 || q.Limit != 25 || q.Offset != 50 {
+		t.Fatalf("unexpected query %+v", q)
+	}
+	var env envelope
+	decodeEnvelope(t, w, &env)
+	if env.Data["total"].(float64) != 1 || len(env.Data["items"].([]any)) != 1 {
+		t.Fatalf("unexpected payload %+v", env.Data)
+	}
+}
+
+func TestSearchHandler_Validation(t *testing.T) {
+	repo := &mockSearchRepo{}
+	cols := &mockCollectionsRepo{existsForUserFn: func(string, int) (bool, error) { return false, nil }}
+	h := handlers.NewSearchHandler(repo, cols)
+
+	cases := []struct {
+		url  string
+		want int
+	}{
+		{"/search?q=%20%20", http.StatusBadRequest},
+		{"/search?q=" + strings.Repeat("a", 300), http.StatusBadRequest},
+		{"/search?q=x&collectionId=abc", http.StatusBadRequest},
+		{"/search?q=x&collectionId=9", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		c, w := newTestContext()
+		c.Request = httptest.NewRequest(http.MethodGet, tc.url, nil)
+		h.Search(c)
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d got %d", tc.url, tc.want, w.Code)
+		}
+	}
+	if repo.calls != 0 {
+		t.Fatalf("expected no search for invalid requests")
+	}
+}
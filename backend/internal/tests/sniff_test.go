@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/samusafe/genericapi/internal/i18n"
+	"github.com/samusafe/genericapi/internal/services"
+	"github.com/samusafe/genericapi/internal/sniff"
+)
+
+func buildZip(t *testing.T, entries ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip create: %v", err)
+		}
+		w.Write([]byte("<xml/>"))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestSniff_Detect(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"pdf", []byte("%PDF-1.4\n%âãÏÓ\n1 0 obj"), sniff.TypePDF},
+		{"pdf after bom and whitespace", []byte("\xef\xbb\xbf\r\n%PDF-1.7\n"), sniff.TypePDF},
+		{"pdf behind binary preamble", []byte("\x00\x01junk%PDF-1.4\n"), sniff.TypePDF},
+		{"text mentioning pdf marker", []byte("notes: files start with %PDF-1.4 as header\n"), sniff.TypeText},
+		{"docx", buildZip(t, "[Content_Types].xml", "word/document.xml"), sniff.TypeDOCX},
+		{"plain zip", buildZip(t, "readme.txt"), sniff.TypeZIP},
+		{"utf8 text", []byte("olá mundo\n"), sniff.TypeText},
+		{"latin1 text", []byte("caf\xe9"), sniff.TypeText},
+		{"windows executable", []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff"), sniff.TypeUnknown},
+	}
+	for _, tc := range cases {
+		if got := sniff.Detect(bytes.NewReader(tc.data), int64(len(tc.data))); got != tc.want {
+			t.Errorf("%s: expected %s got %s", tc.name, tc.want, got)
+		}
+	}
+	if sniff.Matches(".docx", sniff.TypeZIP) || !sniff.Matches(".md", sniff.TypeText) {
+		t.Fatalf("unexpected extension matching")
+	}
+}
+
+func TestAnalyzerService_RejectsRenamedExecutable(t *testing.T) {
+	repo := &mockRepo{}
+	py := &mockPythonClient{respBody: `{"summary":"ok","fullText":"x"}`}
+	service := services.NewAnalyzerServiceFull(repo, py, nil)
+
+	res := service.AnalyzeContent(context.Background(), "report.pdf", []byte("MZ\x90\x00\x03\x00\x00\x00"), "en", "user", nil, nil, nil, services.AnalyzeOptions{})
+	if res.Error != i18n.GetMessage("en", "FileContentMismatch") {
+		t.Fatalf("expected FileContentMismatch, got %+v", res)
+	}
+	if repo.insertDocCalls != 0 {
+		t.Fatalf("expected nothing stored for a rejected file")
+	}
+
+	res = service.AnalyzeContent(context.Background(), "report.docx", buildZip(t, "word/document.xml"), "en", "user", nil, nil, nil, services.AnalyzeOptions{})
+	if res.Error != "" || repo.lastDetectedType != sniff.TypeDOCX {
+		t.Fatalf("expected docx accepted and detected type stored, got res=%+v type=%q", res, repo.lastDetectedType)
+	}
+}
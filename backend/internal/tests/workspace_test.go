@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samusafe/genericapi/internal/config"
+	"github.com/samusafe/genericapi/internal/handlers"
+	"github.com/samusafe/genericapi/internal/i18n"
+	"github.com/samusafe/genericapi/internal/models"
+	"github.com/samusafe/genericapi/internal/repositories"
+	"github.com/samusafe/genericapi/internal/services"
+	"github.com/samusafe/genericapi/internal/workspace"
+)
+
+type mockWorkspaceRepo struct {
+	imported *models.WorkspaceArchive
+	dryRun   bool
+}
+
+func (m *mockWorkspaceRepo) ExportWorkspace(ctx context.Context, userID string, sink repositories.WorkspaceSink) error {
+	colID, correct := 7, 1
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	sections := []struct {
+		name    string
+		records []any
+	}{
+		{"collections", []any{models.WorkspaceCollection{ID: colID, Name: "Finance", CreatedAt: created}}},
+		{"documents", []any{
+			models.WorkspaceDocument{ID: 1, CollectionID: &colID, FileName: "report.pdf", FullText: "Revenue grew.", ContentHash: "abc", CreatedAt: created},
+			models.WorkspaceDocument{ID: 2, FileName: "notes.md", FullText: "Loose notes."},
+		}},
+		{"analyses", []any{models.WorkspaceAnalysis{ID: 11, DocumentID: 1, Summary: "Revenue up", Keywords: []string{"revenue"}, AnalysisVersion: 1, Backend: "python"}}},
+		{"quizQuestions", []any{models.WorkspaceQuizQuestion{ID: 21, AnalysisID: 11, QuizVersion: 1, Type: models.QuizTypeTrueFalse, Question: "Revenue grew?", Answer: "True", Options: []string{"True", "False"}, CorrectIndex: &correct}}},
+	}
+	for _, s := range sections {
+		if err := sink.Section(s.name); err != nil {
+			return err
+		}
+		for _, r := range s.records {
+			if err := sink.Add(r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *mockWorkspaceRepo) ImportWorkspace(ctx context.Context, userID string, a *models.WorkspaceArchive, dryRun bool) (*models.ImportReport, error) {
+	m.imported, m.dryRun = a, dryRun
+	return &models.ImportReport{
+		DryRun:      dryRun,
+		Collections: models.ImportCounts{Existing: len(a.Collections)},
+		Documents:   models.ImportCounts{Created: len(a.Documents) - 1, Existing: 1},
+		Conflicts:   []models.ImportConflict{{Kind: "document", ArchiveID: 1, Name: "report.pdf", ExistingID: 40}},
+	}, nil
+}
+
+func exportedWorkspace(t *testing.T, h *handlers.WorkspaceHandler) []byte {
+	t.Helper()
+	c, w := newTestContext()
+	c.Request = httptest.NewRequest(http.MethodGet, "/me/export", nil)
+	h.Export(c)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/gzip" {
+		t.Fatalf("expected gzip export, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), ".json.gz") {
+		t.Fatalf("unexpected disposition %s", w.Header().Get("Content-Disposition"))
+	}
+	return w.Body.Bytes()
+}
+
+func importWorkspace(h *handlers.WorkspaceHandler, query string, body []byte) *httptest.ResponseRecorder {
+	c, w := newTestContext()
+	c.Request = httptest.NewRequest(http.MethodPost, "/me/import"+query, bytes.NewReader(body))
+	h.Import(c)
+	return w
+}
+
+func TestWorkspace_ExportRoundTrip(t *testing.T) {
+	h := handlers.NewWorkspaceHandler(services.NewWorkspaceService(&mockWorkspaceRepo{}))
+
+	a, err := workspace.Read(bytes.NewReader(exportedWorkspace(t, h)), config.WorkspaceImportMaxBytes)
+	if err != nil {
+		t.Fatalf("read exported archive: %v", err)
+	}
+	if a.Format != workspace.Format || a.Version != workspace.Version || a.ExportedAt.IsZero() {
+		t.Fatalf("unexpected header %+v", a)
+	}
+	if len(a.Collections) != 1 || len(a.Documents) != 2 || len(a.Analyses) != 1 || len(a.QuizQuestions) != 1 {
+		t.Fatalf("unexpected sections %+v", a)
+	}
+	if *a.Documents[0].CollectionID != 7 || a.Documents[1].CollectionID != nil || *a.QuizQuestions[0].CorrectIndex != 1 {
+		t.Fatalf("references lost in round trip: %+v %+v", a.Documents, a.QuizQuestions)
+	}
+}
+
+func TestWorkspace_ImportDryRun(t *testing.T) {
+	i18n.Init()
+	repo := &mockWorkspaceRepo{}
+	h := handlers.NewWorkspaceHandler(services.NewWorkspaceService(repo))
+
+	w := importWorkspace(h, "?dryRun=true", exportedWorkspace(t, h))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	if !repo.dryRun || repo.imported == nil || len(repo.imported.Documents) != 2 {
+		t.Fatalf("expected dry run of the full archive, got dryRun=%v archive=%+v", repo.dryRun, repo.imported)
+	}
+	var env envelope
+	decodeEnvelope(t, w, &env)
+	if env.Data["dryRun"] != true || len(env.Data["conflicts"].([]any)) != 1 {
+		t.Fatalf("unexpected report %+v", env.Data)
+	}
+
+	// Plain JSON archives are accepted as well.
+	plain := `{"format":"docanalyzer-workspace","version":1,"collections":[],"documents":[{"id":1,"fileName":"a.txt"}]}`
+	if w := importWorkspace(h, "", []byte(plain)); w.Code != http.StatusOK || repo.dryRun {
+		t.Fatalf("expected plain JSON import, got %d dryRun=%v", w.Code, repo.dryRun)
+	}
+}
+
+func TestWorkspace_ImportRejectsInvalidArchives(t *testing.T) {
+	i18n.Init()
+	repo := &mockWorkspaceRepo{}
+	h := handlers.NewWorkspaceHandler(services.NewWorkspaceService(repo))
+
+	cases := []struct {
+		name, query, body string
+		status            int
+		message           string
+	}{
+		{"not json", "", "PK\x03\x04", http.StatusBadRequest, i18n.GetMessage("en", "InvalidWorkspaceArchive")},
+		{"wrong format", "", `{"format":"other","version":1}`, http.StatusBadRequest, i18n.GetMessage("en", "InvalidWorkspaceArchive")},
+		{"newer version", "", `{"format":"docanalyzer-workspace","version":99}`, http.StatusBadRequest, i18n.GetMessage("en", "UnsupportedWorkspaceVersion")},
+		{"dangling analysis", "", `{"format":"docanalyzer-workspace","version":1,"analyses":[{"id":1,"documentId":5}]}`, http.StatusBadRequest, i18n.GetMessage("en", "InvalidWorkspaceArchive")},
+		{"duplicate document", "", `{"format":"docanalyzer-workspace","version":1,"documents":[{"id":1,"fileName":"a"},{"id":1,"fileName":"b"}]}`, http.StatusBadRequest, i18n.GetMessage("en", "InvalidWorkspaceArchive")},
+		{"bad dryRun", "?dryRun=maybe", `{}`, http.StatusBadRequest, i18n.GetMessage("en", "InvalidRequest")},
+	}
+	for _, tc := range cases {
+		w := importWorkspace(h, tc.query, []byte(tc.body))
+		var env envelope
+		decodeEnvelope(t, w, &env)
+		if w.Code != tc.status || env.Message != tc.message {
+			t.Fatalf("%s: expected %d %q, got %d %q", tc.name, tc.status, tc.message, w.Code, env.Message)
+		}
+	}
+	if repo.imported != nil {
+		t.Fatalf("invalid archives must not reach the repository")
+	}
+}
+
+func TestWorkspace_ImportTooLarge(t *testing.T) {
+	i18n.Init()
+	prev := config.WorkspaceImportMaxBytes
+	config.WorkspaceImportMaxBytes = 64
+	defer func() { config.WorkspaceImportMaxBytes = prev }()
+
+	h := handlers.NewWorkspaceHandler(services.NewWorkspaceService(&mockWorkspaceRepo{}))
+	big := []byte(`{"format":"docanalyzer-workspace","version":1,"documents":[{"id":1,"fileName":"` + strings.Repeat("x", 4096) + `"}]}`)
+
+	if w := importWorkspace(h, "", big); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWorkspace_ImportGzipBomb(t *testing.T) {
+	i18n.Init()
+	prev := config.WorkspaceImportMaxBytes
+	config.WorkspaceImportMaxBytes = 64 << 10
+	defer func() { config.WorkspaceImportMaxBytes = prev }()
+
+	// 16MB of spaces compress to a few KB: well under the body limit, far over it decompressed
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	zw.Write([]byte(`{"format":"docanalyzer-workspace","version":1,"documents":[{"id":1,"fileName":"a"`))
+	zw.Write(bytes.Repeat([]byte(" "), 16<<20))
+	zw.Write([]byte(`}]}`))
+	zw.Close()
+	if int64(body.Len()) >= config.WorkspaceImportMaxBytes {
+		t.Fatalf("compressed payload should fit the body limit, got %d bytes", body.Len())
+	}
+
+	repo := &mockWorkspaceRepo{}
+	h := handlers.NewWorkspaceHandler(services.NewWorkspaceService(repo))
+	if w := importWorkspace(h, "", body.Bytes()); w.Code != http.StatusRequestEntityTooLarge || repo.imported != nil {
+		t.Fatalf("expected 413 without import, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWorkspace_WriterReportsSinkErrors(t *testing.T) {
+	failing := errors.New("disk full")
+	aw := workspace.NewWriter(errWriter{failing}, time.Now())
+	if err := aw.Section("collections"); !errors.Is(err, failing) {
+		t.Fatalf("expected write error, got %v", err)
+	}
+	if err := aw.Close(); !errors.Is(err, failing) {
+		t.Fatalf("expected sticky write error, got %v", err)
+	}
+}
+
+type errWriter struct{ err error }
+
+func (w errWriter) Write(p []byte) (int, error) { return 0, w.err }
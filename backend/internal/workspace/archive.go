@@ -0,0 +1,174 @@
+// Package workspace reads and writes the workspace archive: one JSON object (gzip-compressed on
+// export) holding a user's collections, documents, analyses and quiz questions.
+//
+// Version history:
+//   - 1: collections, documents (with extracted text), analyses and quiz questions.
+//
+// Readers accept every version up to Version; plain (uncompressed) JSON is accepted too.
+package workspace
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/samusafe/genericapi/internal/models"
+)
+
+const (
+	// Format identifies the archive kind.
+	Format = "docanalyzer-workspace"
+	// Version is the archive version written by this build.
+	Version = 1
+)
+
+var (
+	ErrInvalidArchive     = errors.New("invalid workspace archive")
+	ErrUnsupportedVersion = errors.New("unsupported workspace archive version")
+	// ErrArchiveTooLarge means the decompressed archive exceeds the limit given to Read.
+	ErrArchiveTooLarge = errors.New("workspace archive too large")
+)
+
+// Writer streams an archive section by section, one record at a time, so a large workspace is
+// never held in memory. Sections must be written in archive order and each at most once.
+type Writer struct {
+	w       io.Writer
+	err     error
+	inItems bool
+	count   int
+}
+
+// NewWriter writes the archive header.
+func NewWriter(w io.Writer, exportedAt time.Time) *Writer {
+	wr := &Writer{w: w}
+	stamp, _ := json.Marshal(exportedAt.UTC())
+	wr.write(fmt.Sprintf(`{"format":%q,"version":%d,"exportedAt":%s`, Format, Version, stamp))
+	return wr
+}
+
+func (w *Writer) write(s string) {
+	if w.err == nil {
+		_, w.err = io.WriteString(w.w, s)
+	}
+}
+
+// Section starts the named record list (e.g. "documents").
+func (w *Writer) Section(name string) error {
+	w.endSection()
+	w.write(fmt.Sprintf(`,%q:[`, name))
+	w.inItems, w.count = true, 0
+	return w.err
+}
+
+// Add appends one record to the current section.
+func (w *Writer) Add(record any) error {
+	if w.err != nil {
+		return w.err
+	}
+	b, err := json.Marshal(record)
+	if err != nil {
+		w.err = err
+		return err
+	}
+	if w.count > 0 {
+		w.write(",\n")
+	} else {
+		w.write("\n")
+	}
+	w.count++
+	w.write(string(b))
+	return w.err
+}
+
+func (w *Writer) endSection() {
+	if w.inItems {
+		w.write("]")
+		w.inItems = false
+	}
+}
+
+// Close ends the archive; it does not close the underlying writer.
+func (w *Writer) Close() error {
+	w.endSection()
+	w.write("}\n")
+	return w.err
+}
+
+// Read decodes and validates an archive (gzip or plain JSON). A gzip archive is decompressed up to
+// maxBytes; beyond that Read returns ErrArchiveTooLarge, so a small compression bomb cannot fill
+// memory.
+func Read(r io.Reader, maxBytes int64) (*models.WorkspaceArchive, error) {
+	br := bufio.NewReader(r)
+	var src io.Reader = br
+	var limited *io.LimitedReader
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		defer zr.Close()
+		limited = &io.LimitedReader{R: zr, N: maxBytes + 1}
+		src = limited
+	}
+	var a models.WorkspaceArchive
+	if err := json.NewDecoder(src).Decode(&a); err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			return nil, err
+		case limited != nil && limited.N <= 0:
+			return nil, fmt.Errorf("%w: more than %d bytes decompressed", ErrArchiveTooLarge, maxBytes)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if err := Validate(&a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Validate checks the header, that IDs are unique per kind and that every reference resolves
+// inside the archive.
+func Validate(a *models.WorkspaceArchive) error {
+	if a.Format != Format {
+		return fmt.Errorf("%w: format %q", ErrInvalidArchive, a.Format)
+	}
+	if a.Version < 1 || a.Version > Version {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, a.Version)
+	}
+	collections := make(map[int]bool, len(a.Collections))
+	for _, c := range a.Collections {
+		if collections[c.ID] || strings.TrimSpace(c.Name) == "" {
+			return fmt.Errorf("%w: collection %d", ErrInvalidArchive, c.ID)
+		}
+		collections[c.ID] = true
+	}
+	documents := make(map[int]bool, len(a.Documents))
+	for _, d := range a.Documents {
+		if documents[d.ID] || d.FileName == "" || (d.CollectionID != nil && !collections[*d.CollectionID]) {
+			return fmt.Errorf("%w: document %d", ErrInvalidArchive, d.ID)
+		}
+		documents[d.ID] = true
+	}
+	analyses := make(map[int]bool, len(a.Analyses))
+	for _, an := range a.Analyses {
+		if analyses[an.ID] || !documents[an.DocumentID] {
+			return fmt.Errorf("%w: analysis %d", ErrInvalidArchive, an.ID)
+		}
+		analyses[an.ID] = true
+	}
+	questions := make(map[int]bool, len(a.QuizQuestions))
+	for _, q := range a.QuizQuestions {
+		if questions[q.ID] || !analyses[q.AnalysisID] || q.Question == "" {
+			return fmt.Errorf("%w: quiz question %d", ErrInvalidArchive, q.ID)
+		}
+		questions[q.ID] = true
+	}
+	return nil
+}
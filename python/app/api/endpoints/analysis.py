@@ -1,5 +1,7 @@
-from fastapi import APIRouter, File, UploadFile, HTTPException
-from app.services.analysis_service import analyze_file_content
+from fastapi import APIRouter, Body, File, UploadFile, HTTPException
+from app.services.analysis_service import analyze_file_content, analyze_text
+from app.services.models_loader import analysis_models, model_version, get_summarizer
+from app.services.summarization import local_summarize, heuristic_summary
 
 router = APIRouter()
 
@@ -21,3 +23,38 @@ async def analyze_file(file: UploadFile = File(...)):
         raise
     except Exception as e:
         raise HTTPException(status_code=400, detail=f"Invalid file format or corrupted file: {e}")
+
+
+@router.post("/analyze-text")
+async def analyze_plain_text(text: str = Body(..., embed=True)):
+    """
+    Endpoint to analyze text that was already extracted by the caller
+    (the Go API decodes .txt/.md uploads itself).
+    """
+    if not text or not text.strip():
+        raise HTTPException(status_code=400, detail="Text content is required.")
+    return analyze_text(text)
+
+
+@router.post("/summarize")
+async def summarize(text: str = Body(..., embed=True)):
+    """
+    Endpoint to summarize text without the rest of the analysis
+    (the Go API sends the concatenated summaries of a collection for its digest).
+    Returns 503 if the summarization model is not loaded.
+    """
+    if not text or not text.strip():
+        raise HTTPException(status_code=400, detail="Text content is required.")
+    if get_summarizer() is None:
+        raise HTTPException(status_code=503, detail="Summarization model is not available.")
+    return {"summary": local_summarize(text) or heuristic_summary(text)}
+
+
+@router.get("/models")
+async def models():
+    """
+    Models currently behind /analyze. The Go API compares "modelVersion" with the one stored on
+    an analysis before reusing it.
+    """
+    current = analysis_models()
+    return {"modelVersion": model_version(current), "models": current}
@@ -0,0 +1,18 @@
+from fastapi import APIRouter, Body, HTTPException
+from app.services.embeddings import embed_text
+
+router = APIRouter()
+
+@router.post("/embed")
+async def embed(text: str = Body(..., embed=True)):
+    """
+    Endpoint to compute a document embedding for similarity search.
+    Returns 503 if the sentence model is not loaded.
+    """
+    if not text or not text.strip():
+        raise HTTPException(status_code=400, detail="Text content is required.")
+
+    result = embed_text(text)
+    if result is None:
+        raise HTTPException(status_code=503, detail="Embedding model is not available.")
+    return result
@@ -0,0 +1,21 @@
+from fastapi import APIRouter, Body, HTTPException
+from app.services.question_answering import answer_question
+
+router = APIRouter()
+
+@router.post("/answer")
+async def answer(question: str = Body(...), contexts: list[dict] = Body(...)):
+    """
+    Endpoint to answer a question from passages retrieved by the caller.
+    Each context is {"id": int, "text": str}; citations refer to those ids.
+    Returns 503 if the question answering model is not loaded.
+    """
+    if not question or not question.strip():
+        raise HTTPException(status_code=400, detail="Question is required.")
+    if not contexts:
+        raise HTTPException(status_code=400, detail="At least one context is required.")
+
+    result = answer_question(question, contexts)
+    if result is None:
+        raise HTTPException(status_code=503, detail="Question answering model is not available.")
+    return result
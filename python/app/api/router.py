@@ -1,8 +1,10 @@
 from fastapi import APIRouter
-from app.api.endpoints import analysis, quiz, health
+from app.api.endpoints import analysis, quiz, health, embeddings, qa
 
 api_router = APIRouter()
 
 api_router.include_router(analysis.router, tags=["Analysis"])
 api_router.include_router(quiz.router, tags=["Quiz"])
+api_router.include_router(embeddings.router, tags=["Embeddings"])
+api_router.include_router(qa.router, tags=["Question Answering"])
 api_router.include_router(health.router, tags=["Health"])
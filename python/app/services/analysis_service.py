@@ -1,32 +1,84 @@
 from .text_processing import extract_text
 from .keywords_sentiment import analyze_sentiment, extract_keywords
 from .summarization import local_summarize, heuristic_summary
+from .models_loader import analysis_models, model_version, get_summarizer, SUMMARIZER_MODEL_NAME
+from langdetect import DetectorFactory, detect
 import re
+import time
 
-def analyze_text(text: str) -> dict:
+DetectorFactory.seed = 0  # langdetect is randomized; keep the detected language stable
+
+
+def _elapsed_ms(start: float) -> float:
+    return round((time.perf_counter() - start) * 1000, 1)
+
+
+def detect_language(text: str) -> str | None:
+    try:
+        return detect(text[:5000])
+    except Exception:
+        return None
+
+
+def count_tokens(text: str) -> tuple[int, str]:
+    """Tokens as the summarizer sees them, or whitespace-separated words when it is not loaded."""
+    summarizer = get_summarizer()
+    if summarizer is not None:
+        try:
+            return len(summarizer.tokenizer.encode(text)), SUMMARIZER_MODEL_NAME
+        except Exception:
+            pass
+    return len(text.split()), "whitespace"
+
+
+def analyze_text(text: str, timings: dict | None = None) -> dict:
     """
     Analyzes the given text to extract sentiment, keywords, and a structured summary.
     This version uses only local models to ensure zero cost and provides a more
-    study-friendly output.
+    study-friendly output. The "meta" block records which models produced the result,
+    the detected language, token counts and how long each stage took.
     """
+    timings = dict(timings or {})
+    total = time.perf_counter()
+
+    start = time.perf_counter()
     sentiment = analyze_sentiment(text)
+    timings['sentiment'] = _elapsed_ms(start)
+
+    start = time.perf_counter()
     keywords = extract_keywords(text)
-    
+    timings['keywords'] = _elapsed_ms(start)
+
     # Generate summary using the local, improved summarization function
+    start = time.perf_counter()
     summary_paragraph = local_summarize(text) or heuristic_summary(text)
-    
+    timings['summary'] = _elapsed_ms(start)
+
     # Generate bullet points from the summary paragraph by splitting it into sentences.
     summary_points = [s.strip() for s in re.split(r'(?<=[.!?])\s+', summary_paragraph) if s.strip()]
 
+    input_tokens, tokenizer = count_tokens(text)
+    summary_tokens, _ = count_tokens(summary_paragraph)
+    timings['total'] = round(timings.get('extract', 0) + _elapsed_ms(total), 1)
+
+    models = analysis_models()
     return {
         'summary': summary_paragraph,
         'summary_points': summary_points,
         'keywords': keywords,
         'sentiment': sentiment,
         'fullText': text,
+        'meta': {
+            'modelVersion': model_version(models),
+            'models': models,
+            'language': detect_language(text),
+            'tokens': {'input': input_tokens, 'summary': summary_tokens, 'tokenizer': tokenizer},
+            'timingsMs': timings,
+        },
     }
 
 
 def analyze_file_content(raw: bytes, filename: str) -> dict:
+    start = time.perf_counter()
     text = extract_text(raw, filename)
-    return analyze_text(text)
+    return analyze_text(text, {'extract': _elapsed_ms(start)})
@@ -0,0 +1,44 @@
+import os
+import numpy as np
+from .models_loader import get_keybert_model, KEYBERT_MODEL_NAME
+
+# Sentence-transformer models truncate long inputs, so the text is embedded in chunks and the
+# chunk vectors are mean-pooled. EMBED_MAX_CHUNKS bounds the cost for very long documents.
+CHUNK_CHARS = int(os.getenv("EMBED_CHUNK_CHARS", "1000"))
+MAX_CHUNKS = int(os.getenv("EMBED_MAX_CHUNKS", "32"))
+
+
+def _chunks(text: str) -> list[str]:
+    words = text.split()
+    chunks, current, size = [], [], 0
+    for word in words:
+        if size + len(word) > CHUNK_CHARS and current:
+            chunks.append(" ".join(current))
+            current, size = [], 0
+            if len(chunks) >= MAX_CHUNKS:
+                break
+        current.append(word)
+        size += len(word) + 1
+    if current and len(chunks) < MAX_CHUNKS:
+        chunks.append(" ".join(current))
+    return chunks
+
+
+def embed_text(text: str) -> dict | None:
+    """
+    Returns a unit-length document embedding computed with the KeyBERT sentence model,
+    or None when the model is not loaded.
+    """
+    model = get_keybert_model()
+    if model is None:
+        return None
+    vectors = np.asarray(model.model.embed(_chunks(text)), dtype=np.float32)
+    vector = vectors.mean(axis=0)
+    norm = np.linalg.norm(vector)
+    if norm > 0:
+        vector = vector / norm
+    return {
+        'embedding': vector.tolist(),
+        'model': KEYBERT_MODEL_NAME,
+        'dimensions': int(vector.shape[0]),
+    }
@@ -1,5 +1,8 @@
 import os
 import torch
+import transformers
+import keybert
+import textblob
 from transformers import pipeline
 from keybert import KeyBERT
 
@@ -12,17 +15,21 @@ except Exception:  # pragma: no cover
 SUMMARIZER_MODEL_NAME = os.getenv("SUMMARIZER_MODEL", "facebook/bart-large-cnn")
 KEYBERT_MODEL_NAME = os.getenv("KEYBERT_MODEL", "all-MiniLM-L6-v2")
 QG_MODEL_NAME = os.getenv("QG_MODEL", "valhalla/t5-base-qg-hl")
+READER_MODEL_NAME = os.getenv("READER_MODEL", "distilbert-base-cased-distilled-squad")
+# Overrides the derived model version, e.g. to make the API re-analyze after a fine-tune.
+ANALYSIS_MODEL_VERSION = os.getenv("ANALYSIS_MODEL_VERSION", "")
 
 
 # Global holders
 _SUMMARIZER = None
 _QA_PIPELINE = None
 _KEYBERT_MODEL = None
+_READER = None
 
 
 def load_models():
     """Idempotent loader for heavy models. Called at startup."""
-    global _SUMMARIZER, _QA_PIPELINE, _KEYBERT_MODEL
+    global _SUMMARIZER, _QA_PIPELINE, _KEYBERT_MODEL, _READER
 
     if _SUMMARIZER is None:
         try:
@@ -58,6 +65,48 @@ def load_models():
         else:
             print("⚠️ Transformers QG dependencies missing; skipping quiz model.")
 
+    if _READER is None:
+        try:
+            print(f"❓ Loading question answering model: {READER_MODEL_NAME}...")
+            device = 0 if torch.cuda.is_available() else -1
+            _READER = pipeline("question-answering", model=READER_MODEL_NAME, device=device)
+            print("✅ Question answering model loaded.")
+        except Exception as e:  # pragma: no cover
+            print(f"❌ Question answering model load failed: {e}")
+            _READER = None
+
+
+def _revision(pipe) -> str:
+    """Hub commit of a pipeline's weights, or the transformers version when it was not recorded."""
+    commit = getattr(getattr(pipe.model, "config", None), "_commit_hash", None)
+    return commit or f"transformers-{transformers.__version__}"
+
+
+def analysis_models() -> dict:
+    """
+    Name and version of each model behind /analyze. A model that failed to load is reported as
+    "unavailable", so results produced by the fallbacks do not look current once it is back.
+    """
+    return {
+        "summarizer": {
+            "name": SUMMARIZER_MODEL_NAME,
+            "version": _revision(_SUMMARIZER) if _SUMMARIZER is not None else "unavailable",
+        },
+        "keywords": {
+            "name": KEYBERT_MODEL_NAME,
+            "version": f"keybert-{keybert.__version__}" if _KEYBERT_MODEL is not None else "unavailable",
+        },
+        "sentiment": {"name": "textblob", "version": textblob.__version__},
+    }
+
+
+def model_version(models: dict | None = None) -> str:
+    """Single string identifying the analysis models; the API compares it before reusing a result."""
+    if ANALYSIS_MODEL_VERSION:
+        return ANALYSIS_MODEL_VERSION
+    models = models or analysis_models()
+    return ";".join(f"{stage}={m['name']}@{m['version']}" for stage, m in sorted(models.items()))
+
 
 def get_summarizer():
     return _SUMMARIZER
@@ -67,3 +116,6 @@ def get_keybert_model():
 
 def get_qa_pipeline():
     return _QA_PIPELINE
+
+def get_reader():
+    return _READER
@@ -0,0 +1,42 @@
+from .models_loader import get_reader
+
+# Other passages are cited too when their best span scores at least this share of the answer's.
+CITATION_SCORE_RATIO = 0.5
+MAX_CITATIONS = 3
+
+
+def answer_question(question: str, contexts: list[dict]) -> dict | None:
+    """
+    Extractive QA: runs the reader over every retrieved passage and returns the best span.
+    Offsets are character offsets into the passage text, so the caller can map them back to the
+    document. Returns None when the model is not loaded.
+    """
+    reader = get_reader()
+    if reader is None:
+        return None
+
+    spans = []
+    for ctx in contexts:
+        text = ctx.get('text') or ''
+        if not text.strip():
+            continue
+        result = reader(question=question, context=text)
+        spans.append({
+            'contextId': ctx['id'],
+            'answer': result['answer'],
+            'score': float(result['score']),
+            'start': int(result['start']),
+            'end': int(result['end']),
+        })
+
+    if not spans:
+        return {'answer': '', 'score': 0.0, 'citations': []}
+
+    spans.sort(key=lambda s: s['score'], reverse=True)
+    best = spans[0]
+    cited = [s for s in spans if s['score'] >= best['score'] * CITATION_SCORE_RATIO][:MAX_CITATIONS]
+    return {
+        'answer': best['answer'],
+        'score': best['score'],
+        'citations': [{'contextId': s['contextId'], 'start': s['start'], 'end': s['end']} for s in cited],
+    }
@@ -1,12 +1,32 @@
 import random
+import re
 from sentence_splitter import SentenceSplitter
 from .models_loader import get_qa_pipeline, get_keybert_model
 
 # Heuristic strategy: prefer model-based QG; if unavailable, derive cloze (named entity masking)
 # or simple True/blank questions from semantically meaningful sentences.
+#
+# Other formats are built from the document's keyphrases (KeyBERT): cloze questions blank a
+# keyphrase out of a sentence, multiple-choice questions offer it among other keyphrases of the
+# document, and true/false statements swap it for another keyphrase half of the time.
+# Difficulty picks the sentences: short ones for easy, long ones for hard.
 
+QUIZ_TYPES = ("open", "multiple_choice", "true_false", "cloze")
+DIFFICULTIES = ("easy", "medium", "hard")
+BLANK = "_____"
+MC_OPTIONS = {"easy": 3, "medium": 4, "hard": 5}
 
-def generate_quiz(text: str, num_questions: int = 5):
+
+def generate_quiz(text: str, num_questions: int = 5, quiz_type: str = "open", difficulty: str = "medium"):
+    if quiz_type == "open":
+        quiz = _open_quiz(text, num_questions)
+        for q in quiz["quiz"]:
+            q["type"] = "open"
+        return quiz
+    return {"quiz": _keyphrase_quiz(text, num_questions, quiz_type, difficulty)}
+
+
+def _open_quiz(text: str, num_questions: int):
     qa = get_qa_pipeline()
     if not qa:
         return fallback_quiz(text, num_questions)
@@ -38,6 +58,75 @@ def generate_quiz(text: str, num_questions: int = 5):
     return {"quiz": qa_pairs[:num_questions]}
 
 
+def _sentences_by_difficulty(text: str, difficulty: str) -> list[str]:
+    sentences = [s.strip() for s in SentenceSplitter(language='en').split(text=text)]
+    sentences = [s for s in sentences if 6 <= len(s.split()) < 100]
+    if difficulty == "easy":
+        sentences.sort(key=len)
+    elif difficulty == "hard":
+        sentences.sort(key=len, reverse=True)
+    else:
+        random.shuffle(sentences)
+    return sentences
+
+
+def _keyphrases(text: str, top_n: int = 30) -> list[str]:
+    kw_model = get_keybert_model()
+    if not kw_model:
+        # No model: the longest distinct words stand in for keyphrases.
+        words = {w.lower() for w in re.findall(r"[^\W\d_]{6,}", text)}
+        return sorted(words, key=len, reverse=True)[:top_n]
+    try:
+        return [kw for kw, _ in kw_model.extract_keywords(
+            text, keyphrase_ngram_range=(1, 2), stop_words='english', top_n=top_n)]
+    except Exception:
+        return []
+
+
+def _find(sentence: str, phrase: str):
+    return re.search(r"\b" + re.escape(phrase) + r"\b", sentence, flags=re.IGNORECASE)
+
+
+def _keyphrase_quiz(text: str, num_questions: int, quiz_type: str, difficulty: str) -> list[dict]:
+    phrases = _keyphrases(text)
+    if not phrases:
+        return []
+    questions, used = [], set()
+    for sentence in _sentences_by_difficulty(text, difficulty):
+        if len(questions) >= num_questions:
+            break
+        phrase = next((p for p in phrases if p not in used and _find(sentence, p)), None)
+        if phrase is None:
+            continue
+        match = _find(sentence, phrase)
+        others = [p for p in phrases if p != phrase and not _find(sentence, p)]
+        q = _build(sentence, match, phrase, others, quiz_type, difficulty)
+        if q:
+            used.add(phrase)
+            questions.append(q)
+    return questions
+
+
+def _build(sentence, match, phrase, others, quiz_type, difficulty):
+    cloze = sentence[:match.start()] + BLANK + sentence[match.end():]
+    if quiz_type == "cloze":
+        return {"type": "cloze", "question": cloze, "answer": match.group(0)}
+    if quiz_type == "multiple_choice":
+        distractors = random.sample(others, min(len(others), MC_OPTIONS[difficulty] - 1))
+        if not distractors:
+            return None
+        # Options are all keyphrases, so the answer's casing in the sentence cannot give it away.
+        options = distractors + [phrase]
+        random.shuffle(options)
+        return {"type": "multiple_choice", "question": cloze, "options": options,
+                "correctIndex": options.index(phrase)}
+    # true_false
+    if others and random.random() < 0.5:
+        statement = sentence[:match.start()] + random.choice(others) + sentence[match.end():]
+        return {"type": "true_false", "question": statement, "answer": "False"}
+    return {"type": "true_false", "question": sentence, "answer": "True"}
+
+
 def fallback_quiz(text: str, num_questions: int = 5):
     keybert_model = get_keybert_model()
     if not keybert_model: